@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/piyushjajoo/crud/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+var apiKey pkg.APIKey
+var apiKeyFile string
+var apiKeyRotate time.Duration
+
+// apiKeyCmd represents the add apikey command
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey <client id>",
+	Short: "apikey adds an api key of the client to the api key file of the micro-service",
+	Long: `
+Apikey command adds an api key to the micro-service scaffolded with crud init --auth apikey.
+The key is printed once, only its SHA-256 hash is appended to the api key file, api-keys.json by default,
+which the service reads from API_KEYS_FILE. The file is read again when it changes, no restart is needed.
+
+The client id is the subject of the requests authenticated with the key, the owner of the resources they create.
+The scopes and roles granted to the key are provided with --scope and --role.
+The requests of the client are limited to --rate-limit per second with bursts of --burst, the API_KEY_RATE_LIMIT
+and API_KEY_BURST of the service are used when they are not provided.
+
+To rotate the key of a client add a new key with --rotate <duration>, the previous keys of the client expire
+after the duration so the client can switch to the new key. Remove the entry from the file to revoke a key.
+`,
+	Example: "crud add apikey ci-pipeline --scope items:read --role reader --rate-limit 5 --rotate 24h",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cobra.CheckErr(fmt.Errorf("apikey needs the client id"))
+		}
+
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+
+		project, err := pkg.LoadProject(wd)
+		cobra.CheckErr(err)
+
+		apiKey.ID = args[0]
+		key, entry, err := project.NewAPIKey(apiKey)
+		cobra.CheckErr(err)
+		cobra.CheckErr(project.AddAPIKey(apiKeyFile, entry, apiKeyRotate))
+		fmt.Printf("API key of %s is added to %s, it is not stored and can't be shown again\n%s\n", entry.ID, apiKeyFile, key)
+	},
+}
+
+func init() {
+	addCmd.AddCommand(apiKeyCmd)
+
+	apiKeyCmd.Flags().StringSliceVar(&apiKey.Scopes, "scope", nil, "scopes granted to the key (e.g. --scope items:read,items:write)")
+	apiKeyCmd.Flags().StringSliceVar(&apiKey.Roles, "role", nil, "roles granted to the key (e.g. --role admin)")
+	apiKeyCmd.Flags().Float64Var(&apiKey.RateLimit, "rate-limit", 0, "requests per second allowed for the client, API_KEY_RATE_LIMIT of the service if it is 0")
+	apiKeyCmd.Flags().IntVar(&apiKey.Burst, "burst", 0, "requests allowed at once above the rate limit, API_KEY_BURST of the service if the rate limit is 0")
+	apiKeyCmd.Flags().DurationVar(&apiKeyRotate, "rotate", 0, "the previous keys of the client expire after the duration (e.g. --rotate 24h)")
+	apiKeyCmd.Flags().StringVar(&apiKeyFile, "file", pkg.APIKeysFileName, "api key file the key is added to")
+}
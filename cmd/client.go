@@ -0,0 +1,68 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/piyushjajoo/crud/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+var clientLang, clientDir string
+
+// clientCmd represents the gen client command
+var clientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "client generates the client of the api of the micro-service in another language",
+	Long: `
+Client command generates the client of the api of the micro-service scaffolded with crud init, the go client is
+generated in the client directory by crud init and crud add resource.
+
+With --lang ts the typescript interfaces of the models and a fetch client of the resources are generated in
+client/ts, or the directory provided with --dir, for the frontend consumers. The client is generated from the
+resources of the crud.yaml manifest, the same definitions which drive pkg/models, and it is generated again by
+crud add resource so the types never drift.
+`,
+	Example: "crud gen client --lang ts --dir web/src/api",
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(pkg.ValidateClientLang(clientLang)) // validates client language
+
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+
+		project, err := pkg.LoadProject(wd)
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(project.GenerateTSClient(clientDir))
+		fmt.Printf("TypeScript client of %d resources is generated at %s\n", len(project.Resources), project.TSClient)
+	},
+}
+
+func init() {
+	genCmd.AddCommand(clientCmd)
+
+	clientCmd.Flags().StringVar(&clientLang, "lang", pkg.TypeScriptLang, "language of the client, ts")
+	clientCmd.Flags().StringVar(&clientDir, "dir", pkg.DefaultTSClientDir, "directory of the client relative to the project root")
+}
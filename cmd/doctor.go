@@ -0,0 +1,76 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/piyushjajoo/crud/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "doctor checks the prerequisites of crud and the health of the micro-service scaffolded with crud init",
+	Long: `
+Doctor command checks the versions of go, helm, docker and kubectl, that the current directory is writable and
+that GOPROXY, GOFLAGS and the module cache allow the modules of the generated code to be downloaded.
+
+Run from the root directory of a project, where the crud.yaml manifest is, it also checks that the module of
+go.mod matches the manifest, that the files generated from the manifest exist and are up to date, that no file
+has unresolved conflict markers of crud upgrade and that the project compiles.
+
+The checks are ok, warn for the optional tools and settings, or fail, doctor exits with an error if any check
+failed.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(runDoctor(wd, os.Stdout))
+	},
+}
+
+// runDoctor prints the checks of the directory and returns an error if any check failed
+func runDoctor(dir string, out io.Writer) error {
+	failed := 0
+	for _, c := range pkg.Doctor(dir) {
+		if c.Status == pkg.CheckFail {
+			failed++
+		}
+		// the details of the failed builds span lines, they are indented under the check
+		fmt.Fprintf(out, "[%-4s] %-15s %s\n", c.Status, c.Name, strings.ReplaceAll(c.Detail, "\n", "\n                       "))
+	}
+	if failed > 0 {
+		return fmt.Errorf("checks failed: %d", failed)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/piyushjajoo/crud/pkg"
+)
+
+// doctorProject writes a project whose go.mod doesn't match its manifest in a temporary directory
+func doctorProject(t *testing.T) string {
+	t.Helper()
+	p := &pkg.Project{ModuleName: "github.com/acme/shop", ProjectDirName: "shop", AbsolutePath: t.TempDir(), Router: pkg.MuxRouter}
+	if err := p.WriteManifest(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(p.AbsolutePath, "go.mod"), []byte("module github.com/acme/store\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p.AbsolutePath
+}
+
+func TestRunDoctor(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	var out bytes.Buffer
+	err := runDoctor(doctorProject(t), &out)
+	if err == nil || !strings.HasPrefix(err.Error(), "checks failed: ") {
+		t.Fatalf("runDoctor() error = %v, want the failed checks counted\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"[ok  ] directory       ",
+		"[ok  ] manifest        github.com/acme/shop with 0 resources\n",
+		"[fail] go.mod          module github.com/acme/store of go.mod doesn't match module github.com/acme/shop of crud.yaml\n",
+		"[warn] generated files missing, they may have been deleted on purpose: ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't contain %q\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDoctorExitCode(t *testing.T) {
+	// the doctor command runs in the test binary started below, it exits once the checks are printed
+	if dir := os.Getenv("CRUD_TEST_DOCTOR_DIR"); dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		rootCmd.SetArgs([]string{"doctor"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	doctor := exec.Command(os.Args[0], "-test.run=^TestDoctorExitCode$")
+	doctor.Env = append(os.Environ(), "CRUD_TEST_DOCTOR_DIR="+doctorProject(t))
+	out, err := doctor.CombinedOutput()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() == 0 {
+		t.Fatalf("crud doctor error = %v, want a non-zero exit\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "[fail] go.mod") || !strings.Contains(string(out), "Error: checks failed: ") {
+		t.Errorf("crud doctor output doesn't contain the failed check\n%s", out)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/piyushjajoo/crud/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+var specFile string
+var printSchema bool
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate -f <spec file>",
+	Short: "generate creates the micro-service along with its resources from the spec file",
+	Long: `
+Generate command creates the micro-service described in the spec file, its module name, type, router, features and
+resources with their fields, validation rules and references, like crud init followed by crud add resource for
+every resource. The project is created in the directory named after the last element of the module name.
+
+The features are the options of crud init, swagger, chart, makefile, compose, grpc, ci, auth, rateLimit, cache,
+events, outbox, metrics and baseImage. The repositories keep the resources in memory, the outbox stores the events in the
+outbox table of postgres from which the relay publishes them and needs the events. The resources have the options
+of crud add resource, the fields have a name, type, validation rules and the resource they reference, the scopes,
+roles and rate limits are keyed by the action.
+
+If the project already exists, the resources of the spec which are not in its crud.yaml manifest are added, so the
+spec can be kept in the repository and reviewed like code. The features and the existing resources of the spec must
+be the ones of the manifest, crud can't change them.
+
+The json schema of the spec is published at
+` + pkg.SpecSchemaURL + `
+add the comment below at the top of the spec for the completion and validation in the editors with the yaml
+language server, or provide --schema to print it.
+# yaml-language-server: $schema=` + pkg.SpecSchemaURL + `
+`,
+	Example: "crud generate -f service.yaml",
+	Run: func(cmd *cobra.Command, args []string) {
+		if printSchema {
+			schema, err := pkg.SpecSchema()
+			cobra.CheckErr(err)
+			fmt.Print(string(schema))
+			return
+		}
+		if specFile == "" {
+			cobra.CheckErr(fmt.Errorf("generate needs the spec file, provide -f"))
+		}
+
+		spec, err := pkg.LoadSpec(specFile)
+		cobra.CheckErr(err)
+
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+		projectPath := fmt.Sprintf("%s/%s", wd, spec.DirName())
+
+		created, added, err := spec.Generate(projectPath)
+		cobra.CheckErr(err)
+		if created {
+			fmt.Printf("Your micro-service scaffolding is created at\n%s\n", projectPath)
+		}
+		for _, r := range added {
+			fmt.Printf("Resource %s is added, its endpoints are served at /%s\n", r.Name, r.PathName())
+		}
+		if !created && len(added) == 0 {
+			fmt.Printf("The micro-service at %s is up to date with %s\n", projectPath, specFile)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().StringVarP(&specFile, "file", "f", "", "spec file describing the micro-service and its resources")
+	generateCmd.Flags().BoolVar(&printSchema, "schema", false, "to print the json schema of the spec file")
+}
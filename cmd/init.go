@@ -31,50 +31,103 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var api, helm bool
-var name string
+var api, helm, makefile, compose, grpc, rateLimit, outbox, metrics bool
+var name, baseImage, ci, router, cache, events, projectType string
+var auth []string
 
 // initCmd represents the init command
 var initCmd = &cobra.Command{
-	Use:     "init <module name>",
+	Use:     "init [module name]",
 	Short:   "init creates the scaffolding for the go based micro-service",
 	Aliases: []string{"initialize", "initialise", "create"},
 	Long: `
 Init (cobra init) command initializes the go module along with a bare-bone http-server.
 Please make sure you have go installed and GOPATH set. Also make sure you have helm v3 installed as well.
+Run crud doctor to check the prerequisites.
 
 By default if no flags provided it initializes following -
 1. go.mod and go.sum files
-2. Dockerfile to build your micro-service along with build.sh script
-3. main.go with bare http-server written in gorilla mux
+2. multi-stage Dockerfile to build your micro-service along with build.sh script
+3. main.go with bare http-server written in gorilla mux, or the router provided with --router flag
 4. README.md with basic Summary
 
+If no module name is provided on a terminal, the module name and the options are asked interactively, the flags
+provide the defaults of the answers. The options are recorded in crud.yaml along with the init command which
+creates the project again non-interactively.
+
+The router can be one of mux (gorilla mux), chi, stdlib (go 1.22 http.ServeMux), gin or echo.
 If you want api documentation provide --swagger flag. If you want helm chart provide --chart flag.
+If you want a Makefile with build, test, lint, run, docker and helm targets provide --makefile flag.
+If you want a ci pipeline provide --ci flag with github or gitlab.
+If you want a docker-compose.yaml to run the service and its backing services locally provide --compose flag.
+If you want a grpc server along with the http server provide --grpc flag, resources added with
+'crud add resource --grpc' are then served over grpc as well.
+If you want the resource endpoints to require JWT bearer tokens provide --auth jwt, the tokens are verified with
+the JWKS url or the static key configured in the env, the scopes of each resource are provided with
+'crud add resource --scope'. It needs go 1.25 or later.
+If you want the resource endpoints to accept api keys of machine clients provide --auth apikey, the keys are added
+with 'crud add apikey' and verified against their hashes in the api key file. Provide --auth jwt,apikey for both.
+If you want to limit the requests of each client to the resource endpoints provide --rate-limit flag, the clients
+are identified by the subject of the token, the client id of the key or the ip address. The default limit is
+configured in the env and the limits of each endpoint with 'crud add resource --rate-limit'.
+If you want the resources to be cached provide --cache redis, the reads of the repositories are cached in redis,
+or in the process if no redis is configured, and invalidated by the writes.
+If you want the domain events of the writes, e.g. inventory.item.created, to be published provide --events with
+kafka, nats or log, which logs them. Provide --outbox as well to store the events in the outbox table of a postgres
+database, from which a relay publishes them.
+If you want the prometheus metrics of the http requests, their number and duration by route, method and status,
+provide --metrics flag, they are served on METRICS_LISTEN_ADDR apart from the api.
+If you want a worker which consumes the messages of a broker instead of a service serving the resources provide
+--type worker, the messages are consumed from the broker provided with --events, kafka or nats, or published in
+the process if none is provided. The handlers of the message types are registered in pkg/routes and the failed
+messages are retried with backoff and dead lettered once every attempt failed.
+The final image of the Dockerfile is based on distroless, provide --base-image scratch for an empty base image.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		// the module name and the options are asked interactively if no module name is provided on a terminal
+		interactive := false
 		if len(args) < 1 {
-			cobra.CheckErr(fmt.Errorf("init needs the module name"))
+			if !isTerminal(os.Stdin) {
+				cobra.CheckErr(fmt.Errorf("init needs the module name"))
+			}
+			moduleName, err := runInitWizard(os.Stdin, os.Stdout)
+			cobra.CheckErr(err)
+			args = []string{moduleName}
+			interactive = true
 		}
 
-		cobra.CheckErr(validateModuleName(args[0])) // validates module name
+		cobra.CheckErr(pkg.ValidateModuleName(args[0]))    // validates module name
+		cobra.CheckErr(pkg.ValidateType(projectType))      // validates project type
+		cobra.CheckErr(pkg.ValidateBaseImage(baseImage))   // validates base image profile
+		cobra.CheckErr(pkg.ValidateCI(ci))                 // validates ci provider
+		cobra.CheckErr(pkg.ValidateRouter(router))         // validates router
+		cobra.CheckErr(pkg.ValidateAuth(auth))             // validates authentication methods
+		cobra.CheckErr(pkg.ValidateCache(cache))           // validates cache
+		cobra.CheckErr(pkg.ValidateEvents(events, outbox)) // validates event broker
+
+		// the worker has no http router, so the router can't be provided
+		if projectType == pkg.WorkerType {
+			if cmd.Flags().Changed("router") {
+				cobra.CheckErr(fmt.Errorf("worker has no resource endpoints, --router can't be provided"))
+			}
+			router = ""
+		}
 
-		projectPath, err := createProject(args) // create project
+		project, err := createProject(args) // create project
 		cobra.CheckErr(err)
+		projectPath := project.AbsolutePath
 		fmt.Printf("Your micro-service scaffolding is created at\n%s\n", projectPath)
+		if interactive {
+			fmt.Printf("The answers are recorded in %s, create the project again non-interactively with\n%s\n", pkg.ManifestFileName, project.InitCommand())
+		}
 	},
 }
 
-// validateModuleName validates the syntax of the module name
-func validateModuleName(moduleName string) error {
-	// FIXME add implementation
-	return nil
-}
-
 // createProject initializes the Project object
-func createProject(args []string) (string, error) {
+func createProject(args []string) (*pkg.Project, error) {
 	wd, err := os.Getwd()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// parse the project directory name from module name
@@ -85,17 +138,35 @@ func createProject(args []string) (string, error) {
 		AbsolutePath:    wd,
 		ModuleName:      args[0],
 		ProjectDirName:  projectDirName,
+		Type:            projectType,
 		CreateApiDoc:    api,
 		CreateHelmChart: helm,
+		CreateMakefile:  makefile,
+		CreateCompose:   compose,
+		BaseImage:       baseImage,
+		CI:              ci,
+		GRPC:            grpc,
+		Router:          router,
+		Auth:            auth,
+		RateLimit:       rateLimit,
+		Cache:           cache,
+		Events:          events,
+		Outbox:          outbox,
+		Metrics:         metrics,
+	}
+
+	// validate the options of the worker
+	if err = project.ValidateWorker(); err != nil {
+		return nil, err
 	}
 
 	// create the project
 	err = project.Create()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return project.AbsolutePath, nil
+	return project, nil
 }
 
 // getProjectDirName returns the project directory name from the module name
@@ -108,6 +179,19 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 
 	initCmd.Flags().StringVarP(&name, "name", "n", "", "module name for the go module, last part of the name will be used for directory name (e.g 'github.com/piyushjajoo/crud' is the module name and crud is the directory name)")
+	initCmd.Flags().StringVarP(&projectType, "type", "t", pkg.ServiceType, "type of the project, service serves the resources over http and worker consumes the messages of the broker provided with --events")
 	initCmd.Flags().BoolVarP(&api, "swagger", "s", false, "to generate swagger api documentation file")
 	initCmd.Flags().BoolVarP(&helm, "chart", "c", false, "to generate helm chart")
+	initCmd.Flags().BoolVarP(&makefile, "makefile", "m", false, "to generate Makefile with the standard developer targets")
+	initCmd.Flags().StringVarP(&router, "router", "r", pkg.MuxRouter, "router of the http server, one of mux, chi, stdlib, gin or echo")
+	initCmd.Flags().BoolVar(&grpc, "grpc", false, "to generate grpc server which runs alongside the http server")
+	initCmd.Flags().BoolVar(&compose, "compose", false, "to generate docker-compose.yaml to run the service along with its backing services locally")
+	initCmd.Flags().StringVar(&ci, "ci", "", "to generate ci pipeline which vets, tests, lints and builds the docker image, one of github or gitlab")
+	initCmd.Flags().StringSliceVar(&auth, "auth", nil, "to authenticate the resource endpoints, jwt validates bearer tokens against a JWKS url or a static key, apikey verifies api keys against their hashes in a key file")
+	initCmd.Flags().BoolVar(&rateLimit, "rate-limit", false, "to generate token bucket rate limiting of the resource endpoints per client")
+	initCmd.Flags().StringVar(&cache, "cache", "", "to cache the reads of the repositories and invalidate them on writes, redis")
+	initCmd.Flags().StringVar(&events, "events", "", "to publish the created, updated and deleted events of the resources, one of kafka, nats or log")
+	initCmd.Flags().BoolVar(&outbox, "outbox", false, "to store the events in the outbox table of postgres from which a relay publishes them, needs --events")
+	initCmd.Flags().BoolVar(&metrics, "metrics", false, "to serve the prometheus metrics of the http requests on METRICS_LISTEN_ADDR")
+	initCmd.Flags().StringVarP(&baseImage, "base-image", "b", pkg.DistrolessBaseImage, "base image profile for the final stage of the Dockerfile, one of distroless or scratch")
 }
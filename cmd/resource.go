@@ -0,0 +1,157 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/piyushjajoo/crud/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+var resourceFields []string
+var resourceGRPC bool
+var resourceScopes, resourceRoles, resourceOwner, resourceRateLimits []string
+
+// resourceCmd represents the add resource command
+var resourceCmd = &cobra.Command{
+	Use:   "resource <name>",
+	Short: "resource adds a resource with CRUD endpoints to the micro-service",
+	Long: `
+Resource command adds a resource to the micro-service scaffolded with crud init. It generates -
+1. model in pkg/models
+2. repository interface along with an in-memory implementation in pkg/repository
+3. handlers for the list, get, create, update and delete endpoints in pkg/handlers, registered under /<plural name>
+
+Fields are provided with --field name:type, supported types are string, bool, int, int64, float64 and time.
+Every resource also gets id, createdAt and updatedAt fields.
+
+Validation rules are appended to the field as --field name:type:rules, e.g. --field price:float64:required,min=0.
+The rules are required, min=<n> and max=<n> (length of strings), email, enum=<a>|<b> and regex=<pattern>,
+which must be the last rule. They are written as validate tags of the model, enforced in the create and update
+handlers and documented in the api documentation.
+
+A string field references another resource, whose id it holds, with the rule ref=<resource>, e.g.
+--field customerId:string:required,ref=customer. The resource must exist or be the resource itself, the repository
+checks that the referenced resource exists when the resource is created or updated and returns 400 otherwise.
+The referenced resource can't be deleted while resources reference it, the delete returns 409.
+
+A field which may be null, distinct from the zero value of its type, is provided with the rule nullable, e.g.
+--field discount:float64:nullable,min=0. It is a pointer in the model and its other rules validate the values which
+are not null, it can't be required nor served over grpc.
+
+If the project was created with --auth, every endpoint requires a valid bearer token or api key. The scopes the
+token or key must be granted are provided with --scope action=scope, the action is one of list, get, create, update,
+delete or read (list and get) and write (create, update and delete), e.g. --scope read=items:read --scope write=items:write.
+The roles of which the token or key must be granted any, the roles claim of tokens, are provided the same way with
+--role action=role, e.g. --role read=reader --role read=admin --role write=admin.
+The actions only the owner of the resource may perform are provided with --owner, e.g. --owner update,delete.
+The owner is the subject of the token, or the client id of the key, which created the resource, it is stored in its
+ownerId field. The resources of other owners are not listed with --owner list and not found with --owner get.
+
+If the project was created with --rate-limit, the requests each client may send to an endpoint are provided with
+--rate-limit action=rate[/burst], the rate is the number of requests per second and the burst the number of requests
+allowed at once, e.g. --rate-limit write=5/10. The endpoints without a limit get the limit of RATE_LIMIT and the limit
+of every endpoint can be overridden with RATE_LIMIT_ROUTES, e.g. RATE_LIMIT_ROUTES=items.create:1/5.
+
+If you want the resource to be served over grpc as well provide --grpc flag, the project must be created with --grpc.
+It generates the proto file in proto directory and the service implementation in pkg/grpcserver,
+the go code is generated from the proto file with protoc, make sure protoc, protoc-gen-go and protoc-gen-go-grpc are installed.
+`,
+	Example: "crud add resource item --field name:string:required,max=100 --field price:float64:min=0 --grpc",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cobra.CheckErr(fmt.Errorf("resource needs the resource name"))
+		}
+
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+
+		project, err := pkg.LoadProject(wd)
+		cobra.CheckErr(err)
+
+		resource, err := createResource(args[0])
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(project.AddResource(resource))
+		fmt.Printf("Resource %s is added, its endpoints are served at /%s\n", resource.Name, resource.PathName())
+	},
+}
+
+// createResource initializes the Resource object from the name and the flags
+func createResource(name string) (*pkg.Resource, error) {
+	var fields []*pkg.Field
+	for _, definition := range resourceFields {
+		field, err := pkg.ParseField(definition)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	resource, err := pkg.NewResource(name, fields, resourceGRPC)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, definition := range resourceScopes {
+		if resource.Scopes == nil {
+			resource.Scopes = map[string][]string{}
+		}
+		if err := pkg.ParseScope(definition, resource.Scopes); err != nil {
+			return nil, err
+		}
+	}
+	for _, definition := range resourceRoles {
+		if resource.Roles == nil {
+			resource.Roles = map[string][]string{}
+		}
+		if err := pkg.ParseRole(definition, resource.Roles); err != nil {
+			return nil, err
+		}
+	}
+	resource.Owner, err = pkg.ParseOwnership(resourceOwner)
+	if err != nil {
+		return nil, err
+	}
+	for _, definition := range resourceRateLimits {
+		if resource.RateLimits == nil {
+			resource.RateLimits = map[string]pkg.RateLimit{}
+		}
+		if err := pkg.ParseRateLimit(definition, resource.RateLimits); err != nil {
+			return nil, err
+		}
+	}
+	return resource, nil
+}
+
+func init() {
+	addCmd.AddCommand(resourceCmd)
+
+	resourceCmd.Flags().StringArrayVarP(&resourceFields, "field", "f", nil, "field of the resource in the form name:type[:rules], can be repeated (e.g. --field name:string:required --field price:float64:min=0)")
+	resourceCmd.Flags().BoolVar(&resourceGRPC, "grpc", false, "to generate proto file and grpc service of the resource")
+	resourceCmd.Flags().StringArrayVar(&resourceScopes, "scope", nil, "scope the bearer token or api key must be granted in the form action=scope, can be repeated (e.g. --scope read=items:read --scope write=items:write)")
+	resourceCmd.Flags().StringArrayVar(&resourceRoles, "role", nil, "role of which the bearer token or api key must be granted any in the form action=role, can be repeated (e.g. --role read=reader --role write=admin)")
+	resourceCmd.Flags().StringSliceVar(&resourceOwner, "owner", nil, "actions only the owner of the resource may perform, any of list, get, update and delete (e.g. --owner update,delete)")
+	resourceCmd.Flags().StringArrayVar(&resourceRateLimits, "rate-limit", nil, "requests per second each client may send in the form action=rate[/burst], can be repeated (e.g. --rate-limit read=50 --rate-limit write=5/10)")
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/piyushjajoo/crud/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+var sqlDialect string
+var sqlTables []string
+var sqlDryRun bool
+
+// sqlCmd represents the import sql command
+var sqlCmd = &cobra.Command{
+	Use:   "sql <file>",
+	Short: "sql adds the resources of the tables created in the DDL file to the micro-service",
+	Long: `
+Sql command parses the CREATE TABLE statements of the DDL file, or of stdin if the file is -, and adds a resource
+for every table, along with its model in pkg/models, repository in pkg/repository and handlers in pkg/handlers, as
+crud add resource does. The DDL is parsed offline, no database is connected to.
+
+The dialect is one of postgres, mysql or sqlite and it is detected from the DDL if --dialect is not provided, e.g.
+identifiers quoted with backticks and AUTO_INCREMENT are mysql, AUTOINCREMENT and WITHOUT ROWID are sqlite.
+The primary and foreign keys added with ALTER TABLE, as pg_dump writes them, are imported as well, the other
+statements are skipped.
+
+The name of the resource is the singular of the name of the table, e.g. order_items is order-item, and the fields
+are its columns in lower camel case. The columns are mapped to the field types by their sql types -
+1. char, varchar, text, uuid, enum, json and the other textual types are string
+2. boolean, and tinyint(1) of mysql, is bool
+3. smallint and int are int, bigint and the integers of sqlite are int64
+4. real, float, double, decimal and numeric are float64
+5. timestamp, datetime and date are time
+Arrays and binary columns are not imported. The columns which may be null are nullable fields, pointers in the
+model, varchar(n) is validated with max=n and the enums of mysql with their values. The string and time columns
+which can't be null and have no default, nor are identities or generated, are required, the numbers and bools are
+not as required would reject 0 and false.
+
+The single column primary key is the id of the resource, the ids are strings generated by the repository, and the
+created_at and updated_at columns are the timestamps every resource has. The single column foreign keys are string
+fields referencing the resource of the table, which must be imported or exist in the project, and they are required
+unless they may be null. What can't be imported as it is, e.g. composite keys, is printed as a warning.
+
+Provide --table to import only some tables and --dry-run to print the crud add resource commands of the resources
+instead of adding them.
+`,
+	Example: "crud import sql schema.sql --table customers --table orders",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cobra.CheckErr(fmt.Errorf("sql needs the DDL file"))
+		}
+		cobra.CheckErr(pkg.ValidateDialect(sqlDialect)) // validates sql dialect
+
+		var ddl []byte
+		var err error
+		if args[0] == "-" {
+			ddl, err = io.ReadAll(os.Stdin)
+		} else {
+			ddl, err = os.ReadFile(args[0])
+		}
+		cobra.CheckErr(err)
+
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+
+		project, err := pkg.LoadProject(wd)
+		cobra.CheckErr(err)
+
+		resources, warnings, err := project.ImportSQL(string(ddl), sqlDialect, sqlTables)
+		cobra.CheckErr(err)
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+
+		if sqlDryRun {
+			for _, r := range resources {
+				fmt.Println(r.AddCommand())
+			}
+			return
+		}
+		cobra.CheckErr(project.AddResources(resources))
+		for _, r := range resources {
+			fmt.Printf("Resource %s is added, its endpoints are served at /%s\n", r.Name, r.PathName())
+		}
+	},
+}
+
+func init() {
+	importCmd.AddCommand(sqlCmd)
+
+	sqlCmd.Flags().StringVar(&sqlDialect, "dialect", "", "sql dialect of the DDL, one of postgres, mysql or sqlite, detected from the DDL if not provided")
+	sqlCmd.Flags().StringArrayVar(&sqlTables, "table", nil, "table to import, can be repeated, every table created in the DDL is imported if not provided")
+	sqlCmd.Flags().BoolVar(&sqlDryRun, "dry-run", false, "to print the crud add resource commands of the resources instead of adding them")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/piyushjajoo/crud/pkg"
+
+	"github.com/spf13/cobra"
+)
+
+var upgradeDryRun bool
+
+// upgradeCmd represents the upgrade command
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "upgrade applies the templates of this version of crud to the micro-service scaffolded with crud init",
+	Long: `
+Upgrade command renders the templates of this version of crud with the options and resources of the crud.yaml
+manifest and merges them into the micro-service. It must be run from the root directory of the project.
+
+The files are recorded in .crud/base as they were generated by crud init, crud add resource and crud upgrade.
+The files which weren't changed in the project are replaced by the rendered files, the changes of the project and
+of the templates are merged into the changed files. The lines changed differently on both sides are written between
+conflict markers, with the lines of the project first, which must be resolved before the project builds. The files
+deleted from the project are not added again. The changed files generated before .crud/base was recorded have no
+base to tell the changes of the project from the changes of the templates, they are skipped and the rendered files
+are recorded in .crud/base to compare them with.
+
+Commit the project before upgrading, provide --dry-run to print the summary without changing any file.
+`,
+	Example: "crud upgrade --dry-run",
+	Run: func(cmd *cobra.Command, args []string) {
+		wd, err := os.Getwd()
+		cobra.CheckErr(err)
+
+		project, err := pkg.LoadProject(wd)
+		cobra.CheckErr(err)
+
+		upgrades, err := project.Upgrade(upgradeDryRun)
+		cobra.CheckErr(err)
+
+		counts := map[string]int{}
+		for _, u := range upgrades {
+			counts[u.Status]++
+			if u.Status != pkg.UpgradeUnchanged {
+				fmt.Printf("%-9s %s\n", u.Status, u.Path)
+			}
+		}
+		fmt.Printf("%d added, %d updated, %d merged, %d conflicts, %d deleted, %d skipped, %d unchanged\n",
+			counts[pkg.UpgradeAdded], counts[pkg.UpgradeUpdated], counts[pkg.UpgradeMerged],
+			counts[pkg.UpgradeConflict], counts[pkg.UpgradeDeleted], counts[pkg.UpgradeSkipped], counts[pkg.UpgradeUnchanged])
+		if counts[pkg.UpgradeSkipped] > 0 {
+			fmt.Fprintf(os.Stderr, "warning: the skipped files have no base, compare them with %s to apply the changes of the templates\n", pkg.BaseDir)
+		}
+		if counts[pkg.UpgradeConflict] > 0 && !upgradeDryRun {
+			fmt.Println("resolve the conflict markers of the conflicting files, then run go build ./...")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+
+	upgradeCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "print the summary of the upgrade without changing any file")
+}
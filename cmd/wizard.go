@@ -0,0 +1,414 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/piyushjajoo/crud/pkg"
+)
+
+// errInitAborted is returned when the answers of the init wizard end or the summary is not confirmed
+var errInitAborted = errors.New("init aborted, no project is created")
+
+// choice is an option of a wizard question along with its description
+type choice struct {
+	value       string
+	description string
+}
+
+// wizard asks the questions of the interactive init on the terminal
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// isTerminal reports whether the file is a terminal, the wizard only runs when stdin is one
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// runInitWizard asks the module name and the options of the project, the answers are set on the init flags, whose
+// values are the defaults of the questions, and returns the module name once the summary is confirmed
+func runInitWizard(in io.Reader, out io.Writer) (string, error) {
+	w := &wizard{in: bufio.NewReader(in), out: out}
+	fmt.Fprintln(out, "Answer the questions to create the micro-service, press enter for the default in brackets.")
+
+	moduleName, err := w.ask("Module name (e.g. github.com/acme/inventory)", name, validateNewModule)
+	if err != nil {
+		return "", err
+	}
+
+	if projectType == "" {
+		projectType = pkg.ServiceType
+	}
+	if projectType, err = w.choose("Project type", []choice{
+		{pkg.ServiceType, "serves the resources over http"},
+		{pkg.WorkerType, "consumes the messages of a broker"},
+	}, projectType); err != nil {
+		return "", err
+	}
+
+	if projectType == pkg.WorkerType {
+		err = w.askWorker()
+	} else {
+		err = w.askService()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// the deployment target is the docker-compose.yaml, the helm chart or both, the Dockerfile is always generated
+	deployment := deploymentTarget(compose, helm)
+	if deployment, err = w.choose("Deployment target, the Dockerfile is always generated", []choice{
+		{"docker", "Dockerfile only"},
+		{"compose", "docker-compose.yaml with the backing services"},
+		{"chart", "helm chart"},
+		{"compose+chart", "docker-compose.yaml and helm chart"},
+	}, deployment); err != nil {
+		return "", err
+	}
+	compose = strings.Contains(deployment, "compose")
+	helm = strings.Contains(deployment, "chart")
+
+	if ci == "" {
+		ci = "none"
+	}
+	if ci, err = w.choose("CI pipeline", []choice{
+		{"none", "no pipeline"},
+		{pkg.GitHubCI, "GitHub Actions workflow"},
+		{pkg.GitLabCI, "GitLab CI pipeline"},
+	}, ci); err != nil {
+		return "", err
+	}
+	if ci == "none" {
+		ci = ""
+	}
+
+	if makefile, err = w.confirm("Makefile with the developer targets", makefile); err != nil {
+		return "", err
+	}
+
+	w.summary(moduleName, deployment)
+	ok, err := w.confirm("Generate the project", true)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errInitAborted
+	}
+	return moduleName, nil
+}
+
+// askService asks the options of the resource endpoints of a service
+func (w *wizard) askService() error {
+	var err error
+
+	if router == "" {
+		router = pkg.MuxRouter
+	}
+	if router, err = w.choose("Router", []choice{
+		{pkg.MuxRouter, "gorilla mux"},
+		{pkg.ChiRouter, "chi"},
+		{pkg.StdlibRouter, "go 1.22 http.ServeMux"},
+		{pkg.GinRouter, "gin"},
+		{pkg.EchoRouter, "echo"},
+	}, router); err != nil {
+		return err
+	}
+
+	authentication := "none"
+	if len(auth) > 0 {
+		authentication = strings.Join(auth, ",")
+	}
+	if authentication, err = w.choose("Authentication of the resource endpoints", []choice{
+		{"none", "no authentication"},
+		{pkg.JWTAuth, "JWT bearer tokens, needs go 1.25 or later"},
+		{pkg.APIKeyAuth, "api keys of machine clients"},
+		{pkg.JWTAuth + "," + pkg.APIKeyAuth, "both"},
+	}, authentication); err != nil {
+		return err
+	}
+	auth = nil
+	if authentication != "none" {
+		auth = strings.Split(authentication, ",")
+	}
+
+	if events == "" {
+		events = "none"
+	}
+	if events, err = w.choose("Domain events of the writes", []choice{
+		{"none", "no events"},
+		{pkg.LogEvents, "logged"},
+		{pkg.KafkaEvents, "published to kafka"},
+		{pkg.NATSEvents, "published to nats"},
+	}, events); err != nil {
+		return err
+	}
+	if events == "none" {
+		events = ""
+	}
+
+	// the repositories keep the resources in memory, postgres is the database of the outbox of the events
+	outbox = false
+	if events != "" {
+		database := "memory"
+		if database, err = w.choose("Database", []choice{
+			{"memory", "the repositories keep the resources in memory"},
+			{"postgres", "the events are stored in the outbox table of postgres and relayed to the broker"},
+		}, database); err != nil {
+			return err
+		}
+		outbox = database == "postgres"
+	}
+
+	if cache == "" {
+		cache = "none"
+	}
+	if cache, err = w.choose("Cache of the repositories", []choice{
+		{"none", "no cache"},
+		{pkg.RedisCache, "redis, or in the process if no redis is configured"},
+	}, cache); err != nil {
+		return err
+	}
+	if cache == "none" {
+		cache = ""
+	}
+
+	if rateLimit, err = w.confirm("Rate limiting per client", rateLimit); err != nil {
+		return err
+	}
+	if api, err = w.confirm("Swagger api documentation", api); err != nil {
+		return err
+	}
+	if grpc, err = w.confirm("gRPC server alongside the http server", grpc); err != nil {
+		return err
+	}
+
+	observability := "none"
+	if metrics {
+		observability = "metrics"
+	}
+	if observability, err = w.choose("Observability, the requests are always logged", []choice{
+		{"none", "logs only"},
+		{"metrics", "prometheus metrics of the requests served on METRICS_LISTEN_ADDR"},
+	}, observability); err != nil {
+		return err
+	}
+	metrics = observability == "metrics"
+	return nil
+}
+
+// askWorker asks the broker the messages of a worker are consumed from, the worker has no resource endpoints
+func (w *wizard) askWorker() error {
+	var err error
+
+	router = ""
+	metrics = false
+	if events == "" || events == pkg.LogEvents {
+		events = "memory"
+	}
+	if events, err = w.choose("Broker the messages are consumed from", []choice{
+		{"memory", "published in the process"},
+		{pkg.KafkaEvents, "kafka"},
+		{pkg.NATSEvents, "nats jetstream"},
+	}, events); err != nil {
+		return err
+	}
+	if events == "memory" {
+		events = ""
+	}
+	return nil
+}
+
+// summary prints the answers before the project is generated
+func (w *wizard) summary(moduleName, deployment string) {
+	rows := [][2]string{
+		{"module", moduleName},
+		{"directory", getProjectDirName(moduleName)},
+		{"type", projectType},
+	}
+	if projectType == pkg.WorkerType {
+		rows = append(rows, [2]string{"broker", orNone(events)})
+	} else {
+		database := "memory"
+		if outbox {
+			database = "postgres"
+		}
+		rows = append(rows,
+			[2]string{"router", router},
+			[2]string{"auth", orNone(strings.Join(auth, ","))},
+			[2]string{"events", orNone(events)},
+			[2]string{"database", database},
+			[2]string{"cache", orNone(cache)},
+			[2]string{"rate limit", strconv.FormatBool(rateLimit)},
+			[2]string{"swagger", strconv.FormatBool(api)},
+			[2]string{"grpc", strconv.FormatBool(grpc)},
+			[2]string{"metrics", strconv.FormatBool(metrics)},
+		)
+	}
+	rows = append(rows,
+		[2]string{"deployment", deployment},
+		[2]string{"ci", orNone(ci)},
+		[2]string{"makefile", strconv.FormatBool(makefile)},
+	)
+
+	fmt.Fprintln(w.out, "\nSummary")
+	for _, row := range rows {
+		fmt.Fprintf(w.out, "  %-12s %s\n", row[0], row[1])
+	}
+	fmt.Fprintln(w.out)
+}
+
+// ask asks a question until the answer is valid, an empty answer is the default
+func (w *wizard) ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(w.out, "? %s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(w.out, "? %s: ", question)
+		}
+		answer, err := w.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if err = validate(answer); err != nil {
+			fmt.Fprintf(w.out, "  %s\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// choose asks to choose one of the choices by its number or value
+func (w *wizard) choose(question string, choices []choice, def string) (string, error) {
+	fmt.Fprintf(w.out, "? %s\n", question)
+	for i, c := range choices {
+		fmt.Fprintf(w.out, "  %d) %-14s %s\n", i+1, c.value, c.description)
+	}
+	answer, err := w.ask("Choose", def, func(answer string) error {
+		if _, ok := chosen(choices, answer); ok {
+			return nil
+		}
+		values := make([]string, len(choices))
+		for i, c := range choices {
+			values[i] = c.value
+		}
+		return fmt.Errorf("invalid choice %q, must be a number from 1 to %d or one of %s", answer, len(choices), strings.Join(values, ", "))
+	})
+	if err != nil {
+		return "", err
+	}
+	value, _ := chosen(choices, answer)
+	return value, nil
+}
+
+// confirm asks a yes or no question
+func (w *wizard) confirm(question string, def bool) (bool, error) {
+	defAnswer := "n"
+	if def {
+		defAnswer = "y"
+	}
+	answer, err := w.ask(question+" (y/n)", defAnswer, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "y", "yes", "n", "no":
+			return nil
+		}
+		return fmt.Errorf("invalid answer %q, must be y or n", answer)
+	})
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y"), nil
+}
+
+// readLine reads the next answer, the end of the input aborts the wizard
+func (w *wizard) readLine() (string, error) {
+	line, err := w.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		fmt.Fprintln(w.out)
+		return "", errInitAborted
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// chosen returns the value of the choice answered by its number or value
+func chosen(choices []choice, answer string) (string, bool) {
+	if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(choices) {
+		return choices[i-1].value, true
+	}
+	for _, c := range choices {
+		if c.value == answer {
+			return c.value, true
+		}
+	}
+	return "", false
+}
+
+// validateNewModule validates the module name and that its project directory doesn't exist yet
+func validateNewModule(moduleName string) error {
+	if err := pkg.ValidateModuleName(moduleName); err != nil {
+		return err
+	}
+	dir := getProjectDirName(moduleName)
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("directory %s already exists", dir)
+	}
+	return nil
+}
+
+// deploymentTarget returns the deployment target of the compose and chart flags
+func deploymentTarget(compose, chart bool) string {
+	switch {
+	case compose && chart:
+		return "compose+chart"
+	case compose:
+		return "compose"
+	case chart:
+		return "chart"
+	}
+	return "docker"
+}
+
+// orNone returns none for the empty options
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/piyushjajoo/crud/pkg"
+)
+
+// resetInitFlags sets the init flags to their defaults in a temporary working directory, they are restored once
+// the test ends
+func resetInitFlags(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	saved := []interface{}{name, projectType, router, auth, events, outbox, cache, rateLimit, api, grpc, metrics, compose, helm, ci, makefile}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+		name, projectType, router, auth, events = saved[0].(string), saved[1].(string), saved[2].(string), saved[3].([]string), saved[4].(string)
+		outbox, cache, rateLimit, api, grpc, metrics = saved[5].(bool), saved[6].(string), saved[7].(bool), saved[8].(bool), saved[9].(bool), saved[10].(bool)
+		compose, helm, ci, makefile = saved[11].(bool), saved[12].(bool), saved[13].(string), saved[14].(bool)
+	})
+
+	name, projectType, router, auth, events = "", pkg.ServiceType, pkg.MuxRouter, nil, ""
+	outbox, cache, rateLimit, api, grpc, metrics = false, "", false, false, false, false
+	compose, helm, ci, makefile = false, false, "", false
+}
+
+// answers joins the answers of the wizard, one per line
+func answers(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestRunInitWizard(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers string
+		module  string
+		// options are the init flags after the wizard, in the form of the summary
+		options map[string]interface{}
+		output  []string
+	}{
+		{
+			name: "defaults",
+			answers: answers("github.com/acme/inventory",
+				"", "", "", "", "", "", "", "", "", // type, router, auth, events, cache, rate limit, swagger, grpc, observability
+				"", "", "", // deployment, ci, makefile
+				""), // generate
+			module: "github.com/acme/inventory",
+			options: map[string]interface{}{
+				"type": pkg.ServiceType, "router": pkg.MuxRouter, "auth": []string(nil), "events": "", "outbox": false,
+				"cache": "", "rateLimit": false, "swagger": false, "grpc": false, "metrics": false,
+				"compose": false, "chart": false, "ci": "", "makefile": false,
+			},
+			output: []string{"? Router", "? Observability", "  module       github.com/acme/inventory", "  directory    inventory", "  metrics      false"},
+		},
+		{
+			name: "numbered choices",
+			answers: answers("github.com/acme/inventory",
+				"1", "2", "4", "3", "2", "2", // type, router, auth, events, database, cache
+				"y", "yes", "n", "2", // rate limit, swagger, grpc, observability
+				"4", "2", "y", // deployment, ci, makefile
+				"y"),
+			module: "github.com/acme/inventory",
+			options: map[string]interface{}{
+				"type": pkg.ServiceType, "router": pkg.ChiRouter, "auth": []string{pkg.JWTAuth, pkg.APIKeyAuth}, "events": pkg.KafkaEvents,
+				"outbox": true, "cache": pkg.RedisCache, "rateLimit": true, "swagger": true, "grpc": false, "metrics": true,
+				"compose": true, "chart": true, "ci": pkg.GitHubCI, "makefile": true,
+			},
+			output: []string{"? Database", "  database     postgres", "  metrics      true", "  deployment   compose+chart"},
+		},
+		{
+			name: "choices by value",
+			answers: answers("github.com/acme/inventory",
+				"service", "echo", "apikey", "log", "memory", "none",
+				"n", "n", "y", "metrics",
+				"chart", "gitlab", "n",
+				""),
+			module: "github.com/acme/inventory",
+			options: map[string]interface{}{
+				"type": pkg.ServiceType, "router": pkg.EchoRouter, "auth": []string{pkg.APIKeyAuth}, "events": pkg.LogEvents,
+				"outbox": false, "cache": "", "rateLimit": false, "swagger": false, "grpc": true, "metrics": true,
+				"compose": false, "chart": true, "ci": pkg.GitLabCI, "makefile": false,
+			},
+		},
+		{
+			name: "invalid answers are asked again",
+			answers: answers("github.com/acme/inv entory", "github.com/acme/inventory",
+				"cron", "service", "9", "0", "gin", "", "", "", "maybe", "y", "", "", "",
+				"kubernetes", "", "travis", "", "",
+				""),
+			module: "github.com/acme/inventory",
+			options: map[string]interface{}{
+				"type": pkg.ServiceType, "router": pkg.GinRouter, "auth": []string(nil), "events": "", "outbox": false,
+				"cache": "", "rateLimit": true, "swagger": false, "grpc": false, "metrics": false,
+				"compose": false, "chart": false, "ci": "", "makefile": false,
+			},
+			output: []string{
+				`invalid module name "github.com/acme/inv entory"`,
+				`invalid choice "cron", must be a number from 1 to 2 or one of service, worker`,
+				`invalid choice "9", must be a number from 1 to 5`,
+				`invalid choice "0"`,
+				`invalid answer "maybe", must be y or n`,
+				`invalid choice "kubernetes"`,
+				`invalid choice "travis"`,
+			},
+		},
+		{
+			name: "worker",
+			answers: answers("github.com/acme/consumer",
+				"2", "3", // type, broker
+				"1", "1", "n",
+				""),
+			module: "github.com/acme/consumer",
+			options: map[string]interface{}{
+				"type": pkg.WorkerType, "router": "", "auth": []string(nil), "events": pkg.NATSEvents, "outbox": false,
+				"cache": "", "rateLimit": false, "swagger": false, "grpc": false, "metrics": false,
+				"compose": false, "chart": false, "ci": "", "makefile": false,
+			},
+			output: []string{"? Broker the messages are consumed from", "  broker       nats"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetInitFlags(t)
+			var out bytes.Buffer
+			moduleName, err := runInitWizard(strings.NewReader(tt.answers), &out)
+			if err != nil {
+				t.Fatalf("runInitWizard() error = %v\n%s", err, out.String())
+			}
+			if moduleName != tt.module {
+				t.Errorf("runInitWizard() = %q, want %q", moduleName, tt.module)
+			}
+
+			got := map[string]interface{}{
+				"type": projectType, "router": router, "auth": auth, "events": events, "outbox": outbox,
+				"cache": cache, "rateLimit": rateLimit, "swagger": api, "grpc": grpc, "metrics": metrics,
+				"compose": compose, "chart": helm, "ci": ci, "makefile": makefile,
+			}
+			for option, want := range tt.options {
+				if !reflect.DeepEqual(got[option], want) {
+					t.Errorf("%s = %#v, want %#v", option, got[option], want)
+				}
+			}
+			for _, want := range tt.output {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output doesn't contain %q\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunInitWizardFlagDefaults(t *testing.T) {
+	resetInitFlags(t)
+	router, auth, metrics, compose, ci = pkg.GinRouter, []string{pkg.JWTAuth}, true, true, pkg.GitHubCI
+
+	var out bytes.Buffer
+	if _, err := runInitWizard(strings.NewReader(answers("github.com/acme/inventory", "", "", "", "", "", "", "", "", "", "", "", "", "")), &out); err != nil {
+		t.Fatalf("runInitWizard() error = %v\n%s", err, out.String())
+	}
+	if router != pkg.GinRouter || !reflect.DeepEqual(auth, []string{pkg.JWTAuth}) || !metrics || !compose || ci != pkg.GitHubCI {
+		t.Errorf("runInitWizard() changed the defaults of the flags to router %q, auth %v, metrics %v, compose %v, ci %q", router, auth, metrics, compose, ci)
+	}
+	for _, want := range []string{"? Choose [gin]:", "? Choose [jwt]:", "? Choose [metrics]:", "? Choose [compose]:", "? Choose [github]:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't contain %q\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunInitWizardAborts(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers string
+		dir     string
+		wantErr string
+	}{
+		{name: "no answers", answers: "", wantErr: errInitAborted.Error()},
+		{name: "answers end", answers: answers("github.com/acme/inventory", "1", "2"), wantErr: errInitAborted.Error()},
+		{name: "answers end without newline", answers: "github.com/acme/inventory\n1\nchi", wantErr: errInitAborted.Error()},
+		{name: "summary not confirmed", answers: answers("github.com/acme/inventory", "", "", "", "", "", "", "", "", "", "", "", "", "n"), wantErr: errInitAborted.Error()},
+		{name: "directory exists", answers: answers("github.com/acme/inventory"), dir: "inventory", wantErr: errInitAborted.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetInitFlags(t)
+			if tt.dir != "" {
+				if err := os.Mkdir(filepath.Join(".", tt.dir), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var out bytes.Buffer
+			_, err := runInitWizard(strings.NewReader(tt.answers), &out)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("runInitWizard() error = %v, want %q\n%s", err, tt.wantErr, out.String())
+			}
+			if tt.dir != "" && !strings.Contains(out.String(), "directory "+tt.dir+" already exists") {
+				t.Errorf("output doesn't contain the existing directory\n%s", out.String())
+			}
+		})
+	}
+}
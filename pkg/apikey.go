@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	// APIKeysFileName is the api key file of the project relative to the project root, it is mounted
+	// in the docker-compose stack
+	APIKeysFileName = "api-keys.json"
+	// DefaultAPIKeyHeader is the default request header of the api keys
+	DefaultAPIKeyHeader = "X-API-Key"
+)
+
+// APIKey is an entry of the api key file, it mirrors auth.APIKey of the generated project
+type APIKey struct {
+	ID        string     `json:"id"`
+	Hash      string     `json:"hash"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	Roles     []string   `json:"roles,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RateLimit float64    `json:"rateLimit,omitempty"`
+	Burst     int        `json:"burst,omitempty"`
+}
+
+// NewAPIKey returns a random key prefixed with the project name, so leaked keys are recognizable, and its entry
+// of the key file, only the hash of the key is stored
+func (p *Project) NewAPIKey(entry APIKey) (string, APIKey, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", APIKey{}, err
+	}
+	key := p.ProjectDirName + "_" + base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(key))
+	entry.Hash = hex.EncodeToString(sum[:])
+	return key, entry, nil
+}
+
+// AddAPIKey appends the entry to the key file, the keys of the same client expire after rotate if it is positive
+// so the clients can switch to the new key
+func (p *Project) AddAPIKey(path string, entry APIKey, rotate time.Duration) error {
+	if !p.HasAuth(APIKeyAuth) {
+		return fmt.Errorf("api keys can't be added, the project was created without --auth %s", APIKeyAuth)
+	}
+	if entry.ID == "" {
+		return errors.New("the api key needs the client id")
+	}
+	if entry.RateLimit < 0 || entry.Burst < 0 {
+		return errors.New("the rate limit and burst of the api key can't be negative")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		log.Println("error reading", path, ":", err)
+		return err
+	}
+	var keys []APIKey
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &keys); err != nil {
+			return fmt.Errorf("error parsing the api keys of %s: %w", path, err)
+		}
+	}
+
+	if rotate > 0 {
+		expiresAt := time.Now().UTC().Add(rotate).Truncate(time.Second)
+		for i := range keys {
+			if keys[i].ID == entry.ID && (keys[i].ExpiresAt == nil || keys[i].ExpiresAt.After(expiresAt)) {
+				keys[i].ExpiresAt = &expiresAt
+			}
+		}
+	}
+	return p.writeAPIKeys(path, append(keys, entry))
+}
+
+// writeAPIKeys writes the api key file, it is written with an empty array when the project is created
+func (p *Project) writeAPIKeys(path string, keys []APIKey) error {
+	if keys == nil {
+		keys = []APIKey{}
+	}
+	out, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		log.Println("error marshalling the api keys:", err)
+		return err
+	}
+	if err = os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		log.Println("error writing", path, ":", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// authentication methods the service can be generated with
+const (
+	JWTAuth    = "jwt"
+	APIKeyAuth = "apikey"
+)
+
+// modules of the authentication, the claims are jwt claims whatever the method and the api key rate limits
+// are token buckets of the rate module
+const (
+	JWTModuleName     = "github.com/golang-jwt/jwt/v5"
+	KeyfuncModuleName = "github.com/MicahParks/keyfunc/v3"
+	RateModuleName    = "golang.org/x/time"
+)
+
+// resource actions the scopes are required for
+const (
+	ListAction   = "list"
+	GetAction    = "get"
+	CreateAction = "create"
+	UpdateAction = "update"
+	DeleteAction = "delete"
+)
+
+// actions are the resource actions in the order of the generated routes
+var actions = []string{ListAction, GetAction, CreateAction, UpdateAction, DeleteAction}
+
+// actionGroups are the shorthands for the actions which read and write the resource
+var actionGroups = map[string][]string{
+	"read":  {ListAction, GetAction},
+	"write": {CreateAction, UpdateAction, DeleteAction},
+}
+
+// ValidateAuth validates the authentication methods
+func ValidateAuth(methods []string) error {
+	for _, m := range methods {
+		if m != JWTAuth && m != APIKeyAuth {
+			return fmt.Errorf("invalid auth %q, must be one of %s or %s", m, JWTAuth, APIKeyAuth)
+		}
+	}
+	return nil
+}
+
+// HasAuth reports whether the service authenticates the requests with the method
+func (p *Project) HasAuth(method string) bool {
+	for _, m := range p.Auth {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticated reports whether the service authenticates the requests to the resources with any method
+func (p *Project) Authenticated() bool {
+	return len(p.Auth) > 0
+}
+
+// AuthMethods returns the auth.Method variables of the generated main.go in the order they are tried
+func (p *Project) AuthMethods() string {
+	var methods []string
+	if p.HasAuth(JWTAuth) {
+		methods = append(methods, "authenticator")
+	}
+	if p.HasAuth(APIKeyAuth) {
+		methods = append(methods, "apiKeys")
+	}
+	return strings.Join(methods, ", ")
+}
+
+// AuthMethodNames describes the authentication methods in the generated docs
+func (p *Project) AuthMethodNames() string {
+	var names []string
+	if p.HasAuth(JWTAuth) {
+		names = append(names, "JWT bearer tokens")
+	}
+	if p.HasAuth(APIKeyAuth) {
+		names = append(names, "api keys")
+	}
+	return strings.Join(names, " or ")
+}
+
+// HasAuthorization reports whether any resource requires scopes or roles
+func (p *Project) HasAuthorization() bool {
+	for _, r := range p.Resources {
+		if len(r.Scopes) > 0 || len(r.Roles) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Owned reports whether the resource records its owner
+func (r *Resource) Owned() bool {
+	return len(r.Owner) > 0
+}
+
+// OwnerOnly reports whether only the owner of the resource may perform the action
+func (r *Resource) OwnerOnly(action string) bool {
+	for _, a := range r.Owner {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScope parses the scope definition action=scope, e.g. list=items:read, the action is one of list, get, create,
+// update and delete or read for list and get and write for create, update and delete
+func ParseScope(definition string, scopes map[string][]string) error {
+	return parseActionValue("scope", definition, scopes)
+}
+
+// ParseRole parses the role definition action=role, e.g. write=admin, the actions are the ones of ParseScope
+func ParseRole(definition string, roles map[string][]string) error {
+	return parseActionValue("role", definition, roles)
+}
+
+// ParseOwnership validates the actions which only the owner of the resource may perform, the owner is the subject
+// of the token which created it
+func ParseOwnership(ownerActions []string) ([]string, error) {
+	var result []string
+	for _, action := range ownerActions {
+		switch action {
+		case ListAction, GetAction, UpdateAction, DeleteAction:
+			result = append(result, action)
+		default:
+			return nil, fmt.Errorf("invalid owner action %q, must be one of list, get, update or delete", action)
+		}
+	}
+	return result, nil
+}
+
+// parseActionValue parses the definition action=value of the kind and appends the value to the actions
+func parseActionValue(kind, definition string, values map[string][]string) error {
+	parts := strings.SplitN(definition, "=", 2)
+	if len(parts) != 2 || parts[1] == "" || strings.ContainsAny(parts[1], " \"") {
+		return fmt.Errorf("invalid %s %q, it must be in the form action=%s", kind, definition, kind)
+	}
+
+	targets, ok := actionGroups[parts[0]]
+	if !ok {
+		targets = []string{parts[0]}
+	}
+	for _, action := range targets {
+		if !isAction(action) {
+			return fmt.Errorf("invalid action %q of %s %s, must be one of list, get, create, update, delete, read or write", parts[0], kind, parts[1])
+		}
+		values[action] = append(values[action], parts[1])
+	}
+	return nil
+}
+
+func isAction(action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceHandler returns the http.Handler expression of the action of the resource, it is wrapped with the
+// authentication middleware, the rate limiter of the route and the scopes and roles required for the action,
+// the route is limited after the authentication so the clients are limited by their subject
+func (p *Project) ResourceHandler(r *Resource, action string) string {
+	handler := fmt.Sprintf("http.HandlerFunc(%sHandler.%s)", r.VarName(), strings.ToUpper(action[:1])+action[1:])
+	if roles := r.Roles[action]; len(roles) > 0 && p.Authenticated() {
+		handler = fmt.Sprintf("auth.RequireRoles(%s)(%s)", goStrings(roles), handler)
+	}
+	if scopes := r.Scopes[action]; len(scopes) > 0 && p.Authenticated() {
+		handler = fmt.Sprintf("auth.RequireScopes(%s)(%s)", goStrings(scopes), handler)
+	}
+	if p.RateLimit {
+		handler = p.rateLimited(r, action, handler)
+	}
+	if p.Authenticated() {
+		handler = fmt.Sprintf("authn(%s)", handler)
+	}
+	return handler
+}
+
+// GRPCMethodRules returns the auth.Rule literals of the rpcs of the grpc resources which require scopes or roles
+// keyed by the full method name, e.g. /inventory.v1.ItemService/ListItems
+func (p *Project) GRPCMethodRules() map[string]string {
+	methodRules := map[string]string{}
+	for _, r := range p.GRPCResources() {
+		rpcs := map[string]string{
+			ListAction:   "List" + r.GoPluralName(),
+			GetAction:    "Get" + r.GoName(),
+			CreateAction: "Create" + r.GoName(),
+			UpdateAction: "Update" + r.GoName(),
+			DeleteAction: "Delete" + r.GoName(),
+		}
+		for _, action := range actions {
+			var fields []string
+			if scopes := r.Scopes[action]; len(scopes) > 0 {
+				fields = append(fields, fmt.Sprintf("Scopes: []string{%s}", goStrings(scopes)))
+			}
+			if roles := r.Roles[action]; len(roles) > 0 {
+				fields = append(fields, fmt.Sprintf("Roles: []string{%s}", goStrings(roles)))
+			}
+			if len(fields) > 0 {
+				method := fmt.Sprintf("/%s.%sService/%s", p.ProtoPackage(), r.GoName(), rpcs[action])
+				methodRules[method] = strings.Join(fields, ", ")
+			}
+		}
+	}
+	return methodRules
+}
+
+// goStrings returns the go string literals of the values separated by commas, e.g. "a", "b"
+func goStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import "testing"
+
+func TestResourceHandler(t *testing.T) {
+	r := &Resource{
+		Name:       "item",
+		Scopes:     map[string][]string{UpdateAction: {"items:write"}},
+		Roles:      map[string][]string{UpdateAction: {"admin"}},
+		RateLimits: map[string]RateLimit{UpdateAction: {Rate: 5, Burst: 10}},
+	}
+	tests := []struct {
+		name    string
+		project *Project
+		action  string
+		want    string
+	}{
+		{
+			name:    "plain",
+			project: &Project{},
+			action:  UpdateAction,
+			want:    "http.HandlerFunc(itemHandler.Update)",
+		},
+		{
+			// the route is limited after the authentication and before the scopes and roles are checked, so the
+			// denied requests are limited as well
+			name:    "authenticated and limited",
+			project: &Project{Auth: []string{APIKeyAuth}, RateLimit: true},
+			action:  UpdateAction,
+			want:    `authn(limiter.Route("items.update", ratelimit.Limit{Rate: 5, Burst: 10})(auth.RequireScopes("items:write")(auth.RequireRoles("admin")(http.HandlerFunc(itemHandler.Update)))))`,
+		},
+		{
+			name:    "default limit",
+			project: &Project{Auth: []string{JWTAuth}, RateLimit: true},
+			action:  ListAction,
+			want:    `authn(limiter.Route("items.list", ratelimit.Limit{})(http.HandlerFunc(itemHandler.List)))`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.project.ResourceHandler(r, tt.action); got != tt.want {
+				t.Errorf("ResourceHandler() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
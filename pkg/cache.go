@@ -0,0 +1,25 @@
+package pkg
+
+import "fmt"
+
+// caches the repositories can be decorated with
+const (
+	RedisCache = "redis"
+)
+
+// RedisModuleName is the module of the redis client of the cache
+const RedisModuleName = "github.com/redis/go-redis/v9"
+
+// ValidateCache validates the cache, empty means the repositories are not cached
+func ValidateCache(cache string) error {
+	switch cache {
+	case "", RedisCache:
+		return nil
+	}
+	return fmt.Errorf("invalid cache %q, must be %s", cache, RedisCache)
+}
+
+// Cached reports whether the repositories are decorated with the read-through cache
+func (p *Project) Cached() bool {
+	return p.Cache != ""
+}
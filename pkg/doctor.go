@@ -0,0 +1,261 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// statuses of the doctor checks
+const (
+	CheckOK   = "ok"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// Check is the result of a doctor check
+type Check struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// toolVersion matches the semantic version in the output of the tools, e.g. v3.14.2 of helm
+var toolVersion = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?`)
+
+// Doctor checks the tools and the go environment crud needs and that the directory is writable, if the directory
+// is the root of a project it also checks that the project compiles and matches its manifest
+func Doctor(dir string) []Check {
+	checks := []Check{checkGo()}
+	checks = append(checks, checkTools()...)
+	checks = append(checks, checkWritable(dir))
+	checks = append(checks, checkGoEnv()...)
+
+	if _, err := os.Stat(filepath.Join(dir, ManifestFileName)); err == nil {
+		checks = append(checks, checkProject(dir)...)
+	}
+	return checks
+}
+
+// checkGo checks the version of the go toolchain, the generated code needs go 1.21 and some options later versions
+func checkGo() Check {
+	version, err := goVersion()
+	if err != nil {
+		return Check{"go", CheckFail, "go is not installed or not on PATH: " + err.Error()}
+	}
+	switch {
+	case !goVersionAtLeast(version, 21):
+		return Check{"go", CheckFail, fmt.Sprintf("go %s is installed, the generated code needs go 1.21 or later", version)}
+	case !goVersionAtLeast(version, 22):
+		return Check{"go", CheckWarn, fmt.Sprintf("go %s is installed, --router %s needs go 1.22 and --auth %s go 1.25", version, StdlibRouter, JWTAuth)}
+	case !goVersionAtLeast(version, 25):
+		return Check{"go", CheckWarn, fmt.Sprintf("go %s is installed, --auth %s needs go 1.25", version, JWTAuth)}
+	}
+	return Check{"go", CheckOK, "go " + version}
+}
+
+// checkTools checks the versions of the tools the generated project is built and deployed with, they are optional
+func checkTools() []Check {
+	tools := []struct {
+		name     string
+		args     []string
+		neededBy string
+	}{
+		{"helm", []string{"version", "--short"}, "crud init --chart and make helm-lint"},
+		{"docker", []string{"version", "--format", "{{.Client.Version}}"}, "build.sh, make docker-build and docker compose"},
+		{"kubectl", []string{"version", "--client"}, "the deployment of the helm chart"},
+	}
+
+	var checks []Check
+	for _, t := range tools {
+		out, err := exec.Command(t.name, t.args...).Output()
+		version := toolVersion.FindString(string(out))
+		switch {
+		case err != nil && version == "":
+			checks = append(checks, Check{t.name, CheckWarn, fmt.Sprintf("%s is not installed or not working, it is needed by %s: %v", t.name, t.neededBy, err)})
+		case t.name == "helm" && !strings.HasPrefix(strings.TrimPrefix(version, "v"), "3."):
+			checks = append(checks, Check{t.name, CheckFail, fmt.Sprintf("helm %s is installed, crud init --chart needs helm v3", version)})
+		default:
+			checks = append(checks, Check{t.name, CheckOK, t.name + " " + version})
+		}
+	}
+	return checks
+}
+
+// checkWritable checks that the project directories can be created in the directory
+func checkWritable(dir string) Check {
+	f, err := os.CreateTemp(dir, ".crud-doctor-")
+	if err != nil {
+		return Check{"directory", CheckFail, fmt.Sprintf("%s is not writable: %v", dir, err)}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return Check{"directory", CheckOK, dir + " is writable"}
+}
+
+// checkGoEnv checks the go env the modules of the generated code are downloaded with
+func checkGoEnv() []Check {
+	out, err := exec.Command("go", "env", "-json", "GOPROXY", "GOFLAGS", "GOMODCACHE").Output()
+	if err != nil {
+		return []Check{{"go env", CheckFail, "error running go env: " + err.Error()}}
+	}
+	var env struct{ GOPROXY, GOFLAGS, GOMODCACHE string }
+	if err = json.Unmarshal(out, &env); err != nil {
+		return []Check{{"go env", CheckFail, "error parsing go env: " + err.Error()}}
+	}
+
+	var checks []Check
+	switch {
+	case env.GOPROXY == "off":
+		checks = append(checks, Check{"GOPROXY", CheckFail, "GOPROXY=off, the modules of the generated code can't be downloaded unless they are in the module cache"})
+	case env.GOPROXY == "":
+		checks = append(checks, Check{"GOPROXY", CheckWarn, "GOPROXY is empty, the modules are downloaded directly from their repositories"})
+	default:
+		checks = append(checks, Check{"GOPROXY", CheckOK, env.GOPROXY})
+	}
+
+	if strings.Contains(env.GOFLAGS, "-mod=vendor") || strings.Contains(env.GOFLAGS, "-mod=readonly") {
+		checks = append(checks, Check{"GOFLAGS", CheckWarn, fmt.Sprintf("GOFLAGS=%s, go get and go mod tidy of crud init and crud add resource may fail unless -mod is mod", env.GOFLAGS)})
+	} else {
+		checks = append(checks, Check{"GOFLAGS", CheckOK, fmt.Sprintf("%q", env.GOFLAGS)})
+	}
+
+	if env.GOMODCACHE == "" {
+		return append(checks, Check{"module cache", CheckFail, "GOMODCACHE is not set, set GOPATH or GOMODCACHE"})
+	}
+	if _, err := os.Stat(env.GOMODCACHE); os.IsNotExist(err) {
+		return append(checks, Check{"module cache", CheckOK, env.GOMODCACHE + " is created by the first go get"})
+	}
+	if f, err := os.CreateTemp(env.GOMODCACHE, ".crud-doctor-"); err != nil {
+		checks = append(checks, Check{"module cache", CheckFail, fmt.Sprintf("%s is not writable: %v", env.GOMODCACHE, err)})
+	} else {
+		f.Close()
+		os.Remove(f.Name())
+		checks = append(checks, Check{"module cache", CheckOK, env.GOMODCACHE})
+	}
+	return checks
+}
+
+// checkProject checks that the project in the directory matches its manifest and compiles
+func checkProject(dir string) []Check {
+	p, err := LoadProject(dir)
+	if err != nil {
+		return []Check{{"manifest", CheckFail, err.Error()}}
+	}
+	checks := []Check{{"manifest", CheckOK, fmt.Sprintf("%s with %d resources", p.ModuleName, len(p.Resources))}}
+
+	if module, err := goModModule(dir); err != nil {
+		checks = append(checks, Check{"go.mod", CheckFail, err.Error()})
+	} else if module != p.ModuleName {
+		checks = append(checks, Check{"go.mod", CheckFail, fmt.Sprintf("module %s of go.mod doesn't match module %s of %s", module, p.ModuleName, ManifestFileName)})
+	} else {
+		checks = append(checks, Check{"go.mod", CheckOK, "module " + module})
+	}
+
+	checks = append(checks, p.checkGeneratedFiles()...)
+
+	build := exec.Command("go", "build", "./...")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		checks = append(checks, Check{"build", CheckFail, fmt.Sprintf("go build ./... failed: %v\n%s", err, bytes.TrimSpace(out))})
+	} else {
+		checks = append(checks, Check{"build", CheckOK, "go build ./... succeeded"})
+	}
+	return checks
+}
+
+// checkGeneratedFiles renders the project and checks that the files generated from the manifest exist, that the
+// files which are generated again are up to date and that no file has the conflict markers of crud upgrade
+func (p *Project) checkGeneratedFiles() []Check {
+	rendered, err := os.MkdirTemp("", "crud-doctor-")
+	if err != nil {
+		return []Check{{"generated files", CheckFail, "error creating temporary directory: " + err.Error()}}
+	}
+	defer os.RemoveAll(rendered)
+	if err = p.renderInto(rendered); err != nil {
+		return []Check{{"generated files", CheckFail, "error rendering the templates: " + err.Error()}}
+	}
+
+	var missing, outdated, conflicts []string
+	err = filepath.WalkDir(rendered, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(rendered, path)
+		if err != nil {
+			return err
+		}
+		current, err := os.ReadFile(filepath.Join(p.AbsolutePath, rel))
+		if os.IsNotExist(err) {
+			missing = append(missing, filepath.ToSlash(rel))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		expected, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(expected, generatedHeader) && !bytes.Equal(current, expected) {
+			outdated = append(outdated, filepath.ToSlash(rel))
+		}
+		if hasConflictMarkers(current) {
+			conflicts = append(conflicts, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return []Check{{"generated files", CheckFail, "error comparing the generated files: " + err.Error()}}
+	}
+
+	var checks []Check
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		checks = append(checks, Check{"generated files", CheckWarn, "missing, they may have been deleted on purpose: " + strings.Join(missing, ", ")})
+	}
+	if len(outdated) > 0 {
+		sort.Strings(outdated)
+		checks = append(checks, Check{"generated files", CheckFail, fmt.Sprintf("don't match %s, run crud upgrade: %s", ManifestFileName, strings.Join(outdated, ", "))})
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		checks = append(checks, Check{"generated files", CheckFail, "have unresolved conflict markers of crud upgrade: " + strings.Join(conflicts, ", ")})
+	}
+	if len(checks) == 0 {
+		checks = append(checks, Check{"generated files", CheckOK, "match " + ManifestFileName})
+	}
+	return checks
+}
+
+// hasConflictMarkers reports whether the file has a conflict written by crud upgrade
+func hasConflictMarkers(content []byte) bool {
+	return bytes.Contains(content, []byte("\n"+conflictStart)) || bytes.HasPrefix(content, []byte(conflictStart))
+}
+
+// goModModule returns the module path of the go.mod in the directory
+func goModModule(dir string) (string, error) {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("go.mod at %s has no module directive", dir)
+}
@@ -0,0 +1,240 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// checkStatuses returns the name and the status of the checks, e.g. go.mod:fail
+func checkStatuses(checks []Check) []string {
+	statuses := make([]string, len(checks))
+	for i, c := range checks {
+		statuses[i] = c.Name + ":" + c.Status
+	}
+	return statuses
+}
+
+// doctorProject writes the manifest of a project and the files in a temporary directory, the project isn't
+// rendered so its generated files are missing
+func doctorProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	p := &Project{ModuleName: "github.com/acme/shop", ProjectDirName: "shop", AbsolutePath: t.TempDir(), Router: MuxRouter, BaseImage: DistrolessBaseImage}
+	if err := p.WriteManifest(); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(p.AbsolutePath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p.AbsolutePath
+}
+
+func TestDoctor(t *testing.T) {
+	names := func(checks []Check) []string {
+		var names []string
+		for _, c := range checks {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+	environment := []string{"go", "helm", "docker", "kubectl", "directory", "GOPROXY", "GOFLAGS", "module cache"}
+
+	if got := names(Doctor(t.TempDir())); !reflect.DeepEqual(got, environment) {
+		t.Errorf("Doctor() of a directory without manifest checks %v, want %v", got, environment)
+	}
+
+	dir := doctorProject(t, map[string]string{"go.mod": "module github.com/acme/shop\n"})
+	got := names(Doctor(dir))
+	if len(got) <= len(environment) || !reflect.DeepEqual(got[:len(environment)], environment) || got[len(environment)] != "manifest" {
+		t.Errorf("Doctor() of a project checks %v, want the environment and then the project checked", got)
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	if c := checkWritable(dir); c.Status != CheckOK {
+		t.Errorf("checkWritable() = %+v, want ok", c)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("checkWritable() left %s behind", entries[0].Name())
+	}
+
+	if c := checkWritable(filepath.Join(dir, "missing")); c.Status != CheckFail || !strings.Contains(c.Detail, "is not writable") {
+		t.Errorf("checkWritable() of a missing directory = %+v, want fail", c)
+	}
+}
+
+func TestCheckProject(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	const goMod = "module github.com/acme/shop\n\ngo 1.21\n"
+	tests := []struct {
+		name       string
+		files      map[string]string
+		want       []string
+		wantDetail string
+	}{
+		{
+			name:  "builds",
+			files: map[string]string{"go.mod": goMod, "shop.go": "package main\n\nfunc main() {}\n"},
+			want:  []string{"manifest:ok", "go.mod:ok", "generated files:warn", "build:ok"},
+		},
+		{
+			name:       "build fails",
+			files:      map[string]string{"go.mod": goMod, "shop.go": "package main\n\nfunc main() { serve() }\n"},
+			want:       []string{"manifest:ok", "go.mod:ok", "generated files:warn", "build:fail"},
+			wantDetail: "undefined: serve",
+		},
+		{
+			name:       "module of go.mod doesn't match",
+			files:      map[string]string{"go.mod": "module github.com/acme/store\n"},
+			want:       []string{"manifest:ok", "go.mod:fail", "generated files:warn", "build:ok"},
+			wantDetail: "module github.com/acme/store of go.mod doesn't match module github.com/acme/shop of crud.yaml",
+		},
+		{
+			name:       "go.mod without module",
+			files:      map[string]string{"go.mod": "go 1.21\n"},
+			want:       []string{"manifest:ok", "go.mod:fail", "generated files:warn", "build:fail"},
+			wantDetail: "has no module directive",
+		},
+		{
+			name:       "invalid manifest",
+			files:      map[string]string{ManifestFileName: "module: ["},
+			want:       []string{"manifest:fail"},
+			wantDetail: "error parsing crud.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := checkProject(doctorProject(t, tt.files))
+			if got := checkStatuses(checks); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("checkProject() = %v, want %v\n%+v", got, tt.want, checks)
+			}
+			if tt.wantDetail == "" {
+				return
+			}
+			for _, c := range checks {
+				if c.Status == CheckFail && strings.Contains(c.Detail, tt.wantDetail) {
+					return
+				}
+			}
+			t.Errorf("checkProject() has no failed check with %q\n%+v", tt.wantDetail, checks)
+		})
+	}
+}
+
+func TestCheckGeneratedFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, dir string)
+		want   []Check
+	}{
+		{
+			name:   "generated",
+			change: func(t *testing.T, dir string) {},
+			want:   []Check{{"generated files", CheckOK, "match crud.yaml"}},
+		},
+		{
+			name: "edited",
+			change: func(t *testing.T, dir string) {
+				writeUpgradeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+			},
+			want: []Check{{"generated files", CheckOK, "match crud.yaml"}},
+		},
+		{
+			name: "deleted",
+			change: func(t *testing.T, dir string) {
+				removeUpgradeFile(t, filepath.Join(dir, "build.sh"))
+				removeUpgradeFile(t, filepath.Join(dir, "Dockerfile"))
+			},
+			want: []Check{{"generated files", CheckWarn, "missing, they may have been deleted on purpose: Dockerfile, build.sh"}},
+		},
+		{
+			name: "outdated",
+			change: func(t *testing.T, dir string) {
+				writeUpgradeFile(t, filepath.Join(dir, "pkg/repository/repositories.go"), "package repository\n")
+			},
+			want: []Check{{"generated files", CheckFail, "don't match crud.yaml, run crud upgrade: pkg/repository/repositories.go"}},
+		},
+		{
+			name: "conflict markers",
+			change: func(t *testing.T, dir string) {
+				writeUpgradeFile(t, filepath.Join(dir, "main.go"), "package main\n"+conflictStart+"a\n"+conflictSeparator+"b\n"+conflictEnd)
+				removeUpgradeFile(t, filepath.Join(dir, "Dockerfile"))
+			},
+			want: []Check{
+				{"generated files", CheckWarn, "missing, they may have been deleted on purpose: Dockerfile"},
+				{"generated files", CheckFail, "have unresolved conflict markers of crud upgrade: main.go"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := upgradeProject(t)
+			tt.change(t, p.AbsolutePath)
+			if got := p.checkGeneratedFiles(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkGeneratedFiles() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasConflictMarkers(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{content: conflictStart + "a\n" + conflictSeparator + conflictEnd, want: true},
+		{content: "a\n" + conflictStart + "b\n", want: true},
+		{content: "a := \"" + conflictStart + "\"\n", want: false},
+		{content: "a\nb\n", want: false},
+	}
+	for _, tt := range tests {
+		if got := hasConflictMarkers([]byte(tt.content)); got != tt.want {
+			t.Errorf("hasConflictMarkers(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestGoModModule(t *testing.T) {
+	tests := []struct {
+		name    string
+		goMod   string
+		want    string
+		wantErr string
+	}{
+		{name: "module", goMod: "module github.com/acme/shop\n\ngo 1.21\n", want: "github.com/acme/shop"},
+		{name: "quoted module", goMod: "// shop\nmodule \"github.com/acme/shop\"\n", want: "github.com/acme/shop"},
+		{name: "no module", goMod: "go 1.21\n", wantErr: "has no module directive"},
+		{name: "no go.mod", wantErr: "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.goMod != "" {
+				writeUpgradeFile(t, filepath.Join(dir, "go.mod"), tt.goMod)
+			}
+			got, err := goModModule(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("goModModule() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("goModModule() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
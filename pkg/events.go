@@ -0,0 +1,71 @@
+package pkg
+
+import "fmt"
+
+// brokers the domain events can be published to, the log publisher logs them
+const (
+	LogEvents   = "log"
+	KafkaEvents = "kafka"
+	NATSEvents  = "nats"
+)
+
+// modules of the event brokers and of the postgres driver of the outbox
+const (
+	KafkaModuleName = "github.com/segmentio/kafka-go"
+	NATSModuleName  = "github.com/nats-io/nats.go"
+	PgxModuleName   = "github.com/jackc/pgx/v5"
+)
+
+// ValidateEvents validates the event broker and the outbox, empty means no events are published
+func ValidateEvents(broker string, outbox bool) error {
+	switch broker {
+	case "", LogEvents, KafkaEvents, NATSEvents:
+	default:
+		return fmt.Errorf("invalid events %q, must be one of %s, %s or %s", broker, LogEvents, KafkaEvents, NATSEvents)
+	}
+	if outbox && broker == "" {
+		return fmt.Errorf("outbox needs the events broker, provide --events")
+	}
+	return nil
+}
+
+// Publishes reports whether the repositories publish the domain events of the writes, the events broker of a
+// worker is the broker the messages are consumed from
+func (p *Project) Publishes() bool {
+	return p.Events != "" && !p.Worker()
+}
+
+// DecoratesRepositories reports whether the repositories are decorated with the cache or the events
+func (p *Project) DecoratesRepositories() bool {
+	return p.Cached() || p.Publishes()
+}
+
+// eventModules returns the modules of the event broker and the outbox
+func (p *Project) eventModules() []string {
+	var modules []string
+	switch p.Events {
+	case KafkaEvents:
+		modules = append(modules, KafkaModuleName)
+	case NATSEvents:
+		modules = append(modules, NATSModuleName)
+	}
+	if p.Outbox {
+		modules = append(modules, PgxModuleName)
+	}
+	return modules
+}
+
+// BackingServices are the services of the docker-compose stack the service depends on
+func (p *Project) BackingServices() []string {
+	var services []string
+	if p.Outbox {
+		services = append(services, "postgres")
+	}
+	if p.Cached() {
+		services = append(services, "redis")
+	}
+	if p.Events == KafkaEvents || p.Events == NATSEvents {
+		services = append(services, p.Events)
+	}
+	return services
+}
@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ManifestFileName is the file in the project root which records the options the project was created with
+// and the resources added to it
+const ManifestFileName = "crud.yaml"
+
+// WriteManifest writes the project options and resources into the manifest file
+func (p *Project) WriteManifest() error {
+	out, err := yaml.Marshal(p)
+	if err != nil {
+		log.Println("error marshalling the manifest:", err)
+		return err
+	}
+
+	manifest := fmt.Sprintf("# generated by crud, it is updated by crud add commands\n# created with: %s\n%s", p.InitCommand(), out)
+	if err = os.WriteFile(p.AbsolutePath+"/"+ManifestFileName, []byte(manifest), 0644); err != nil {
+		log.Println("error writing", ManifestFileName, "at", p.AbsolutePath, ":", err)
+		return err
+	}
+	return nil
+}
+
+// InitCommand returns the crud init command which creates the project with the options of the manifest
+// non-interactively, the resources are added with crud add resource
+func (p *Project) InitCommand() string {
+	args := []string{"crud", "init", p.ModuleName}
+	if p.Worker() {
+		args = append(args, "--type", WorkerType)
+	} else if p.Router != "" && p.Router != MuxRouter {
+		args = append(args, "--router", p.Router)
+	}
+	flags := []struct {
+		set  bool
+		name string
+	}{
+		{p.CreateApiDoc, "--swagger"},
+		{p.CreateHelmChart, "--chart"},
+		{p.CreateMakefile, "--makefile"},
+		{p.CreateCompose, "--compose"},
+		{p.GRPC, "--grpc"},
+		{p.RateLimit, "--rate-limit"},
+		{p.Outbox, "--outbox"},
+		{p.Metrics, "--metrics"},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			args = append(args, flag.name)
+		}
+	}
+	options := []struct {
+		value string
+		name  string
+	}{
+		{p.CI, "--ci"},
+		{strings.Join(p.Auth, ","), "--auth"},
+		{p.Cache, "--cache"},
+		{p.Events, "--events"},
+	}
+	for _, option := range options {
+		if option.value != "" {
+			args = append(args, option.name, option.value)
+		}
+	}
+	if p.BaseImage != "" && p.BaseImage != DistrolessBaseImage {
+		args = append(args, "--base-image", p.BaseImage)
+	}
+	return strings.Join(args, " ")
+}
+
+// LoadProject loads the project created at the absolute path from its manifest file
+func LoadProject(absolutePath string) (*Project, error) {
+	manifest, err := os.ReadFile(absolutePath + "/" + ManifestFileName)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s not found at %s, run the command from the root of a project created with crud init", ManifestFileName, absolutePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Project{}
+	if err = yaml.Unmarshal(manifest, p); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", ManifestFileName, err)
+	}
+	p.AbsolutePath = absolutePath
+	// projects created before the router could be selected use gorilla mux, the workers have no router
+	if p.Router == "" && !p.Worker() {
+		p.Router = MuxRouter
+	}
+	return p, nil
+}
@@ -0,0 +1,152 @@
+package pkg
+
+import "strings"
+
+// conflict markers of the lines changed both in the project and by the templates
+const (
+	conflictStart     = "<<<<<<< current\n"
+	conflictSeparator = "=======\n"
+	conflictEnd       = ">>>>>>> upgrade\n"
+)
+
+// maxLCSCells limits the memory of the longest common subsequence of two files, the differing middle of larger
+// files is merged as a whole
+const maxLCSCells = 16 << 20
+
+// merge3 merges the changes from base to theirs into ours line by line, the lines changed differently on both
+// sides are written between conflict markers, it reports whether there were conflicts
+func merge3(base, ours, theirs string) (string, bool) {
+	baseLines, ourLines, theirLines := splitLines(base), splitLines(ours), splitLines(theirs)
+	ourMatches, theirMatches := lcsMatches(baseLines, ourLines), lcsMatches(baseLines, theirLines)
+
+	var out strings.Builder
+	conflict := false
+	i, a, b := 0, 0, 0
+	for {
+		// the next base line kept on both sides, the lines before it changed on either side
+		j := i
+		for j < len(baseLines) && (ourMatches[j] < 0 || theirMatches[j] < 0) {
+			j++
+		}
+		ourEnd, theirEnd := len(ourLines), len(theirLines)
+		if j < len(baseLines) {
+			ourEnd, theirEnd = ourMatches[j], theirMatches[j]
+		}
+
+		baseChunk, ourChunk, theirChunk := baseLines[i:j], ourLines[a:ourEnd], theirLines[b:theirEnd]
+		switch {
+		case equalLines(ourChunk, theirChunk), equalLines(baseChunk, theirChunk):
+			writeLines(&out, ourChunk)
+		case equalLines(baseChunk, ourChunk):
+			writeLines(&out, theirChunk)
+		default:
+			conflict = true
+			out.WriteString(conflictStart)
+			writeLines(&out, ourChunk)
+			terminateLine(&out)
+			out.WriteString(conflictSeparator)
+			writeLines(&out, theirChunk)
+			terminateLine(&out)
+			out.WriteString(conflictEnd)
+		}
+
+		if j == len(baseLines) {
+			return out.String(), conflict
+		}
+		out.WriteString(baseLines[j])
+		i, a, b = j+1, ourEnd+1, theirEnd+1
+	}
+}
+
+// splitLines splits the text into lines along with their line endings
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lcsMatches returns for each line of a the index of the line of b it is matched with in their longest common
+// subsequence, -1 if it is not matched
+func lcsMatches(a, b []string) []int {
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	// the common prefix and suffix are matched without the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		matches[prefix] = prefix
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		matches[len(a)-1-suffix] = len(b) - 1 - suffix
+		suffix++
+	}
+
+	n, m := len(a)-prefix-suffix, len(b)-prefix-suffix
+	if n == 0 || m == 0 || n*m > maxLCSCells {
+		return matches
+	}
+	// lengths[i][j] is the length of the longest common subsequence of a[prefix+i:] and b[prefix+j:]
+	lengths := make([][]int32, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[prefix+i] == b[prefix+j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[prefix+i] == b[prefix+j]:
+			matches[prefix+i] = prefix + j
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// equalLines reports whether the lines are the same
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeLines writes the lines to the builder
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// terminateLine ends the last line written to the builder with a line break, so the conflict markers are lines
+// of their own
+func terminateLine(out *strings.Builder) {
+	if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		out.WriteString("\n")
+	}
+}
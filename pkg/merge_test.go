@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name, base, ours, theirs string
+		want                     string
+		conflict                 bool
+	}{
+		{
+			name: "unchanged",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nb\nc\n",
+		},
+		{
+			name: "only ours changed",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "only theirs changed",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nC\nd\n",
+			want: "a\nb\nC\nd\n",
+		},
+		{
+			name: "both changed different lines",
+			base: "a\nb\nc\nd\n", ours: "A\nb\nc\nd\n", theirs: "a\nb\nc\nD\n",
+			want: "A\nb\nc\nD\n",
+		},
+		{
+			name: "both made the same change",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nB\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "both added different lines",
+			base: "a\nc\n", ours: "a\nb\nc\n", theirs: "a\nc\nd\n",
+			want: "a\nb\nc\nd\n",
+		},
+		{
+			name: "conflict",
+			base: "a\nb\nc\n", ours: "a\nours\nc\n", theirs: "a\ntheirs\nc\n",
+			want:     "a\n" + conflictStart + "ours\n" + conflictSeparator + "theirs\n" + conflictEnd + "c\n",
+			conflict: true,
+		},
+		{
+			name: "deleted in ours and changed in theirs",
+			base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nB\nc\n",
+			want:     "a\n" + conflictStart + conflictSeparator + "B\n" + conflictEnd + "c\n",
+			conflict: true,
+		},
+		{
+			name: "missing trailing newline",
+			base: "a\nb", ours: "x\na\nb", theirs: "a\nb\nc",
+			want: "x\na\nb\nc",
+		},
+		{
+			name: "conflict without trailing newline",
+			base: "a", ours: "b", theirs: "c",
+			want:     conflictStart + "b\n" + conflictSeparator + "c\n" + conflictEnd,
+			conflict: true,
+		},
+		{
+			name: "empty base with the same content",
+			base: "", ours: "a\nb\n", theirs: "a\nb\n",
+			want: "a\nb\n",
+		},
+		{
+			name: "empty base with different content",
+			base: "", ours: "a\n", theirs: "b\n",
+			want:     conflictStart + "a\n" + conflictSeparator + "b\n" + conflictEnd,
+			conflict: true,
+		},
+		{
+			name: "empty base and ours",
+			base: "", ours: "", theirs: "a\n",
+			want: "a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflict := merge3(tt.base, tt.ours, tt.theirs)
+			if got != tt.want || conflict != tt.conflict {
+				t.Errorf("merge3(%q, %q, %q) = %q, %v, want %q, %v", tt.base, tt.ours, tt.theirs, got, conflict, tt.want, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestLCSMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []int
+	}{
+		{name: "equal", a: []string{"a", "b"}, b: []string{"a", "b"}, want: []int{0, 1}},
+		{name: "changed line", a: []string{"a", "b", "c"}, b: []string{"a", "x", "c"}, want: []int{0, -1, 2}},
+		{name: "inserted lines", a: []string{"a", "c"}, b: []string{"a", "b", "c", "d"}, want: []int{0, 2}},
+		{name: "deleted lines", a: []string{"a", "b", "c", "d"}, b: []string{"b", "d"}, want: []int{-1, 0, -1, 1}},
+		{name: "reordered lines keep the later line of a", a: []string{"x", "a", "b", "y"}, b: []string{"x", "b", "a", "y"}, want: []int{0, -1, 1, 3}},
+		{name: "empty a", a: nil, b: []string{"a"}, want: []int{}},
+		{name: "empty b", a: []string{"a", "b"}, b: nil, want: []int{-1, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lcsMatches(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lcsMatches(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
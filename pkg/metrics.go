@@ -0,0 +1,4 @@
+package pkg
+
+// PrometheusModuleName is the module of the prometheus client of the metrics
+const PrometheusModuleName = "github.com/prometheus/client_golang"
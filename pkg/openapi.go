@@ -0,0 +1,383 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// OpenAPIFileName is the path of the generated OpenAPI document relative to the project root
+const OpenAPIFileName = "api/swagger.json"
+
+// listOps are the filter operators supported by each field kind, they mirror FieldOps of the generated repository package
+var listOps = map[string][]string{
+	"String": {"eq", "ne", "contains"},
+	"Bool":   {"eq", "ne"},
+	"Int":    {"eq", "ne", "gt", "gte", "lt", "lte"},
+	"Float":  {"eq", "ne", "gt", "gte", "lt", "lte"},
+	"Time":   {"eq", "ne", "gt", "gte", "lt", "lte"},
+}
+
+// opDescriptions describe the filter operators in the OpenAPI document
+var opDescriptions = map[string]string{
+	"eq":       "equal to",
+	"ne":       "not equal to",
+	"gt":       "greater than",
+	"gte":      "greater than or equal to",
+	"lt":       "less than",
+	"lte":      "less than or equal to",
+	"contains": "containing",
+}
+
+// object is a json object of the OpenAPI document, the keys are sorted when it is marshalled
+type object map[string]interface{}
+
+// writeOpenAPI writes the OpenAPI document of the http endpoints of the resources, it is written again every time
+// a resource is added
+func (p *Project) writeOpenAPI() error {
+	apiDir := p.AbsolutePath + "/api"
+	if err := createDir(apiDir); err != nil {
+		log.Println("error creating api directory at", p.AbsolutePath, ":", err)
+		return err
+	}
+
+	out, err := json.MarshalIndent(p.OpenAPI(), "", "  ")
+	if err != nil {
+		log.Println("error marshalling the OpenAPI document:", err)
+		return err
+	}
+	if err = os.WriteFile(p.AbsolutePath+"/"+OpenAPIFileName, append(out, '\n'), 0644); err != nil {
+		log.Println("error writing", OpenAPIFileName, "at", p.AbsolutePath, ":", err)
+		return err
+	}
+	return nil
+}
+
+// OpenAPI returns the OpenAPI 3 document of the http endpoints of the project
+func (p *Project) OpenAPI() object {
+	paths := object{
+		"/healthz": object{
+			"get": object{
+				"summary":     "Health check",
+				"operationId": "healthz",
+				"responses":   object{"200": object{"description": "The service is healthy"}},
+			},
+		},
+	}
+	schemas := object{
+		"Problem": object{
+			"type":        "object",
+			"description": "RFC 7807 problem details",
+			"required":    []string{"type", "title", "status"},
+			"properties": object{
+				"type":     object{"type": "string", "description": "URI identifying the problem type, about:blank if it is described by the status"},
+				"title":    object{"type": "string"},
+				"status":   object{"type": "integer"},
+				"detail":   object{"type": "string"},
+				"instance": object{"type": "string", "description": "Path of the request"},
+				"invalid-params": object{
+					"type":        "array",
+					"description": "Request fields which failed the validation",
+					"items": object{
+						"type":     "object",
+						"required": []string{"name", "reasons"},
+						"properties": object{
+							"name":    object{"type": "string"},
+							"reasons": object{"type": "array", "items": object{"type": "string"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, r := range p.Resources {
+		paths["/"+r.PathName()] = object{
+			"get":  p.limited(p.secure(r, ListAction, r.listOperation())),
+			"post": p.limited(p.secure(r, CreateAction, r.createOperation())),
+		}
+		paths["/"+r.PathName()+"/{id}"] = object{
+			"parameters": []object{{"name": "id", "in": "path", "required": true, "schema": object{"type": "string"}}},
+			"get":        p.limited(p.secure(r, GetAction, r.getOperation())),
+			"put":        p.limited(p.secure(r, UpdateAction, r.updateOperation())),
+			"delete":     p.limited(p.secure(r, DeleteAction, r.deleteOperation(p.ReferencingResources(r)))),
+		}
+		schemas[r.GoName()] = r.schema()
+		schemas[r.GoName()+"List"] = object{
+			"type":     "object",
+			"required": []string{"items", "total"},
+			"properties": object{
+				"items":      object{"type": "array", "items": schemaRef(r.GoName())},
+				"nextCursor": object{"type": "string", "description": "Cursor of the next page, it is omitted on the last page"},
+				"total":      object{"type": "integer", "description": "Number of " + r.HumanPluralName() + " matching the filters"},
+			},
+		}
+	}
+
+	components := object{
+		"schemas": schemas,
+		"parameters": object{
+			"limit": object{
+				"name": "limit", "in": "query",
+				"description": "Maximum number of items returned, larger limits are lowered to 100",
+				"schema":      object{"type": "integer", "minimum": 1, "maximum": 100, "default": 20},
+			},
+			"offset": object{
+				"name": "offset", "in": "query",
+				"description": "Number of items skipped, it is ignored when cursor is set",
+				"schema":      object{"type": "integer", "minimum": 0, "default": 0},
+			},
+			"cursor": object{
+				"name": "cursor", "in": "query",
+				"description": "nextCursor of the previous page listed with the same filters and sort",
+				"schema":      object{"type": "string"},
+			},
+		},
+	}
+	securitySchemes := object{}
+	if p.HasAuth(JWTAuth) {
+		securitySchemes["bearerAuth"] = object{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
+	}
+	if p.HasAuth(APIKeyAuth) {
+		securitySchemes["apiKeyAuth"] = object{"type": "apiKey", "in": "header", "name": DefaultAPIKeyHeader}
+	}
+	if len(securitySchemes) > 0 {
+		components["securitySchemes"] = securitySchemes
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   p.ProjectDirName,
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+// secure adds the authentication and the scopes, roles and ownership of the action to the operation of the resource
+func (p *Project) secure(r *Resource, action string, operation object) object {
+	if !p.Authenticated() {
+		return operation
+	}
+	scopes := r.Scopes[action]
+	if scopes == nil {
+		scopes = []string{}
+	}
+	var security []object
+	if p.HasAuth(JWTAuth) {
+		security = append(security, object{"bearerAuth": scopes})
+	}
+	if p.HasAuth(APIKeyAuth) {
+		// the scopes of api keys are not OAuth scopes, they are described with the 403 response
+		security = append(security, object{"apiKeyAuth": []string{}})
+	}
+	operation["security"] = security
+	responses := operation["responses"].(object)
+	responses["401"] = problemResponse("The credentials are missing or invalid")
+	if p.HasAuth(APIKeyAuth) {
+		responses["429"] = problemResponse("The rate limit of the api key is exceeded, retry after the Retry-After seconds")
+	}
+	var forbidden []string
+	if len(scopes) > 0 {
+		forbidden = append(forbidden, "the credentials are not granted the scopes "+strings.Join(scopes, ", "))
+	}
+	if roles := r.Roles[action]; len(roles) > 0 {
+		forbidden = append(forbidden, "the credentials are not granted any of the roles "+strings.Join(roles, ", "))
+	}
+	if action != ListAction && action != GetAction && r.OwnerOnly(action) {
+		forbidden = append(forbidden, "the subject of the credentials is not the owner of the "+r.HumanName())
+	}
+	if len(forbidden) > 0 {
+		description := strings.Join(forbidden, " or ")
+		responses["403"] = problemResponse(strings.ToUpper(description[:1]) + description[1:])
+	}
+	if action == ListAction && r.OwnerOnly(action) {
+		operation["description"] = "Only the " + r.HumanPluralName() + " owned by the subject of the credentials are listed"
+	}
+	if action == GetAction && r.OwnerOnly(action) {
+		operation["description"] = "The " + r.HumanPluralName() + " of other owners are not found"
+	}
+	return operation
+}
+
+func (r *Resource) listOperation() object {
+	parameters := []object{
+		{"$ref": "#/components/parameters/limit"},
+		{"$ref": "#/components/parameters/offset"},
+		{"$ref": "#/components/parameters/cursor"},
+		{
+			"name": "sort", "in": "query",
+			"description": fmt.Sprintf("Comma separated fields to sort by, a - prefix sorts in descending order, the fields are %s", strings.Join(r.listFieldNames(), ", ")),
+			"schema":      object{"type": "string", "default": "createdAt"},
+			"example":     "-createdAt,id",
+		},
+	}
+	for _, f := range r.listFields() {
+		for _, op := range listOps[f.ListKind()] {
+			name := f.JSONName()
+			if op != "eq" {
+				name += "_" + op
+			}
+			parameters = append(parameters, object{
+				"name": name, "in": "query",
+				"description": fmt.Sprintf("Filters the %s with %s %s the value", r.HumanPluralName(), f.JSONName(), opDescriptions[op]),
+				"schema":      f.schema(),
+			})
+		}
+	}
+
+	return object{
+		"summary":     "List " + r.HumanPluralName(),
+		"operationId": "list" + r.GoPluralName(),
+		"tags":        []string{r.HumanPluralName()},
+		"parameters":  parameters,
+		"responses": object{
+			"200": jsonResponse("A page of "+r.HumanPluralName(), r.GoName()+"List"),
+			"400": problemResponse("Invalid pagination, filter or sort parameters"),
+		},
+	}
+}
+
+func (r *Resource) createOperation() object {
+	return object{
+		"summary":     "Create " + r.HumanName(),
+		"operationId": "create" + r.GoName(),
+		"tags":        []string{r.HumanPluralName()},
+		"requestBody": object{"required": true, "content": object{"application/json": object{"schema": schemaRef(r.GoName())}}},
+		"responses": object{
+			"201": jsonResponse("The created "+r.HumanName(), r.GoName()),
+			"400": problemResponse("Invalid request body or fields"),
+			"409": problemResponse("The id of the " + r.HumanName() + " exists"),
+		},
+	}
+}
+
+func (r *Resource) getOperation() object {
+	return object{
+		"summary":     "Get " + r.HumanName(),
+		"operationId": "get" + r.GoName(),
+		"tags":        []string{r.HumanPluralName()},
+		"responses": object{
+			"200": jsonResponse("The "+r.HumanName(), r.GoName()),
+			"404": problemResponse("The " + r.HumanName() + " does not exist"),
+		},
+	}
+}
+
+func (r *Resource) updateOperation() object {
+	return object{
+		"summary":     "Replace " + r.HumanName(),
+		"operationId": "update" + r.GoName(),
+		"tags":        []string{r.HumanPluralName()},
+		"requestBody": object{"required": true, "content": object{"application/json": object{"schema": schemaRef(r.GoName())}}},
+		"responses": object{
+			"200": jsonResponse("The updated "+r.HumanName(), r.GoName()),
+			"400": problemResponse("Invalid request body or fields"),
+			"404": problemResponse("The " + r.HumanName() + " does not exist"),
+		},
+	}
+}
+
+// deleteOperation returns the delete operation, the resources referenced by others can't be deleted while they are
+// referenced
+func (r *Resource) deleteOperation(referencing []*Resource) object {
+	responses := object{
+		"204": object{"description": "The " + r.HumanName() + " is deleted"},
+		"404": problemResponse("The " + r.HumanName() + " does not exist"),
+	}
+	if len(referencing) > 0 {
+		names := make([]string, len(referencing))
+		for i, other := range referencing {
+			names[i] = other.HumanPluralName()
+		}
+		responses["409"] = problemResponse("The " + r.HumanName() + " is referenced by " + strings.Join(names, " or "))
+	}
+	return object{
+		"summary":     "Delete " + r.HumanName(),
+		"operationId": "delete" + r.GoName(),
+		"tags":        []string{r.HumanPluralName()},
+		"responses":   responses,
+	}
+}
+
+// schema returns the json schema of the resource model along with the validation rules of the fields
+func (r *Resource) schema() object {
+	properties := object{}
+	var required []string
+	for _, f := range r.listFields() {
+		schema := f.schema()
+		f.schemaConstraints(schema)
+		if ref := f.Reference(); ref != nil {
+			schema["description"] = "Id of the referenced " + ref.HumanName()
+		}
+		if f.Nullable {
+			schema["nullable"] = true
+		}
+		properties[f.JSONName()] = schema
+		if f.Validation != nil && f.Validation.Required {
+			required = append(required, f.JSONName())
+		}
+	}
+	for _, name := range []string{"id", "createdAt", "updatedAt"} {
+		properties[name].(object)["readOnly"] = true
+	}
+	if r.Owned() {
+		properties["ownerId"].(object)["readOnly"] = true
+		properties["ownerId"].(object)["description"] = "Subject of the credentials which created the " + r.HumanName()
+	}
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// listFields returns the fields the resource can be filtered and sorted by, the resource fields along with
+// the id and timestamps every model has
+func (r *Resource) listFields() []*Field {
+	fields := []*Field{{Name: "id", Type: StringFieldType}}
+	fields = append(fields, r.Fields...)
+	if r.Owned() {
+		fields = append(fields, &Field{Name: "ownerId", Type: StringFieldType})
+	}
+	return append(fields, &Field{Name: "createdAt", Type: TimeFieldType}, &Field{Name: "updatedAt", Type: TimeFieldType})
+}
+
+func (r *Resource) listFieldNames() []string {
+	var names []string
+	for _, f := range r.listFields() {
+		names = append(names, f.JSONName())
+	}
+	return names
+}
+
+// schema returns the json schema of the field type
+func (f *Field) schema() object {
+	switch f.Type {
+	case BoolFieldType:
+		return object{"type": "boolean"}
+	case IntFieldType, Int64FieldType:
+		return object{"type": "integer", "format": "int64"}
+	case Float64FieldType:
+		return object{"type": "number", "format": "double"}
+	case TimeFieldType:
+		return object{"type": "string", "format": "date-time"}
+	}
+	return object{"type": "string"}
+}
+
+func schemaRef(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonResponse(description, schema string) object {
+	return object{"description": description, "content": object{"application/json": object{"schema": schemaRef(schema)}}}
+}
+
+func problemResponse(description string) object {
+	return object{"description": description, "content": object{"application/problem+json": object{"schema": schemaRef("Problem")}}}
+}
@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// shopProject adds customer and order resources to the project, the orders reference the customers and their
+// parent order
+func shopProject(t *testing.T, p *Project) *Project {
+	t.Helper()
+	resources := []struct {
+		name   string
+		fields []string
+	}{
+		{"customer", []string{"name:string:required,max=100"}},
+		{"order", []string{"customerId:string:required,ref=customer", "parentId:string:ref=order,nullable", "status:string:enum=open|paid"}},
+	}
+	for _, resource := range resources {
+		var fields []*Field
+		for _, definition := range resource.fields {
+			f, err := ParseField(definition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields = append(fields, f)
+		}
+		r, err := NewResource(resource.name, fields, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Resources = append(p.Resources, r)
+	}
+	p.ProjectDirName = "shop"
+	return p
+}
+
+// lookup returns the value of the document at the keys, e.g. paths, /items, get, or nil if it doesn't exist
+func lookup(t *testing.T, doc object, keys ...string) interface{} {
+	t.Helper()
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value interface{}
+	if err = json.Unmarshal(out, &value); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+func TestOpenAPI(t *testing.T) {
+	doc := shopProject(t, &Project{}).OpenAPI()
+
+	if got := lookup(t, doc, "info", "title"); got != "shop" {
+		t.Errorf("title = %v, want shop", got)
+	}
+	for _, path := range []string{"/healthz", "/customers", "/customers/{id}", "/orders", "/orders/{id}"} {
+		if lookup(t, doc, "paths", path) == nil {
+			t.Errorf("paths have no %s", path)
+		}
+	}
+	if got := lookup(t, doc, "paths", "/orders", "post", "operationId"); got != "createOrder" {
+		t.Errorf("operationId of POST /orders = %v, want createOrder", got)
+	}
+	if got := lookup(t, doc, "components", "securitySchemes"); got != nil {
+		t.Errorf("securitySchemes = %v, want none without auth", got)
+	}
+
+	// the resources referenced by others can't be deleted while they are referenced
+	if got := lookup(t, doc, "paths", "/customers/{id}", "delete", "responses", "409", "description"); got != "The customer is referenced by orders" {
+		t.Errorf("409 of DELETE /customers/{id} = %v, want the referencing orders", got)
+	}
+	if got := lookup(t, doc, "paths", "/orders/{id}", "delete", "responses", "409", "description"); got != "The order is referenced by orders" {
+		t.Errorf("409 of DELETE /orders/{id} = %v, want the self reference", got)
+	}
+
+	schema := lookup(t, doc, "components", "schemas", "Order")
+	want := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"customerId"},
+		"properties": map[string]interface{}{
+			"id":         map[string]interface{}{"type": "string", "readOnly": true},
+			"customerId": map[string]interface{}{"type": "string", "description": "Id of the referenced customer"},
+			"parentId":   map[string]interface{}{"type": "string", "description": "Id of the referenced order", "nullable": true},
+			"status":     map[string]interface{}{"type": "string", "enum": []interface{}{"open", "paid"}},
+			"createdAt":  map[string]interface{}{"type": "string", "format": "date-time", "readOnly": true},
+			"updatedAt":  map[string]interface{}{"type": "string", "format": "date-time", "readOnly": true},
+		},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("Order schema = %v, want %v", schema, want)
+	}
+
+	var filters []string
+	for _, parameter := range lookup(t, doc, "paths", "/customers", "get", "parameters").([]interface{}) {
+		if name, ok := parameter.(map[string]interface{})["name"].(string); ok && strings.HasPrefix(name, "name") {
+			filters = append(filters, name)
+		}
+	}
+	if want := []string{"name", "name_ne", "name_contains"}; !reflect.DeepEqual(filters, want) {
+		t.Errorf("filters of the customer name = %v, want %v", filters, want)
+	}
+}
+
+func TestOpenAPISecurity(t *testing.T) {
+	p := shopProject(t, &Project{Auth: []string{JWTAuth, APIKeyAuth}, RateLimit: true})
+	order := p.Resources[1]
+	order.Scopes = map[string][]string{DeleteAction: {"orders:write"}}
+	order.Roles = map[string][]string{DeleteAction: {"admin"}}
+	order.Owner = []string{ListAction, UpdateAction}
+	doc := p.OpenAPI()
+
+	if got := lookup(t, doc, "components", "securitySchemes", "apiKeyAuth", "name"); got != DefaultAPIKeyHeader {
+		t.Errorf("header of apiKeyAuth = %v, want %s", got, DefaultAPIKeyHeader)
+	}
+	want := []interface{}{
+		map[string]interface{}{"bearerAuth": []interface{}{"orders:write"}},
+		map[string]interface{}{"apiKeyAuth": []interface{}{}},
+	}
+	if got := lookup(t, doc, "paths", "/orders/{id}", "delete", "security"); !reflect.DeepEqual(got, want) {
+		t.Errorf("security of DELETE /orders/{id} = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		path, method, status string
+		want                 interface{}
+	}{
+		{"/orders/{id}", "delete", "403", "The credentials are not granted the scopes orders:write or the credentials are not granted any of the roles admin"},
+		{"/orders/{id}", "put", "403", "The subject of the credentials is not the owner of the order"},
+		{"/orders/{id}", "get", "403", nil},
+		{"/orders", "get", "401", "The credentials are missing or invalid"},
+		{"/orders", "get", "429", "The rate limit of the client is exceeded, retry after the Retry-After seconds"},
+	}
+	for _, tt := range tests {
+		if got := lookup(t, doc, "paths", tt.path, tt.method, "responses", tt.status, "description"); got != tt.want {
+			t.Errorf("%s of %s %s = %v, want %v", tt.status, strings.ToUpper(tt.method), tt.path, got, tt.want)
+		}
+	}
+	if got := lookup(t, doc, "paths", "/orders", "get", "description"); got != "Only the orders owned by the subject of the credentials are listed" {
+		t.Errorf("description of GET /orders = %v, want the owned orders listed", got)
+	}
+	if got := lookup(t, doc, "components", "schemas", "Order", "properties", "ownerId", "readOnly"); got != true {
+		t.Errorf("ownerId of the Order schema is not read only")
+	}
+}
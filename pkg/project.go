@@ -1,29 +1,108 @@
 package pkg
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/piyushjajoo/crud/tpl"
 )
 
 type Project struct {
-	ModuleName      string
-	ProjectDirName  string
-	AbsolutePath    string
-	CreateApiDoc    bool
-	CreateHelmChart bool
+	ModuleName      string      `yaml:"module"`
+	ProjectDirName  string      `yaml:"name"`
+	Type            string      `yaml:"type,omitempty"`
+	AbsolutePath    string      `yaml:"-"`
+	CreateApiDoc    bool        `yaml:"swagger"`
+	CreateHelmChart bool        `yaml:"chart"`
+	CreateMakefile  bool        `yaml:"makefile"`
+	CreateCompose   bool        `yaml:"compose"`
+	BaseImage       string      `yaml:"baseImage"`
+	CI              string      `yaml:"ci,omitempty"`
+	Router          string      `yaml:"router"`
+	GRPC            bool        `yaml:"grpc"`
+	Auth            []string    `yaml:"auth,omitempty"`
+	RateLimit       bool        `yaml:"rateLimit,omitempty"`
+	Cache           string      `yaml:"cache,omitempty"`
+	Events          string      `yaml:"events,omitempty"`
+	Outbox          bool        `yaml:"outbox,omitempty"`
+	Metrics         bool        `yaml:"metrics,omitempty"`
+	TSClient        string      `yaml:"tsClient,omitempty"`
+	GoVersion       string      `yaml:"goVersion"`
+	Resources       []*Resource `yaml:"resources,omitempty"`
 }
 
 const (
-	GorillaMuxModuleName = "github.com/gorilla/mux"
-	EnvConfigModuleName  = "github.com/kelseyhightower/envconfig"
-	ValidatorModuleName  = "github.com/go-playground/validator"
+	GorillaMuxModuleName  = "github.com/gorilla/mux"
+	ChiModuleName         = "github.com/go-chi/chi/v5"
+	GinModuleName         = "github.com/gin-gonic/gin"
+	EchoModuleName        = "github.com/labstack/echo/v4"
+	EnvConfigModuleName   = "github.com/kelseyhightower/envconfig"
+	ValidatorModuleName   = "github.com/go-playground/validator"
+	GRPCModuleName        = "google.golang.org/grpc"
+	ProtobufModuleName    = "google.golang.org/protobuf"
+	GenprotoRPCModuleName = "google.golang.org/genproto/googleapis/rpc"
 )
 
+// base image profiles for the final stage of the Dockerfile
+const (
+	DistrolessBaseImage = "distroless"
+	ScratchBaseImage    = "scratch"
+)
+
+// ci providers for which the pipeline can be generated
+const (
+	GitHubCI = "github"
+	GitLabCI = "gitlab"
+)
+
+// ValidateModuleName validates the syntax of the module name, its elements are separated by slashes and made of
+// letters, digits and the -._~ characters, the last element is the directory name of the project
+func ValidateModuleName(moduleName string) error {
+	if moduleName == "" {
+		return fmt.Errorf("module name can't be empty")
+	}
+	for _, element := range strings.Split(moduleName, "/") {
+		if element == "" {
+			return fmt.Errorf("invalid module name %q, it has an empty path element", moduleName)
+		}
+		if strings.HasPrefix(element, ".") || strings.HasSuffix(element, ".") {
+			return fmt.Errorf("invalid module name %q, path element %q can't start or end with a dot", moduleName, element)
+		}
+		for _, r := range element {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-._~", r)) {
+				return fmt.Errorf("invalid module name %q, character %q is not allowed, use letters, digits and -._~", moduleName, r)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateBaseImage validates the base image profile of the Dockerfile
+func ValidateBaseImage(baseImage string) error {
+	switch baseImage {
+	case DistrolessBaseImage, ScratchBaseImage:
+		return nil
+	}
+	return fmt.Errorf("invalid base image %q, must be one of %s or %s", baseImage, DistrolessBaseImage, ScratchBaseImage)
+}
+
+// ValidateCI validates the ci provider, empty means no pipeline is generated
+func ValidateCI(ci string) error {
+	switch ci {
+	case "", GitHubCI, GitLabCI:
+		return nil
+	}
+	return fmt.Errorf("invalid ci %q, must be one of %s or %s", ci, GitHubCI, GitLabCI)
+}
+
 func (p *Project) Create() error {
 
 	cwd, err := os.Getwd()
@@ -49,12 +128,25 @@ func (p *Project) Create() error {
 			return err
 		}
 
-		// go get gorilla mux
-		if err := goGet(GorillaMuxModuleName); err != nil {
-			log.Println("error getting module", GorillaMuxModuleName, ":", err)
+		// go version used by the builder stage of the Dockerfile
+		if p.GoVersion, err = goVersion(); err != nil {
+			log.Println("error getting go version:", err)
 			return err
 		}
 
+		// go get the router, the standard library router needs go 1.22 or later
+		if p.Router == StdlibRouter && !goVersionAtLeast(p.GoVersion, 22) {
+			err = fmt.Errorf("router %s needs go 1.22 or later, found go %s", StdlibRouter, p.GoVersion)
+			log.Println("error getting router:", err)
+			return err
+		}
+		if module := routers[p.Router].module; module != "" {
+			if err := goGet(module); err != nil {
+				log.Println("error getting module", module, ":", err)
+				return err
+			}
+		}
+
 		// go get github.com/kelseyhightower/envconfig
 		if err := goGet(EnvConfigModuleName); err != nil {
 			log.Println("error getting module", EnvConfigModuleName, ":", err)
@@ -67,6 +159,63 @@ func (p *Project) Create() error {
 			return err
 		}
 
+		// go get grpc, protobuf and the rpc error details modules if grpc flag is set
+		if p.GRPC {
+			for _, module := range []string{GRPCModuleName, ProtobufModuleName, GenprotoRPCModuleName} {
+				if err := goGet(module); err != nil {
+					log.Println("error getting module", module, ":", err)
+					return err
+				}
+			}
+		}
+
+		// go get the jwt module if any auth is set, the JWKS module if jwt auth is set, it needs go 1.25 or later,
+		// and the rate module if apikey auth is set
+		if p.Authenticated() {
+			modules := []string{JWTModuleName}
+			if p.HasAuth(JWTAuth) {
+				if !goVersionAtLeast(p.GoVersion, 25) {
+					err = fmt.Errorf("auth %s needs go 1.25 or later, found go %s", JWTAuth, p.GoVersion)
+					log.Println("error getting auth modules:", err)
+					return err
+				}
+				modules = append(modules, KeyfuncModuleName)
+			}
+			if p.HasAuth(APIKeyAuth) {
+				modules = append(modules, RateModuleName)
+			}
+			for _, module := range modules {
+				if err := goGet(module); err != nil {
+					log.Println("error getting module", module, ":", err)
+					return err
+				}
+			}
+		}
+
+		// go get the redis client if the cache is set
+		if p.Cached() {
+			if err := goGet(RedisModuleName); err != nil {
+				log.Println("error getting module", RedisModuleName, ":", err)
+				return err
+			}
+		}
+
+		// go get the prometheus client if the metrics are set
+		if p.Metrics {
+			if err := goGet(PrometheusModuleName); err != nil {
+				log.Println("error getting module", PrometheusModuleName, ":", err)
+				return err
+			}
+		}
+
+		// go get the event broker client and the postgres driver of the outbox if the events are set
+		for _, module := range p.eventModules() {
+			if err := goGet(module); err != nil {
+				log.Println("error getting module", module, ":", err)
+				return err
+			}
+		}
+
 		// change the directory to cwd
 		err = os.Chdir(cwd)
 		if err != nil {
@@ -75,6 +224,55 @@ func (p *Project) Create() error {
 		}
 	}
 
+	// render the files of the templates
+	if err = p.render(); err != nil {
+		return err
+	}
+
+	// the api key file is written once, the keys are added to it with crud add apikey
+	if p.HasAuth(APIKeyAuth) {
+		if err = p.writeAPIKeys(p.AbsolutePath+"/"+APIKeysFileName, nil); err != nil {
+			return err
+		}
+	}
+
+	// if helm flag is set, create helm chart
+	if p.CreateHelmChart {
+		if err := os.Chdir(p.AbsolutePath); err != nil {
+			log.Println("error changing directory to path", p.AbsolutePath, ":", err)
+			return err
+		} else {
+			chartsDir := p.AbsolutePath+"/charts"
+			if err = createDir(chartsDir); err != nil {
+				log.Println("error creating charts directory at", p.AbsolutePath, ":", err)
+				return err
+			}
+			err = runCommand("helm", "create", chartsDir+"/"+p.ProjectDirName)
+			if err != nil {
+				log.Println("error creating helm chart at", chartsDir, ":", err)
+				return err
+			}
+			// change the directory to cwd
+			err = os.Chdir(cwd)
+			if err != nil {
+				log.Println("error changing current working directory to", cwd, "after creating helm chart:", err)
+				return err
+			}
+		}
+	}
+
+	// record the generated files, crud upgrade merges the changes of the templates into the project against them
+	if err = p.writeBase(); err != nil {
+		return err
+	}
+
+	// record the options in the manifest used by crud add commands
+	return p.WriteManifest()
+}
+
+// render renders the templates of the project and of its resources into the project directory, crud upgrade
+// renders them into a temporary directory
+func (p *Project) render() error {
 	// create main.go
 	mainFile, err := os.Create(fmt.Sprintf("%s/main.go", p.AbsolutePath))
 	if err != nil {
@@ -83,20 +281,34 @@ func (p *Project) Create() error {
 	}
 	defer mainFile.Close()
 
-	mainTemplate := template.Must(template.New("main").Parse(string(tpl.MainTemplate())))
+	// the worker consumes the messages of the broker instead of serving the http server
+	mainContent := tpl.MainTemplate()
+	if p.Worker() {
+		mainContent = tpl.WorkerMainTemplate()
+	}
+	mainTemplate := template.Must(template.New("main").Parse(string(mainContent)))
 	err = mainTemplate.Execute(mainFile, p)
 	if err != nil {
 		log.Println("error executing template for main.go", err)
 		return err
 	}
 
-	// create routes, consts, conf, models and utils directories under pkg directory
+	// create routes, consts, conf, models, utils and lifecycle directories under pkg directory
 	pkgDir := p.AbsolutePath + "/pkg"
 	if err = createDir(pkgDir); err != nil {
 		log.Println("error creating pkg directory at", p.AbsolutePath, ":", err)
 		return err
 	}
 
+	lifecycleDir := pkgDir + "/lifecycle"
+	if err = createDir(lifecycleDir); err != nil {
+		log.Println("error creating lifecycle directory at", pkgDir, ":", err)
+		return err
+	}
+	if err = p.createFileFromTemplate(lifecycleDir+"/lifecycle.go", "lifecycle", tpl.LifecycleTemplate(), p); err != nil {
+		return err
+	}
+
 	routesDir := pkgDir + "/routes"
 	if err = createDir(routesDir); err != nil {
 		log.Println("error creating routes directory at", pkgDir, ":", err)
@@ -109,12 +321,30 @@ func (p *Project) Create() error {
 		}
 		defer routesFile.Close()
 
-		routesTemplate := template.Must(template.New("routes").Parse(string(tpl.RoutesTemplate())))
+		// the routes of the worker register the handlers of the message types
+		routesContent := tpl.RoutesTemplate()
+		if p.Worker() {
+			routesContent = tpl.WorkerRoutesTemplate()
+		}
+		routesTemplate := template.Must(template.New("routes").Parse(string(routesContent)))
 		err = routesTemplate.Execute(routesFile, p)
 		if err != nil {
 			log.Println("error executing template for routes.go", err)
 			return err
 		}
+
+		if !p.Worker() {
+			if err = p.createFileFromTemplate(routesDir+"/routes_test.go", "routestest", tpl.RoutesTestTemplate(), p); err != nil {
+				return err
+			}
+		}
+
+		// gin and echo handlers are adapted from http.Handler
+		if p.Router == GinRouter || p.Router == EchoRouter {
+			if err = p.createFileFromTemplate(routesDir+"/adapter.go", "adapter", tpl.RouterAdapterTemplate(), p); err != nil {
+				return err
+			}
+		}
 	}
 
 	confDir := pkgDir + "/conf"
@@ -175,45 +405,26 @@ func (p *Project) Create() error {
 			log.Println("error executing template for utils.go", err)
 			return err
 		}
-	}
 
-	// if api flag is set, create api documentation
-	if p.CreateApiDoc {
-		apiDir := p.AbsolutePath + "/api"
-		if err = createDir(apiDir); err != nil {
-			log.Println("error creating api directory at", p.AbsolutePath, ":", err)
-			return err
-		}
-		swaggerFile, err := os.Create(fmt.Sprintf("%s/swagger.json", apiDir))
-		if err != nil {
-			log.Println("error creating swagger.json at", apiDir, ":", err)
+		if err = p.createFileFromTemplate(utilsDir+"/tls.go", "tls", tpl.TLSTemplate(), p); err != nil {
 			return err
 		}
-		defer swaggerFile.Close()
 	}
 
-	// if helm flag is set, create helm chart
-	if p.CreateHelmChart {
-		if err := os.Chdir(p.AbsolutePath); err != nil {
-			log.Println("error changing directory to path", p.AbsolutePath, ":", err)
+	// create the packages of the resource endpoints of the service or the consumer of the worker
+	if p.Worker() {
+		err = p.createWorkerPackages(pkgDir)
+	} else {
+		err = p.createServicePackages(pkgDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	// render the files of the resources added with crud add resource
+	for _, r := range p.Resources {
+		if err = p.renderResource(r); err != nil {
 			return err
-		} else {
-			chartsDir := p.AbsolutePath+"/charts"
-			if err = createDir(chartsDir); err != nil {
-				log.Println("error creating charts directory at", p.AbsolutePath, ":", err)
-				return err
-			}
-			err = exec.Command("helm", "create", chartsDir+"/"+p.ProjectDirName).Run()
-			if err != nil {
-				log.Println("error creating helm chart at", chartsDir, ":", err)
-				return err
-			}
-			// change the directory to cwd
-			err = os.Chdir(cwd)
-			if err != nil {
-				log.Println("error changing current working directory to", cwd, "after creating helm chart:", err)
-				return err
-			}
 		}
 	}
 
@@ -240,6 +451,11 @@ func (p *Project) Create() error {
 		return err
 	}
 
+	// create .dockerignore to keep the build context small
+	if err = p.createFileFromTemplate(p.AbsolutePath+"/.dockerignore", "dockerignore", tpl.DockerignoreTemplate(), p); err != nil {
+		return err
+	}
+
 	// create build.sh to build the docker image
 	buildFile, err := os.Create(fmt.Sprintf("%s/build.sh", p.AbsolutePath))
 	if err != nil {
@@ -262,17 +478,338 @@ func (p *Project) Create() error {
 		return err
 	}
 
+	// if makefile flag is set, create Makefile with the developer targets
+	if p.CreateMakefile {
+		if err = p.createFileFromTemplate(p.AbsolutePath+"/Makefile", "makefile", tpl.MakefileTemplate(), p); err != nil {
+			return err
+		}
+	}
+
+	// if compose flag is set, create docker-compose.yaml to run the stack locally
+	if p.CreateCompose {
+		if err = p.createFileFromTemplate(p.AbsolutePath+"/docker-compose.yaml", "compose", tpl.ComposeTemplate(), p); err != nil {
+			return err
+		}
+	}
+
+	// if ci flag is set, create the pipeline for the ci provider
+	switch p.CI {
+	case GitHubCI:
+		workflowsDir := p.AbsolutePath + "/.github/workflows"
+		if err = os.MkdirAll(workflowsDir, 0754); err != nil {
+			log.Println("error creating workflows directory at", p.AbsolutePath, ":", err)
+			return err
+		}
+		if err = p.createFileFromTemplate(workflowsDir+"/ci.yaml", "github", tpl.GitHubWorkflowTemplate(), p); err != nil {
+			return err
+		}
+	case GitLabCI:
+		if err = p.createFileFromTemplate(p.AbsolutePath+"/.gitlab-ci.yml", "gitlab", tpl.GitLabCITemplate(), p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createServicePackages creates the packages of the resource endpoints under the pkg directory of the service
+func (p *Project) createServicePackages(pkgDir string) error {
+	var err error
+	// create apierror directory with the problem details error responses of the handlers and middleware
+	apiErrorDir := pkgDir + "/apierror"
+	if err = createDir(apiErrorDir); err != nil {
+		log.Println("error creating apierror directory at", pkgDir, ":", err)
+		return err
+	}
+	if err = p.createFileFromTemplate(apiErrorDir+"/apierror.go", "apierror", tpl.APIErrorTemplate(), p); err != nil {
+		return err
+	}
+
+	// if auth flag is set, create auth directory with the authentication and authorization middleware, the jwt
+	// verification along with the test token issuer and the api key store
+	if p.Authenticated() {
+		authDir := pkgDir + "/auth"
+		if err = createDir(authDir); err != nil {
+			log.Println("error creating auth directory at", pkgDir, ":", err)
+			return err
+		}
+		if err = p.createFileFromTemplate(authDir+"/auth.go", "auth", tpl.AuthTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(authDir+"/auth_test.go", "authmiddlewaretest", tpl.AuthMiddlewareTestTemplate(), p); err != nil {
+			return err
+		}
+	}
+	if p.HasAuth(JWTAuth) {
+		authDir := pkgDir + "/auth"
+		if err = createDir(authDir + "/authtest"); err != nil {
+			log.Println("error creating authtest directory at", authDir, ":", err)
+			return err
+		}
+		if err = p.createFileFromTemplate(authDir+"/jwt.go", "jwt", tpl.JWTTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(authDir+"/authtest/authtest.go", "authtest", tpl.AuthTestTemplate(), p); err != nil {
+			return err
+		}
+	}
+	if p.HasAuth(APIKeyAuth) {
+		if err = p.createFileFromTemplate(pkgDir+"/auth/apikey.go", "apikey", tpl.APIKeyTemplate(), p); err != nil {
+			return err
+		}
+	}
+
+	// if rate-limit flag is set, create ratelimit directory with the token bucket limiter of the routes
+	if p.RateLimit {
+		rateLimitDir := pkgDir + "/ratelimit"
+		if err = createDir(rateLimitDir); err != nil {
+			log.Println("error creating ratelimit directory at", pkgDir, ":", err)
+			return err
+		}
+		if err = p.createFileFromTemplate(rateLimitDir+"/ratelimit.go", "ratelimit", tpl.RateLimitTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(rateLimitDir+"/ratelimit_test.go", "ratelimittest", tpl.RateLimitTestTemplate(), p); err != nil {
+			return err
+		}
+	}
+
+	// if metrics flag is set, create metrics directory with the prometheus metrics of the requests
+	if p.Metrics {
+		metricsDir := pkgDir + "/metrics"
+		if err = createDir(metricsDir); err != nil {
+			log.Println("error creating metrics directory at", pkgDir, ":", err)
+			return err
+		}
+		if err = p.createFileFromTemplate(metricsDir+"/metrics.go", "metrics", tpl.MetricsTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(metricsDir+"/metrics_test.go", "metricstest", tpl.MetricsTestTemplate(), p); err != nil {
+			return err
+		}
+	}
+
+	// create middleware directory with the middleware applied to every router
+	middlewareDir := pkgDir + "/middleware"
+	if err = createDir(middlewareDir); err != nil {
+		log.Println("error creating middleware directory at", pkgDir, ":", err)
+		return err
+	}
+	if err = p.createFileFromTemplate(middlewareDir+"/middleware.go", "middleware", tpl.MiddlewareTemplate(), p); err != nil {
+		return err
+	}
+
+	// create repository and handlers directories for the resources added with crud add resource
+	repositoryDir := pkgDir + "/repository"
+	if err = createDir(repositoryDir); err != nil {
+		log.Println("error creating repository directory at", pkgDir, ":", err)
+		return err
+	}
+	if err = p.createFileFromTemplate(repositoryDir+"/repository.go", "repository", tpl.RepositoryTemplate(), p); err != nil {
+		return err
+	}
+	if err = p.createFileFromTemplate(repositoryDir+"/list.go", "list", tpl.ListTemplate(), p); err != nil {
+		return err
+	}
+
+	// if cache flag is set, create cache directory with the redis and in-process caches along with the
+	// read-through repository decorator
+	if p.Cached() {
+		cacheDir := pkgDir + "/cache"
+		if err = createDir(cacheDir); err != nil {
+			log.Println("error creating cache directory at", pkgDir, ":", err)
+			return err
+		}
+		if err = p.createFileFromTemplate(cacheDir+"/cache.go", "cache", tpl.CacheTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(cacheDir+"/cache_test.go", "cachetest", tpl.CacheTestTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(repositoryDir+"/cache.go", "repositorycache", tpl.CachedRepositoryTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(repositoryDir+"/cache_test.go", "repositorycachetest", tpl.CachedRepositoryTestTemplate(), p); err != nil {
+			return err
+		}
+	}
+
+	// if events flag is set, create events directory with the publisher of the broker and the outbox along with
+	// the repository decorator publishing the events of the writes
+	if p.Publishes() {
+		eventsDir := pkgDir + "/events"
+		if err = createDir(eventsDir); err != nil {
+			log.Println("error creating events directory at", pkgDir, ":", err)
+			return err
+		}
+		if err = p.createFileFromTemplate(eventsDir+"/events.go", "events", tpl.EventsTemplate(), p); err != nil {
+			return err
+		}
+		switch p.Events {
+		case KafkaEvents:
+			err = p.createFileFromTemplate(eventsDir+"/kafka.go", "kafka", tpl.KafkaPublisherTemplate(), p)
+		case NATSEvents:
+			err = p.createFileFromTemplate(eventsDir+"/nats.go", "nats", tpl.NATSPublisherTemplate(), p)
+		}
+		if err != nil {
+			return err
+		}
+		if p.Outbox {
+			if err = p.createFileFromTemplate(eventsDir+"/outbox.go", "outbox", tpl.OutboxTemplate(), p); err != nil {
+				return err
+			}
+		}
+		if err = p.createFileFromTemplate(repositoryDir+"/events.go", "repositoryevents", tpl.PublishingRepositoryTemplate(), p); err != nil {
+			return err
+		}
+		if err = p.createFileFromTemplate(repositoryDir+"/events_test.go", "repositoryeventstest", tpl.PublishingRepositoryTestTemplate(), p); err != nil {
+			return err
+		}
+	}
+
+	handlersDir := pkgDir + "/handlers"
+	if err = createDir(handlersDir); err != nil {
+		log.Println("error creating handlers directory at", pkgDir, ":", err)
+		return err
+	}
+	if err = p.createFileFromTemplate(handlersDir+"/handlers.go", "handlers", tpl.HandlersTemplate(), p); err != nil {
+		return err
+	}
+
+	// if grpc flag is set, create grpcserver directory for the grpc services of the resources
+	if p.GRPC {
+		if err = createDir(pkgDir + "/grpcserver"); err != nil {
+			log.Println("error creating grpcserver directory at", pkgDir, ":", err)
+			return err
+		}
+	}
+
+	// create client directory with the typed go client of the api, the clients of the resources are added with
+	// crud add resource
+	clientDir := p.AbsolutePath + "/client"
+	if err = createDir(clientDir); err != nil {
+		log.Println("error creating client directory at", p.AbsolutePath, ":", err)
+		return err
+	}
+	if err = p.createFileFromTemplate(clientDir+"/client.go", "client", tpl.ClientTemplate(), p); err != nil {
+		return err
+	}
+	if err = p.createFileFromTemplate(clientDir+"/errors.go", "clienterrors", tpl.ClientErrorsTemplate(), p); err != nil {
+		return err
+	}
+	if err = p.createFileFromTemplate(clientDir+"/client_test.go", "clienttest", tpl.ClientTestTemplate(), p); err != nil {
+		return err
+	}
+
+	return p.createResourceRegistry()
+}
+
+// createWorkerPackages creates the consumer of the broker and the handlers of the message types under the pkg
+// directory of the worker
+func (p *Project) createWorkerPackages(pkgDir string) error {
+	var err error
+	consumerDir := pkgDir + "/consumer"
+	if err = createDir(consumerDir); err != nil {
+		log.Println("error creating consumer directory at", pkgDir, ":", err)
+		return err
+	}
+	if err = p.createFileFromTemplate(consumerDir+"/consumer.go", "consumer", tpl.ConsumerTemplate(), p); err != nil {
+		return err
+	}
+	if err = p.createFileFromTemplate(consumerDir+"/memory.go", "memory", tpl.MemorySubscriptionTemplate(), p); err != nil {
+		return err
+	}
+	switch p.Events {
+	case KafkaEvents:
+		err = p.createFileFromTemplate(consumerDir+"/kafka.go", "kafka", tpl.KafkaSubscriptionTemplate(), p)
+	case NATSEvents:
+		err = p.createFileFromTemplate(consumerDir+"/nats.go", "nats", tpl.NATSSubscriptionTemplate(), p)
+	}
+	if err != nil {
+		return err
+	}
+
+	handlersDir := pkgDir + "/handlers"
+	if err = createDir(handlersDir); err != nil {
+		log.Println("error creating handlers directory at", pkgDir, ":", err)
+		return err
+	}
+	return p.createFileFromTemplate(handlersDir+"/handlers.go", "handlers", tpl.WorkerHandlersTemplate(), p)
+}
+
+// templateFuncs are the functions available in the templates
+var templateFuncs = template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+}
+
+// createFileFromTemplate creates the file at the provided absolute path and renders the named template into it,
+// go files are formatted with gofmt
+func (p *Project) createFileFromTemplate(absolutePath, name string, content []byte, data interface{}) error {
+	var out bytes.Buffer
+	fileTemplate := template.Must(template.New(name).Funcs(templateFuncs).Parse(string(content)))
+	err := fileTemplate.Execute(&out, data)
+	if err != nil {
+		log.Println("error executing template for", absolutePath, ":", err)
+		return err
+	}
+
+	rendered := out.Bytes()
+	if strings.HasSuffix(absolutePath, ".go") {
+		if rendered, err = format.Source(rendered); err != nil {
+			log.Println("error formatting", absolutePath, ":", err)
+			return err
+		}
+	}
+
+	if err = os.MkdirAll(filepath.Dir(absolutePath), 0754); err != nil {
+		log.Println("error creating directory of", absolutePath, ":", err)
+		return err
+	}
+	if err = os.WriteFile(absolutePath, rendered, 0644); err != nil {
+		log.Println("error creating", absolutePath, ":", err)
+		return err
+	}
 	return nil
 }
 
 // goMod runs the go mod init <module name> command
 func goMod(moduleName string) error {
-	return exec.Command("go", "mod", "init", moduleName).Run()
+	return runCommand("go", "mod", "init", moduleName)
 }
 
 // goGet runs the go get <module>
 func goGet(moduleName string) error {
-	return exec.Command("go", "get", moduleName).Run()
+	return runCommand("go", "get", moduleName)
+}
+
+// goVersion returns the version of the installed go toolchain without the go prefix, e.g. 1.17.2
+func goVersion() (string, error) {
+	out, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "go"), nil
+}
+
+// goModTidy runs the go mod tidy command
+func goModTidy() error {
+	return runCommand("go", "mod", "tidy")
+}
+
+// goGenerate runs the go generate <package> command
+func goGenerate(pkg string) error {
+	return runCommand("go", "generate", pkg)
+}
+
+// runCommand runs the command, its output is returned along with the error so that the failures are not opaque
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if out = bytes.TrimSpace(out); len(out) > 0 {
+			return fmt.Errorf("%s %s: %w\n%s", name, strings.Join(args, " "), err, out)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
 }
 
 // createDir creates a directory if it doesn't exist at the provided absolute path
@@ -285,3 +822,19 @@ func createDir(absolutePath string) error {
 	}
 	return nil
 }
+
+// goVersionAtLeast reports whether the go version is 1.<minor> or later, e.g. 1.22 added the pattern based http.ServeMux
+func goVersionAtLeast(goVersion string, minimumMinor int) bool {
+	parts := strings.Split(goVersion, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	major, _ := strconv.Atoi(parts[0])
+	// the minor version of pre-releases is e.g. 22rc1
+	minorDigits := parts[1]
+	if i := strings.IndexFunc(minorDigits, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		minorDigits = minorDigits[:i]
+	}
+	minor, _ := strconv.Atoi(minorDigits)
+	return major > 1 || (major == 1 && minor >= minimumMinor)
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RateLimit is the token bucket limit of a route, Rate requests per second on average and Burst requests at once
+type RateLimit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst,omitempty"`
+}
+
+// ParseRateLimit parses the rate limit definition action=rate[/burst], e.g. create=5/10, the actions are the ones
+// of ParseScope, the burst is the rate rounded up if it is omitted
+func ParseRateLimit(definition string, limits map[string]RateLimit) error {
+	parts := strings.SplitN(definition, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid rate limit %q, it must be in the form action=rate[/burst]", definition)
+	}
+
+	rate, burst := parts[1], ""
+	if i := strings.Index(rate, "/"); i >= 0 {
+		rate, burst = rate[:i], rate[i+1:]
+	}
+	limit := RateLimit{}
+	var err error
+	if limit.Rate, err = strconv.ParseFloat(rate, 64); err != nil || limit.Rate <= 0 || math.IsInf(limit.Rate, 0) {
+		return fmt.Errorf("invalid rate of rate limit %q, it must be a positive number of requests per second", definition)
+	}
+	if burst != "" {
+		if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst <= 0 {
+			return fmt.Errorf("invalid burst of rate limit %q, it must be a positive integer", definition)
+		}
+	}
+
+	targets, ok := actionGroups[parts[0]]
+	if !ok {
+		targets = []string{parts[0]}
+	}
+	for _, action := range targets {
+		if !isAction(action) {
+			return fmt.Errorf("invalid action %q of rate limit %q, must be one of list, get, create, update, delete, read or write", parts[0], definition)
+		}
+		limits[action] = limit
+	}
+	return nil
+}
+
+// RouteName returns the name of the route of the action, e.g. items.create, it identifies the route limits
+// in RATE_LIMIT_ROUTES
+func (r *Resource) RouteName(action string) string {
+	return r.PathName() + "." + action
+}
+
+// rateLimited wraps the handler expression of the action with the rate limiter of the route
+func (p *Project) rateLimited(r *Resource, action, handler string) string {
+	limit := r.RateLimits[action]
+	literal := "ratelimit.Limit{}"
+	if limit.Rate > 0 {
+		literal = fmt.Sprintf("ratelimit.Limit{Rate: %s, Burst: %d}", formatNumber(limit.Rate), limit.Burst)
+	}
+	return fmt.Sprintf("limiter.Route(%q, %s)(%s)", r.RouteName(action), literal, handler)
+}
+
+// limited adds the 429 response of the rate limiter to the operation of a resource route, every resource route
+// may be limited since RATE_LIMIT applies to the routes without a limit
+func (p *Project) limited(operation object) object {
+	if !p.RateLimit {
+		return operation
+	}
+	responses := operation["responses"].(object)
+	responses["429"] = problemResponse("The rate limit of the client is exceeded, retry after the Retry-After seconds")
+	return operation
+}
@@ -0,0 +1,656 @@
+package pkg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/piyushjajoo/crud/tpl"
+)
+
+// Resource is a model of the micro-service for which the model, repository and CRUD handlers are generated
+type Resource struct {
+	Name   string   `yaml:"name"`
+	Fields []*Field `yaml:"fields"`
+	GRPC   bool     `yaml:"grpc,omitempty"`
+	// Scopes are the scopes the bearer token must be granted for each action, e.g. list: [items:read]
+	Scopes map[string][]string `yaml:"scopes,omitempty"`
+	// Roles are the roles of which the bearer token must be granted any for each action, e.g. delete: [admin]
+	Roles map[string][]string `yaml:"roles,omitempty"`
+	// Owner are the actions only the owner, the subject of the token which created the resource, may perform
+	Owner []string `yaml:"owner,omitempty"`
+	// RateLimits are the limits of the requests of each client for each action, e.g. create: {rate: 5, burst: 10}
+	RateLimits map[string]RateLimit `yaml:"rateLimits,omitempty"`
+}
+
+// Field is a field of the resource model
+type Field struct {
+	Name       string      `yaml:"name"`
+	Type       string      `yaml:"type"`
+	Validation *Validation `yaml:"validation,omitempty"`
+	// References is the name of the resource whose id the field holds, e.g. customer for the customerId of an order,
+	// the repository checks that the referenced resource exists when the resource is written
+	References string `yaml:"references,omitempty"`
+	// Nullable fields are pointers in the model, null is distinct from the zero value of the type
+	Nullable bool `yaml:"nullable,omitempty"`
+}
+
+// field types supported in the resource models
+const (
+	StringFieldType  = "string"
+	BoolFieldType    = "bool"
+	IntFieldType     = "int"
+	Int64FieldType   = "int64"
+	Float64FieldType = "float64"
+	TimeFieldType    = "time"
+)
+
+// fieldTypes maps the supported field types to their go and proto types and the kind used to filter and sort them
+var fieldTypes = map[string]struct{ goType, protoType, listKind string }{
+	StringFieldType:  {"string", "string", "String"},
+	BoolFieldType:    {"bool", "bool", "Bool"},
+	IntFieldType:     {"int", "int64", "Int"},
+	Int64FieldType:   {"int64", "int64", "Int"},
+	Float64FieldType: {"float64", "double", "Float"},
+	TimeFieldType:    {"time.Time", "google.protobuf.Timestamp", "Time"},
+}
+
+// reservedFieldNames are the fields every generated model has
+var reservedFieldNames = map[string]bool{"id": true, "createdAt": true, "updatedAt": true}
+
+// initialisms are written in upper case in go names, e.g. sku-id is SkuID
+var initialisms = map[string]bool{"id": true, "url": true, "uri": true, "api": true, "http": true, "json": true, "uuid": true, "ip": true, "sql": true}
+
+// NewResource returns the resource with the name normalized to kebab case, e.g. OrderLine is order-line
+func NewResource(name string, fields []*Field, grpc bool) (*Resource, error) {
+	w := words(name)
+	if len(w) == 0 || !unicode.IsLetter(rune(w[0][0])) {
+		return nil, fmt.Errorf("invalid resource name %q, it must start with a letter", name)
+	}
+	r := &Resource{Name: strings.Join(w, "-"), Fields: fields, GRPC: grpc}
+
+	seen := map[string]bool{}
+	for _, f := range fields {
+		if seen[f.Name] {
+			return nil, fmt.Errorf("duplicate field %q in resource %s", f.Name, r.Name)
+		}
+		seen[f.Name] = true
+	}
+	return r, nil
+}
+
+// ParseField parses the field definition name:type[:rules], e.g. price:float64:required,min=0, the name is normalized
+// to lower camel case, see ParseValidation for the rules
+func ParseField(definition string) (*Field, error) {
+	parts := strings.SplitN(definition, ":", 3)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid field %q, it must be in the form name:type or name:type:rules", definition)
+	}
+
+	f, err := NewField(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 3 && parts[2] != "" {
+		v, err := ParseValidation(parts[2], f)
+		if err != nil {
+			return nil, err
+		}
+		// the ref rule sets the referenced resource, it is not a rule of the validate tag
+		if !v.empty() {
+			f.Validation = v
+		}
+	}
+	return f, nil
+}
+
+// NewField returns the field with the name normalized to lower camel case, e.g. sku-id is skuId, after checking
+// its type
+func NewField(name, typ string) (*Field, error) {
+	w := words(name)
+	if len(w) == 0 || !unicode.IsLetter(rune(w[0][0])) {
+		return nil, fmt.Errorf("invalid field name %q, it must start with a letter", name)
+	}
+	f := &Field{Name: lowerCamel(w), Type: typ}
+	if reservedFieldNames[f.Name] {
+		return nil, fmt.Errorf("invalid field name %q, id, createdAt and updatedAt are added to every resource", name)
+	}
+	if _, ok := fieldTypes[f.Type]; !ok {
+		return nil, fmt.Errorf("invalid type %q of field %s, must be one of string, bool, int, int64, float64 or time", f.Type, f.Name)
+	}
+	return f, nil
+}
+
+// SetValidation sets the validation rules of the field after checking they can be applied to it
+func (f *Field) SetValidation(v *Validation) error {
+	if v != nil {
+		if err := v.check(f); err != nil {
+			return err
+		}
+	}
+	f.Validation = v
+	return nil
+}
+
+// SetReferences sets the resource referenced by the field, the name is normalized to kebab case, the ids of the
+// resources are strings so only string fields can reference resources
+func (f *Field) SetReferences(resource string) error {
+	w := words(resource)
+	if len(w) == 0 || !unicode.IsLetter(rune(w[0][0])) {
+		return fmt.Errorf("invalid resource %q referenced by field %s, it must start with a letter", resource, f.Name)
+	}
+	if f.Type != StringFieldType {
+		return fmt.Errorf("field %s can't reference resource %s, the ids are strings, it is %s", f.Name, resource, f.Type)
+	}
+	f.References = strings.Join(w, "-")
+	return nil
+}
+
+// Reference returns the resource referenced by the field or nil, only its name is set
+func (f *Field) Reference() *Resource {
+	if f.References == "" {
+		return nil
+	}
+	return &Resource{Name: f.References}
+}
+
+// References returns the distinct resources referenced by the fields of the resource, only their names are set
+func (r *Resource) References() []*Resource {
+	var refs []*Resource
+	seen := map[string]bool{}
+	for _, f := range r.Fields {
+		if f.References != "" && !seen[f.References] {
+			seen[f.References] = true
+			refs = append(refs, f.Reference())
+		}
+	}
+	return refs
+}
+
+// ReferencingResources returns the resources of the project with fields referencing the resource, the resource
+// itself if it references itself
+func (p *Project) ReferencingResources(r *Resource) []*Resource {
+	var referencing []*Resource
+	for _, other := range p.Resources {
+		for _, f := range other.Fields {
+			if f.References == r.Name {
+				referencing = append(referencing, other)
+				break
+			}
+		}
+	}
+	return referencing
+}
+
+// HasReferences reports whether any resource of the project references a resource
+func (p *Project) HasReferences() bool {
+	for _, r := range p.Resources {
+		if len(r.References()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GoName returns the go type name of the resource, e.g. OrderLine
+func (r *Resource) GoName() string {
+	return upperCamel(words(r.Name), true)
+}
+
+// GoPluralName returns the plural go name of the resource, e.g. OrderLines
+func (r *Resource) GoPluralName() string {
+	return upperCamel(pluralWords(r.Name), true)
+}
+
+// VarName returns the lower camel case name of the resource, e.g. orderLine
+func (r *Resource) VarName() string {
+	return lowerCamel(words(r.Name))
+}
+
+// AddCommand returns the crud add resource command adding the resource with its fields, the definitions are quoted
+// for the shell if they have characters it interprets, e.g. the pipes of enums
+func (r *Resource) AddCommand() string {
+	args := []string{"crud", "add", "resource", r.Name}
+	for _, f := range r.Fields {
+		definition := f.Definition()
+		if strings.IndexFunc(definition, func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune(":,=._-", c)
+		}) >= 0 {
+			definition = "'" + strings.ReplaceAll(definition, "'", `'\''`) + "'"
+		}
+		args = append(args, "--field", definition)
+	}
+	if r.GRPC {
+		args = append(args, "--grpc")
+	}
+	return strings.Join(args, " ")
+}
+
+// PathName returns the url path segment of the resource, e.g. order-lines
+func (r *Resource) PathName() string {
+	return strings.Join(pluralWords(r.Name), "-")
+}
+
+// FileName returns the name of the generated files of the resource without extension, e.g. order_line
+func (r *Resource) FileName() string {
+	return strings.Join(words(r.Name), "_")
+}
+
+// HumanName returns the name of the resource used in doc comments, e.g. order line
+func (r *Resource) HumanName() string {
+	return strings.Join(words(r.Name), " ")
+}
+
+// HumanPluralName returns the plural name of the resource used in doc comments, e.g. order lines
+func (r *Resource) HumanPluralName() string {
+	return strings.Join(pluralWords(r.Name), " ")
+}
+
+// ProtoName returns the snake case name of the resource used for proto fields, e.g. order_line
+func (r *Resource) ProtoName() string {
+	return strings.Join(words(r.Name), "_")
+}
+
+// PbGoName returns the go name protoc-gen-go generates for the proto field of the resource, e.g. OrderLine
+func (r *Resource) PbGoName() string {
+	return upperCamel(words(r.Name), false)
+}
+
+// PbGoPluralName returns the go name protoc-gen-go generates for the repeated proto field of the resource
+func (r *Resource) PbGoPluralName() string {
+	return upperCamel(pluralWords(r.Name), false)
+}
+
+// ProtoPluralName returns the snake case plural name of the resource used for repeated proto fields
+func (r *Resource) ProtoPluralName() string {
+	return strings.Join(pluralWords(r.Name), "_")
+}
+
+// GoName returns the go name of the field, e.g. UnitPrice
+func (f *Field) GoName() string {
+	return upperCamel(words(f.Name), true)
+}
+
+// JSONName returns the json name of the field, e.g. unitPrice
+func (f *Field) JSONName() string {
+	return f.Name
+}
+
+// GoType returns the go type of the field, a pointer if the field is nullable
+func (f *Field) GoType() string {
+	if f.Nullable {
+		return "*" + fieldTypes[f.Type].goType
+	}
+	return fieldTypes[f.Type].goType
+}
+
+// ProtoName returns the proto name of the field, e.g. unit_price
+func (f *Field) ProtoName() string {
+	return strings.Join(words(f.Name), "_")
+}
+
+// ProtoType returns the proto type of the field
+func (f *Field) ProtoType() string {
+	return fieldTypes[f.Type].protoType
+}
+
+// PbGoName returns the go name protoc-gen-go generates for the proto field, e.g. UnitPrice
+func (f *Field) PbGoName() string {
+	return upperCamel(words(f.Name), false)
+}
+
+// ListKind returns the kind of the field in the generated list filters and sorting, e.g. Float for float64 fields,
+// it is the suffix of the repository FieldType and of the match and compare functions
+func (f *Field) ListKind() string {
+	return fieldTypes[f.Type].listKind
+}
+
+// Value returns the go expression of the value of the field of the model variable, nullable fields are
+// dereferenced so they must be checked against nil first
+func (f *Field) Value(model string) string {
+	if f.Nullable {
+		return "*" + model + "." + f.GoName()
+	}
+	return model + "." + f.GoName()
+}
+
+// ListValue returns the go expression of the field of the model variable passed to the match and compare functions,
+// null is matched and sorted as the zero value of nullable fields
+func (f *Field) ListValue(model string) string {
+	expr := model + "." + f.GoName()
+	if f.Nullable {
+		expr = "valueOf(" + expr + ")"
+	}
+	if f.Type == IntFieldType {
+		return "int64(" + expr + ")"
+	}
+	return expr
+}
+
+// ToProto returns the go expression converting the field of the model variable to its proto type
+func (f *Field) ToProto(model string) string {
+	expr := model + "." + f.GoName()
+	switch f.Type {
+	case IntFieldType:
+		return "int64(" + expr + ")"
+	case TimeFieldType:
+		return "toTimestamp(" + expr + ")"
+	}
+	return expr
+}
+
+// FromProto returns the go expression converting the field of the proto message variable to its go type
+func (f *Field) FromProto(message string) string {
+	expr := message + ".Get" + f.PbGoName() + "()"
+	switch f.Type {
+	case IntFieldType:
+		return "int(" + expr + ")"
+	case TimeFieldType:
+		return "fromTimestamp(" + expr + ")"
+	}
+	return expr
+}
+
+// ProtoPackage returns the proto package of the project, e.g. inventory.v1
+func (p *Project) ProtoPackage() string {
+	return strings.Join(words(p.ProjectDirName), "_") + ".v1"
+}
+
+// HasNullableFields reports whether any resource has nullable fields, the repository package then has the valueOf
+// function dereferencing them
+func (p *Project) HasNullableFields() bool {
+	for _, r := range p.Resources {
+		for _, f := range r.Fields {
+			if f.Nullable {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GRPCResources returns the resources which are served over grpc
+func (p *Project) GRPCResources() []*Resource {
+	var resources []*Resource
+	for _, r := range p.Resources {
+		if r.GRPC {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// AddResource generates the model, repository, handlers and, if requested, the grpc service of the resource
+// and records the resource in the manifest
+func (p *Project) AddResource(r *Resource) error {
+	return p.AddResources([]*Resource{r})
+}
+
+// AddResources generates the resources like AddResource, the go code of the proto files is generated and the
+// modules are tidied once for all of them, the resources may reference each other
+func (p *Project) AddResources(resources []*Resource) error {
+	if err := p.checkResources(resources); err != nil {
+		return err
+	}
+	grpc := false
+	for _, r := range resources {
+		grpc = grpc || r.GRPC
+	}
+	p.Resources = append(p.Resources, resources...)
+
+	for _, r := range resources {
+		if err := p.renderResource(r); err != nil {
+			return err
+		}
+	}
+	if err := p.createResourceRegistry(); err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Println("error getting current working directory:", err)
+		return err
+	}
+	if err := os.Chdir(p.AbsolutePath); err != nil {
+		log.Println("error changing directory to path", p.AbsolutePath, ":", err)
+		return err
+	}
+
+	// generate the go code of the proto files
+	if grpc {
+		if err := goGenerate("./pkg/pb"); err != nil {
+			log.Println("error generating go code from the proto files, make sure protoc, protoc-gen-go and protoc-gen-go-grpc are installed:", err)
+			return err
+		}
+	}
+
+	// add the modules imported by the generated code to go.mod and go.sum
+	if err := goModTidy(); err != nil {
+		log.Println("error running go mod tidy at path", p.AbsolutePath, ":", err)
+		return err
+	}
+
+	if err := os.Chdir(cwd); err != nil {
+		log.Println("error changing current working directory to", cwd, "after adding resource:", err)
+		return err
+	}
+
+	// only the files written for the resources are recorded, the base of the others is kept for crud upgrade
+	err = p.writeBaseFiles(func(rendered *Project) error {
+		for _, r := range resources {
+			if err := rendered.renderResource(r); err != nil {
+				return err
+			}
+		}
+		return rendered.createResourceRegistry()
+	})
+	if err != nil {
+		return err
+	}
+	return p.WriteManifest()
+}
+
+// checkResources checks that the resources can be added to the project, their names must be new and their options
+// supported by the project
+func (p *Project) checkResources(resources []*Resource) error {
+	if p.Worker() {
+		return fmt.Errorf("resources can't be added, the project is a %s", WorkerType)
+	}
+	added := map[string]bool{}
+	for _, existing := range p.Resources {
+		added[existing.Name] = true
+	}
+	for _, r := range resources {
+		if added[r.Name] {
+			return fmt.Errorf("resource %s already exists", r.Name)
+		}
+		added[r.Name] = true
+	}
+	for _, r := range resources {
+		if err := p.checkResource(r, added); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkResource checks that the options of the resource are supported by the project and that the resources it
+// references exist, resources holds the names of the resources of the project along with the ones being added
+func (p *Project) checkResource(r *Resource, resources map[string]bool) error {
+	if r.GRPC && !p.GRPC {
+		return fmt.Errorf("resource %s can't be served over grpc, the project was created without --grpc", r.Name)
+	}
+	if (len(r.Scopes) > 0 || len(r.Roles) > 0 || r.Owned()) && !p.Authenticated() {
+		return fmt.Errorf("resource %s can't require scopes, roles or ownership, the project was created without --auth", r.Name)
+	}
+	if len(r.RateLimits) > 0 && !p.RateLimit {
+		return fmt.Errorf("resource %s can't have rate limits, the project was created without --rate-limit", r.Name)
+	}
+	for _, f := range r.Fields {
+		if r.Owned() && f.JSONName() == "ownerId" {
+			return fmt.Errorf("resource %s can't have field ownerId, it is added to the resources with --owner", r.Name)
+		}
+		if f.Nullable && r.GRPC {
+			return fmt.Errorf("resource %s can't be served over grpc, its field %s is nullable", r.Name, f.Name)
+		}
+		if f.References != "" && !resources[f.References] {
+			return fmt.Errorf("field %s of resource %s references resource %s which doesn't exist, add it first", f.Name, r.Name, f.References)
+		}
+	}
+	return nil
+}
+
+// renderResource renders the model, repository, handlers, client and, if requested, the proto file and grpc service
+// of the resource
+func (p *Project) renderResource(r *Resource) error {
+	data := struct {
+		Project  *Project
+		Resource *Resource
+	}{p, r}
+
+	pkgDir := p.AbsolutePath + "/pkg"
+	if err := p.createFileFromTemplate(fmt.Sprintf("%s/models/%s.go", pkgDir, r.FileName()), "model", tpl.ModelTemplate(), data); err != nil {
+		return err
+	}
+	if err := p.createFileFromTemplate(fmt.Sprintf("%s/repository/%s.go", pkgDir, r.FileName()), "repository", tpl.ResourceRepositoryTemplate(), data); err != nil {
+		return err
+	}
+	if err := p.createFileFromTemplate(fmt.Sprintf("%s/handlers/%s.go", pkgDir, r.FileName()), "handler", tpl.ResourceHandlerTemplate(), data); err != nil {
+		return err
+	}
+	if err := p.createFileFromTemplate(fmt.Sprintf("%s/client/%s.go", p.AbsolutePath, r.FileName()), "client", tpl.ResourceClientTemplate(), data); err != nil {
+		return err
+	}
+
+	if r.GRPC {
+		protoDir := p.AbsolutePath + "/proto"
+		if err := createDir(protoDir); err != nil {
+			log.Println("error creating proto directory at", p.AbsolutePath, ":", err)
+			return err
+		}
+		if err := p.createFileFromTemplate(fmt.Sprintf("%s/%s.proto", protoDir, r.FileName()), "proto", tpl.ProtoTemplate(), data); err != nil {
+			return err
+		}
+		if err := p.createFileFromTemplate(fmt.Sprintf("%s/grpcserver/%s.go", pkgDir, r.FileName()), "grpcserver", tpl.ResourceGRPCServerTemplate(), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createResourceRegistry creates the files which wire the repositories, routes and grpc services of all the resources
+// and the api documentation, they are created again every time a resource is added
+func (p *Project) createResourceRegistry() error {
+	pkgDir := p.AbsolutePath + "/pkg"
+	if err := p.createFileFromTemplate(pkgDir+"/repository/repositories.go", "repositories", tpl.RepositoriesTemplate(), p); err != nil {
+		return err
+	}
+	if err := p.createFileFromTemplate(pkgDir+"/routes/resources.go", "resources", tpl.ResourceRoutesTemplate(), p); err != nil {
+		return err
+	}
+	// if api flag is set, create the api documentation of the routes
+	if p.CreateApiDoc {
+		if err := p.writeOpenAPI(); err != nil {
+			return err
+		}
+	}
+	// if the typescript client was generated with crud gen client, generate it again with the resources
+	if p.TSClient != "" {
+		if err := p.writeTSClient(); err != nil {
+			return err
+		}
+	}
+
+	if !p.GRPC {
+		return nil
+	}
+	if err := p.createFileFromTemplate(pkgDir+"/grpcserver/server.go", "grpcserver", tpl.GRPCServerTemplate(), p); err != nil {
+		return err
+	}
+	if len(p.GRPCResources()) > 0 {
+		pbDir := pkgDir + "/pb"
+		if err := createDir(pbDir); err != nil {
+			log.Println("error creating pb directory at", pkgDir, ":", err)
+			return err
+		}
+		if err := p.createFileFromTemplate(pbDir+"/generate.go", "pb", tpl.PbGenerateTemplate(), p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// words splits the name into lower case words on -, _, spaces and camel case boundaries, e.g. OrderLine is order, line
+func words(name string) []string {
+	var result []string
+	var current []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	for i, c := range runes {
+		switch {
+		case c == '-' || c == '_' || c == ' ' || c == '.':
+			flush()
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			// drop any other character
+			flush()
+		case unicode.IsUpper(c) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))):
+			// start of a new word, e.g. the L in orderLine or the S in HTTPServer
+			flush()
+			current = append(current, c)
+		default:
+			current = append(current, c)
+		}
+	}
+	flush()
+	return result
+}
+
+// pluralWords returns the words of the name with the last word in plural form, e.g. order, lines
+func pluralWords(name string) []string {
+	w := words(name)
+	if len(w) == 0 {
+		return w
+	}
+	last := w[len(w)-1]
+	switch {
+	case strings.HasSuffix(last, "s") || strings.HasSuffix(last, "x") || strings.HasSuffix(last, "z") ||
+		strings.HasSuffix(last, "ch") || strings.HasSuffix(last, "sh"):
+		last += "es"
+	case strings.HasSuffix(last, "y") && len(last) > 1 && !strings.ContainsAny(last[len(last)-2:len(last)-1], "aeiou"):
+		last = last[:len(last)-1] + "ies"
+	default:
+		last += "s"
+	}
+	w[len(w)-1] = last
+	return w
+}
+
+// upperCamel joins the words in upper camel case, initialisms are upper cased if requested
+func upperCamel(w []string, upperInitialisms bool) string {
+	var b strings.Builder
+	for _, word := range w {
+		if upperInitialisms && initialisms[word] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// lowerCamel joins the words in lower camel case, e.g. unitPrice
+func lowerCamel(w []string) string {
+	if len(w) == 0 {
+		return ""
+	}
+	return w[0] + upperCamel(w[1:], false)
+}
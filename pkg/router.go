@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// routers the http server can be generated with
+const (
+	MuxRouter    = "mux"
+	ChiRouter    = "chi"
+	StdlibRouter = "stdlib"
+	GinRouter    = "gin"
+	EchoRouter   = "echo"
+)
+
+// router holds the router specific parts of the generated code
+type router struct {
+	module     string
+	importPath string
+	typ        string
+}
+
+// routers maps the supported routers to their module, import path and router type
+var routers = map[string]router{
+	MuxRouter:    {GorillaMuxModuleName, GorillaMuxModuleName, "*mux.Router"},
+	ChiRouter:    {ChiModuleName, ChiModuleName, "chi.Router"},
+	StdlibRouter: {"", "", "*http.ServeMux"},
+	GinRouter:    {GinModuleName, GinModuleName, "*gin.Engine"},
+	EchoRouter:   {EchoModuleName, EchoModuleName, "*echo.Echo"},
+}
+
+// ValidateRouter validates the router name
+func ValidateRouter(name string) error {
+	if _, ok := routers[name]; !ok {
+		return fmt.Errorf("invalid router %q, must be one of %s, %s, %s, %s or %s", name, MuxRouter, ChiRouter, StdlibRouter, GinRouter, EchoRouter)
+	}
+	return nil
+}
+
+// RouterImport returns the import path of the router package, it is empty for the standard library router
+func (p *Project) RouterImport() string {
+	return routers[p.Router].importPath
+}
+
+// RouterType returns the go type of the router passed to routes.Routes
+func (p *Project) RouterType() string {
+	return routers[p.Router].typ
+}
+
+// Route returns the statement registering the http.Handler expression on router r for the method and path,
+// path parameters are written as {name} for every router
+func (p *Project) Route(method, path, handler string) string {
+	switch p.Router {
+	case ChiRouter:
+		return fmt.Sprintf("r.Method(http.Method%s, %q, %s)", methodName(method), path, handler)
+	case StdlibRouter:
+		return fmt.Sprintf("r.Handle(%q, %s)", method+" "+path, handler)
+	case GinRouter, EchoRouter:
+		return fmt.Sprintf("r.%s(%q, handle(%s))", method, colonParams(path), handler)
+	}
+	return fmt.Sprintf("r.Handle(%q, %s).Methods(http.Method%s)", path, handler, methodName(method))
+}
+
+// methodName returns the name of the http.Method constant of the method, e.g. Get for GET
+func methodName(method string) string {
+	return method[:1] + strings.ToLower(method[1:])
+}
+
+// colonParams rewrites the {name} path parameters to :name, e.g. /items/:id
+func colonParams(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			segments[i] = ":" + s[1:len(s)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
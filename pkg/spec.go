@@ -0,0 +1,436 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SpecSchemaURL is the url of the json schema of the service spec, editors complete and validate the spec with it
+const SpecSchemaURL = "https://raw.githubusercontent.com/piyushjajoo/crud/main/schema/service.schema.json"
+
+// Spec describes the whole micro-service, its options and resources, crud generate creates the project from it
+type Spec struct {
+	Module    string          `yaml:"module"`
+	Type      string          `yaml:"type,omitempty"`
+	Router    string          `yaml:"router,omitempty"`
+	Features  SpecFeatures    `yaml:"features,omitempty"`
+	Resources []*SpecResource `yaml:"resources,omitempty"`
+}
+
+// SpecFeatures are the options of crud init
+type SpecFeatures struct {
+	Swagger   bool     `yaml:"swagger,omitempty"`
+	Chart     bool     `yaml:"chart,omitempty"`
+	Makefile  bool     `yaml:"makefile,omitempty"`
+	Compose   bool     `yaml:"compose,omitempty"`
+	GRPC      bool     `yaml:"grpc,omitempty"`
+	CI        string   `yaml:"ci,omitempty"`
+	Auth      []string `yaml:"auth,omitempty"`
+	RateLimit bool     `yaml:"rateLimit,omitempty"`
+	Cache     string   `yaml:"cache,omitempty"`
+	Events    string   `yaml:"events,omitempty"`
+	Outbox    bool     `yaml:"outbox,omitempty"`
+	Metrics   bool     `yaml:"metrics,omitempty"`
+	BaseImage string   `yaml:"baseImage,omitempty"`
+}
+
+// SpecResource is a resource of the spec, the options are the ones of crud add resource
+type SpecResource struct {
+	Name   string       `yaml:"name"`
+	Fields []*SpecField `yaml:"fields,omitempty"`
+	GRPC   bool         `yaml:"grpc,omitempty"`
+	// Scopes, Roles and RateLimits are keyed by the action, one of list, get, create, update, delete, read or write
+	Scopes     map[string][]string `yaml:"scopes,omitempty"`
+	Roles      map[string][]string `yaml:"roles,omitempty"`
+	Owner      []string            `yaml:"owner,omitempty"`
+	RateLimits map[string]string   `yaml:"rateLimits,omitempty"`
+}
+
+// SpecField is a field of a spec resource
+type SpecField struct {
+	Name       string      `yaml:"name"`
+	Type       string      `yaml:"type"`
+	Validation *Validation `yaml:"validation,omitempty"`
+	References string      `yaml:"references,omitempty"`
+	Nullable   bool        `yaml:"nullable,omitempty"`
+}
+
+// LoadSpec loads the spec file, unknown keys are rejected so that typos are not silently ignored
+func LoadSpec(path string) (*Spec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spec{}
+	if err = yaml.UnmarshalStrict(content, s); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Project returns the project of the spec created at the absolute path along with its resources, the options
+// and resources are validated like the flags of crud init and crud add resource
+func (s *Spec) Project(absolutePath string) (*Project, []*Resource, error) {
+	f := s.Features
+	if f.BaseImage == "" {
+		f.BaseImage = DistrolessBaseImage
+	}
+
+	if err := ValidateModuleName(s.Module); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateType(s.Type); err != nil {
+		return nil, nil, err
+	}
+	router := s.Router
+	if s.Type == WorkerType {
+		if router != "" {
+			return nil, nil, fmt.Errorf("worker has no resource endpoints, router can't be provided")
+		}
+	} else if router == "" {
+		router = MuxRouter
+	}
+	if router != "" {
+		if err := ValidateRouter(router); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := ValidateBaseImage(f.BaseImage); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateCI(f.CI); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateAuth(f.Auth); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateCache(f.Cache); err != nil {
+		return nil, nil, err
+	}
+	if f.Outbox && f.Events == "" {
+		return nil, nil, fmt.Errorf("outbox stores the events before they are published, it needs the events broker")
+	}
+	if err := ValidateEvents(f.Events, f.Outbox); err != nil {
+		return nil, nil, err
+	}
+
+	p := &Project{
+		AbsolutePath:    absolutePath,
+		ModuleName:      s.Module,
+		ProjectDirName:  s.DirName(),
+		Type:            s.Type,
+		CreateApiDoc:    f.Swagger,
+		CreateHelmChart: f.Chart,
+		CreateMakefile:  f.Makefile,
+		CreateCompose:   f.Compose,
+		BaseImage:       f.BaseImage,
+		CI:              f.CI,
+		GRPC:            f.GRPC,
+		Router:          router,
+		Auth:            f.Auth,
+		RateLimit:       f.RateLimit,
+		Cache:           f.Cache,
+		Events:          f.Events,
+		Outbox:          f.Outbox,
+		Metrics:         f.Metrics,
+	}
+	if err := p.ValidateWorker(); err != nil {
+		return nil, nil, err
+	}
+
+	var resources []*Resource
+	for _, sr := range s.Resources {
+		r, err := sr.resource()
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, r)
+	}
+	return p, resources, nil
+}
+
+// DirName returns the directory name of the project, the last element of the module name
+func (s *Spec) DirName() string {
+	return s.Module[strings.LastIndex(s.Module, "/")+1:]
+}
+
+// resource returns the resource of the spec resource, the actions are parsed like the flags of crud add resource
+func (sr *SpecResource) resource() (*Resource, error) {
+	var fields []*Field
+	for _, sf := range sr.Fields {
+		f, err := NewField(sf.Name, sf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %w", sr.Name, err)
+		}
+		f.Nullable = sf.Nullable
+		if sf.References != "" {
+			if err = f.SetReferences(sf.References); err != nil {
+				return nil, fmt.Errorf("resource %s: %w", sr.Name, err)
+			}
+		}
+		if err = f.SetValidation(sf.Validation); err != nil {
+			return nil, fmt.Errorf("resource %s: %w", sr.Name, err)
+		}
+		fields = append(fields, f)
+	}
+	r, err := NewResource(sr.Name, fields, sr.GRPC)
+	if err != nil {
+		return nil, err
+	}
+
+	// the groups read and write are expanded before the actions, so the actions sort after them
+	for _, action := range sortedKeys(sr.Scopes) {
+		for _, scope := range sr.Scopes[action] {
+			if r.Scopes == nil {
+				r.Scopes = map[string][]string{}
+			}
+			if err = ParseScope(action+"="+scope, r.Scopes); err != nil {
+				return nil, fmt.Errorf("resource %s: %w", r.Name, err)
+			}
+		}
+	}
+	for _, action := range sortedKeys(sr.Roles) {
+		for _, role := range sr.Roles[action] {
+			if r.Roles == nil {
+				r.Roles = map[string][]string{}
+			}
+			if err = ParseRole(action+"="+role, r.Roles); err != nil {
+				return nil, fmt.Errorf("resource %s: %w", r.Name, err)
+			}
+		}
+	}
+	if r.Owner, err = ParseOwnership(sr.Owner); err != nil {
+		return nil, fmt.Errorf("resource %s: %w", r.Name, err)
+	}
+	for _, action := range sortedKeys(sr.RateLimits) {
+		if r.RateLimits == nil {
+			r.RateLimits = map[string]RateLimit{}
+		}
+		if err = ParseRateLimit(action+"="+sr.RateLimits[action], r.RateLimits); err != nil {
+			return nil, fmt.Errorf("resource %s: %w", r.Name, err)
+		}
+	}
+	return r, nil
+}
+
+// sortedKeys returns the keys of the map of the spec, the groups read and write first, then the actions in order
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		_, gi := actionGroups[keys[i]]
+		_, gj := actionGroups[keys[j]]
+		if gi != gj {
+			return gi
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// Generate creates the project of the spec at the absolute path along with its resources, if the project already
+// exists the resources of the spec which are not in the manifest are added, the options and the existing resources
+// must be the ones of the manifest as crud can't change them, it returns the added resources
+func (s *Spec) Generate(absolutePath string) (created bool, added []*Resource, err error) {
+	p, resources, err := s.Project(absolutePath)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if _, err = os.Stat(absolutePath + "/" + ManifestFileName); os.IsNotExist(err) {
+		// the resources are checked before the project is created, so that a bad resource doesn't leave a project
+		// behind whose crud.yaml the spec can't be generated into again
+		if err = p.checkResources(resources); err != nil {
+			return false, nil, err
+		}
+		if err = s.create(p, resources); err != nil {
+			return false, nil, err
+		}
+		return true, resources, nil
+	}
+
+	existing, err := LoadProject(absolutePath)
+	if err != nil {
+		return false, nil, err
+	}
+	if existing.InitCommand() != p.InitCommand() {
+		return false, nil, fmt.Errorf("the options of the spec differ from %s, crud can't change them\n  %s: %s\n  spec: %s", ManifestFileName, ManifestFileName, existing.InitCommand(), p.InitCommand())
+	}
+	specResources := map[string]*Resource{}
+	for _, r := range resources {
+		specResources[r.Name] = r
+	}
+	for _, r := range existing.Resources {
+		specResource, ok := specResources[r.Name]
+		if !ok {
+			return false, nil, fmt.Errorf("resource %s of %s is missing in the spec, crud can't remove resources", r.Name, ManifestFileName)
+		}
+		if !sameResource(r, specResource) {
+			return false, nil, fmt.Errorf("resource %s of the spec differs from %s, crud can't change existing resources", r.Name, ManifestFileName)
+		}
+		delete(specResources, r.Name)
+	}
+	for _, r := range resources {
+		if _, ok := specResources[r.Name]; ok {
+			added = append(added, r)
+		}
+	}
+	if len(added) > 0 {
+		if err = existing.AddResources(added); err != nil {
+			return false, nil, err
+		}
+	}
+	return false, added, nil
+}
+
+// create creates the project and adds the resources, if it fails the project directory is removed, unless it existed
+// before
+func (s *Spec) create(p *Project, resources []*Resource) (err error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(p.AbsolutePath); os.IsNotExist(err) {
+		defer func() {
+			if err != nil {
+				// Create and AddResources may fail inside the project directory
+				if chdirErr := os.Chdir(cwd); chdirErr != nil {
+					log.Println("error changing current working directory to", cwd, ":", chdirErr)
+					return
+				}
+				if removeErr := os.RemoveAll(p.AbsolutePath); removeErr != nil {
+					log.Println("error removing project directory at path", p.AbsolutePath, ":", removeErr)
+				}
+			}
+		}()
+	}
+
+	if err = p.Create(); err != nil {
+		return err
+	}
+	if len(resources) > 0 {
+		return p.AddResources(resources)
+	}
+	return nil
+}
+
+// sameResource reports whether the resources are the same, they are compared as they are recorded in the manifest
+func sameResource(a, b *Resource) bool {
+	outA, errA := yaml.Marshal(a)
+	outB, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && string(outA) == string(outB)
+}
+
+// SpecSchema returns the json schema of the spec, it is published at SpecSchemaURL
+func SpecSchema() ([]byte, error) {
+	actionKeys := append([]string{"read", "write"}, actions...)
+	actionMap := func(description string, value object) object {
+		properties := object{}
+		for _, action := range actionKeys {
+			properties[action] = value
+		}
+		return object{"type": "object", "description": description, "properties": properties, "additionalProperties": false}
+	}
+	stringList := object{"type": "array", "items": object{"type": "string"}}
+	enum := func(description string, values ...string) object {
+		schema := object{"type": "string", "enum": values}
+		if description != "" {
+			schema["description"] = description
+		}
+		return schema
+	}
+	boolean := func(description string) object {
+		return object{"type": "boolean", "description": description}
+	}
+	fieldTypeNames := []string{StringFieldType, BoolFieldType, IntFieldType, Int64FieldType, Float64FieldType, TimeFieldType}
+
+	validation := object{
+		"type":        "object",
+		"description": "Rules the field is validated with in the create and update handlers",
+		"properties": object{
+			"required": boolean("The field must not have its zero value"),
+			"min":      object{"type": "number", "description": "Minimum length of strings or value of numbers"},
+			"max":      object{"type": "number", "description": "Maximum length of strings or value of numbers"},
+			"email":    boolean("The string must be an email address"),
+			"regex":    object{"type": "string", "description": "Pattern the string must match"},
+			"enum":     object{"type": "array", "description": "Values the string or integer must be one of", "items": object{"type": "string"}},
+		},
+		"additionalProperties": false,
+	}
+	field := object{
+		"type":     "object",
+		"required": []string{"name", "type"},
+		"properties": object{
+			"name":       object{"type": "string", "description": "Name of the field, it is normalized to lower camel case"},
+			"type":       enum("Type of the field", fieldTypeNames...),
+			"validation": validation,
+			"references": object{"type": "string", "description": "Resource whose id the string field holds, it must exist when the resource is written"},
+			"nullable":   boolean("The field is a pointer in the model, null is distinct from the zero value"),
+		},
+		"additionalProperties": false,
+	}
+	resource := object{
+		"type":     "object",
+		"required": []string{"name"},
+		"properties": object{
+			"name":       object{"type": "string", "description": "Name of the resource, it is normalized to kebab case"},
+			"fields":     object{"type": "array", "items": field},
+			"grpc":       boolean("Serve the resource over grpc as well, needs features.grpc"),
+			"scopes":     actionMap("Scopes the credentials must be granted for each action, needs features.auth", stringList),
+			"roles":      actionMap("Roles of which the credentials must be granted any for each action, needs features.auth", stringList),
+			"owner":      object{"type": "array", "description": "Actions only the owner of the resource may perform, needs features.auth", "items": enum("", ListAction, GetAction, UpdateAction, DeleteAction)},
+			"rateLimits": actionMap("Requests per second each client may send to each action as rate[/burst], needs features.rateLimit", object{"type": "string", "pattern": `^[0-9.]+(/[0-9]+)?$`}),
+		},
+		"additionalProperties": false,
+	}
+	features := object{
+		"type": "object",
+		"properties": object{
+			"swagger":   boolean("Generate the OpenAPI documentation of the endpoints"),
+			"chart":     boolean("Generate the helm chart"),
+			"makefile":  boolean("Generate the Makefile with the developer targets"),
+			"compose":   boolean("Generate the docker-compose.yaml with the backing services"),
+			"grpc":      boolean("Generate the grpc server alongside the http server"),
+			"ci":        enum("CI pipeline", GitHubCI, GitLabCI),
+			"auth":      object{"type": "array", "description": "Authentication of the resource endpoints", "items": enum("", JWTAuth, APIKeyAuth), "uniqueItems": true},
+			"rateLimit": boolean("Limit the requests of each client to the resource endpoints"),
+			"cache":     enum("Cache of the reads of the repositories", RedisCache),
+			"events":    enum("Broker the domain events are published to, or consumed from by a worker", LogEvents, KafkaEvents, NATSEvents),
+			"outbox":    boolean("Store the events in the outbox table of postgres from which the relay publishes them, needs events"),
+			"metrics":   boolean("Serve the prometheus metrics of the http requests on METRICS_LISTEN_ADDR"),
+			"baseImage": enum("Base image of the final stage of the Dockerfile", DistrolessBaseImage, ScratchBaseImage),
+		},
+		"additionalProperties": false,
+	}
+
+	schema := object{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"$id":         SpecSchemaURL,
+		"title":       "crud service spec",
+		"description": "Micro-service generated with crud generate -f",
+		"type":        "object",
+		"required":    []string{"module"},
+		"properties": object{
+			"module":    object{"type": "string", "description": "Go module name, its last element is the directory of the project", "pattern": `^[A-Za-z0-9._~-]+(/[A-Za-z0-9._~-]+)*$`},
+			"type":      enum("Type of the project", ServiceType, WorkerType),
+			"router":    enum("Router of the http server", MuxRouter, ChiRouter, StdlibRouter, GinRouter, EchoRouter),
+			"features":  features,
+			"resources": object{"type": "array", "items": resource},
+		},
+		"additionalProperties": false,
+	}
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
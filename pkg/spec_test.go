@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readmeSpec returns the example spec of the README, the yaml block starting with the yaml-language-server comment
+func readmeSpec(t *testing.T) string {
+	t.Helper()
+	readme, err := os.ReadFile("../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(string(readme), "# yaml-language-server:")
+	if start < 0 {
+		t.Fatal("README.md has no example spec")
+	}
+	end := strings.Index(string(readme[start:]), "```")
+	if end < 0 {
+		t.Fatal("example spec of README.md is not closed")
+	}
+	return string(readme[start : start+end])
+}
+
+func TestGenerateReadmeExample(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generating the project runs go mod tidy")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "service.yaml")
+	if err := os.WriteFile(specPath, []byte(readmeSpec(t)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := LoadSpec(specPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projectPath := filepath.Join(dir, spec.DirName())
+	created, added, err := spec.Generate(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || len(added) != len(spec.Resources) {
+		t.Fatalf("Generate() = %v, %d resources, want the project created with %d resources", created, len(added), len(spec.Resources))
+	}
+
+	// the project builds and generating it again is a no-op
+	build := exec.Command("go", "build", "./...")
+	build.Dir = projectPath
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	created, added, err = spec.Generate(projectPath)
+	if err != nil || created || len(added) != 0 {
+		t.Fatalf("Generate() again = %v, %v, %v, want nothing generated", created, added, err)
+	}
+}
+
+func TestSpecSchema(t *testing.T) {
+	schema, err := SpecSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := os.ReadFile("../schema/service.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(schema) != string(published) {
+		t.Error("schema/service.schema.json differs from SpecSchema(), run crud generate --schema > schema/service.schema.json")
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{
+			name: "spec",
+			spec: "module: github.com/acme/shop\nfeatures:\n  events: log\n  outbox: true\nresources:\n  - name: item\n    fields:\n      - {name: name, type: string, validation: {required: true, max: 10}}\n",
+		},
+		{
+			name:    "unknown key",
+			spec:    "module: github.com/acme/shop\nfeatures:\n  db: postgres\n",
+			wantErr: "field db not found",
+		},
+		{
+			name:    "invalid yaml",
+			spec:    "module: [",
+			wantErr: "error parsing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "service.yaml")
+			if err := os.WriteFile(path, []byte(tt.spec), 0644); err != nil {
+				t.Fatal(err)
+			}
+			s, err := LoadSpec(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadSpec() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Module != "github.com/acme/shop" || !s.Features.Outbox || len(s.Resources) != 1 || s.Resources[0].Fields[0].Validation.Max == nil {
+				t.Errorf("LoadSpec() = %+v, want the spec parsed", s)
+			}
+		})
+	}
+}
+
+func TestSpecProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{name: "minimal", spec: "module: github.com/acme/shop"},
+		{name: "invalid module", spec: "module: github.com/acme/sh op", wantErr: "invalid module name"},
+		{name: "invalid type", spec: "module: shop\ntype: cron", wantErr: "invalid type"},
+		{name: "invalid router", spec: "module: shop\nrouter: httprouter", wantErr: "invalid router"},
+		{name: "worker with router", spec: "module: shop\ntype: worker\nrouter: chi\nfeatures: {events: kafka}", wantErr: "router can't be provided"},
+		{name: "worker of log events", spec: "module: shop\ntype: worker\nfeatures: {events: log}", wantErr: "can't consume the log events"},
+		{name: "worker with metrics", spec: "module: shop\ntype: worker\nfeatures: {events: kafka, metrics: true}", wantErr: "--metrics can't be provided"},
+		{name: "outbox without events", spec: "module: shop\nfeatures: {outbox: true}", wantErr: "outbox stores the events"},
+		{name: "invalid events", spec: "module: shop\nfeatures: {events: rabbitmq}", wantErr: "invalid events"},
+		{name: "invalid auth", spec: "module: shop\nfeatures: {auth: [basic]}", wantErr: "invalid auth"},
+		{name: "invalid field type", spec: "module: shop\nresources: [{name: item, fields: [{name: n, type: uuid}]}]", wantErr: "resource item"},
+		{name: "invalid validation", spec: "module: shop\nresources: [{name: item, fields: [{name: n, type: bool, validation: {email: true}}]}]", wantErr: "resource item"},
+		{name: "invalid action", spec: "module: shop\nfeatures: {auth: [jwt]}\nresources: [{name: item, scopes: {search: [items:read]}}]", wantErr: "resource item"},
+		{name: "invalid rate limit", spec: "module: shop\nfeatures: {rateLimit: true}\nresources: [{name: item, rateLimits: {read: fast}}]", wantErr: "resource item"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "service.yaml")
+			if err := os.WriteFile(path, []byte(tt.spec), 0644); err != nil {
+				t.Fatal(err)
+			}
+			s, err := LoadSpec(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, _, err := s.Project("/tmp/" + s.DirName())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Project() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Router != MuxRouter || p.BaseImage != DistrolessBaseImage {
+				t.Errorf("Project() = router %q, base image %q, want the defaults", p.Router, p.BaseImage)
+			}
+		})
+	}
+}
+
+func TestGenerateChecksResourcesFirst(t *testing.T) {
+	dir := t.TempDir()
+	s := &Spec{
+		Module:    "github.com/acme/shop",
+		Resources: []*SpecResource{{Name: "item", RateLimits: map[string]string{"create": "5/10"}}},
+	}
+	projectPath := filepath.Join(dir, s.DirName())
+	if _, _, err := s.Generate(projectPath); err == nil || !strings.Contains(err.Error(), "without --rate-limit") {
+		t.Fatalf("Generate() error = %v, want the rate limits rejected", err)
+	}
+	if _, err := os.Stat(projectPath); !os.IsNotExist(err) {
+		t.Errorf("Generate() left the project directory behind, stat error = %v", err)
+	}
+}
@@ -0,0 +1,819 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// dialects of the DDL crud import sql parses, empty means the dialect is detected
+const (
+	PostgresDialect = "postgres"
+	MySQLDialect    = "mysql"
+	SQLiteDialect   = "sqlite"
+)
+
+// ValidateDialect validates the sql dialect, empty means the dialect is detected from the DDL
+func ValidateDialect(dialect string) error {
+	switch dialect {
+	case "", PostgresDialect, MySQLDialect, SQLiteDialect:
+		return nil
+	}
+	return fmt.Errorf("invalid dialect %q, must be one of %s, %s or %s", dialect, PostgresDialect, MySQLDialect, SQLiteDialect)
+}
+
+// sqlToken is a token of the DDL, quoted identifiers and string literals are unquoted
+type sqlToken struct {
+	text string
+	// quoted identifiers are never keywords
+	quoted bool
+	str    bool
+	// quote is the quote of the identifier, the backticks of mysql tell its dialect
+	quote rune
+}
+
+// is reports whether the token is the unquoted keyword, keywords are case insensitive
+func (t sqlToken) is(keyword string) bool {
+	return !t.quoted && !t.str && strings.EqualFold(t.text, keyword)
+}
+
+// sqlTable is a table of the DDL along with its primary and foreign keys
+type sqlTable struct {
+	name        string
+	columns     []*sqlColumn
+	primaryKey  []string
+	foreignKeys []sqlForeignKey
+}
+
+// sqlColumn is a column of a table, the type is lower case with its words joined by spaces, e.g. double precision
+type sqlColumn struct {
+	name     string
+	typ      string
+	args     []string
+	array    bool
+	unsigned bool
+	notNull  bool
+	// hasDefault is set for the columns the database fills in, the defaults, identities, generated and serial columns
+	hasDefault bool
+}
+
+// sqlForeignKey references the columns of a table, the primary key if no columns are provided
+type sqlForeignKey struct {
+	columns    []string
+	table      string
+	refColumns []string
+}
+
+// column returns the column of the table with the name, identifiers are case insensitive
+func (t *sqlTable) column(name string) *sqlColumn {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// ImportSQL returns the resources of the tables created in the DDL, along with the warnings about the columns and
+// keys which can't be imported as they are, the tables are filtered by name if any is provided. The single column
+// primary key of a table is the id of its resource and the single column foreign keys reference the resources of
+// the imported tables or of the project
+func (p *Project) ImportSQL(ddl, dialect string, tables []string) ([]*Resource, []string, error) {
+	tokens, err := tokenizeSQL(ddl)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dialect == "" {
+		dialect = detectDialect(tokens)
+	}
+	parsed, err := parseSQL(tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selected := map[string]bool{}
+	for _, name := range tables {
+		selected[strings.ToLower(name)] = true
+	}
+	var imported []*sqlTable
+	for _, t := range parsed {
+		if len(tables) == 0 || selected[strings.ToLower(t.name)] {
+			imported = append(imported, t)
+		}
+	}
+	if len(imported) < len(selected) {
+		for _, name := range tables {
+			if !containsTable(imported, name) {
+				return nil, nil, fmt.Errorf("table %s is not created in the DDL", name)
+			}
+		}
+	}
+	if len(imported) == 0 {
+		return nil, nil, fmt.Errorf("no CREATE TABLE statement found in the DDL")
+	}
+
+	// the resources which can be referenced, the ones of the project and of the imported tables
+	resources := map[string]bool{}
+	for _, r := range p.Resources {
+		resources[r.Name] = true
+	}
+	tablesByName := map[string]*sqlTable{}
+	for _, t := range parsed {
+		tablesByName[strings.ToLower(t.name)] = t
+	}
+	for _, t := range imported {
+		resources[tableResourceName(t.name)] = true
+	}
+
+	var result []*Resource
+	var warnings []string
+	for _, t := range imported {
+		r, tableWarnings, err := t.resource(dialect, tablesByName, resources)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, r)
+		warnings = append(warnings, tableWarnings...)
+	}
+	return result, warnings, nil
+}
+
+// containsTable reports whether the table with the name is in the tables, identifiers are case insensitive
+func containsTable(tables []*sqlTable, name string) bool {
+	for _, t := range tables {
+		if strings.EqualFold(t.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resource returns the resource of the table, the columns are the fields except the primary key, which is the id,
+// and the timestamps every resource has
+func (t *sqlTable) resource(dialect string, tables map[string]*sqlTable, resources map[string]bool) (*Resource, []string, error) {
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("table %s: ", t.name)+fmt.Sprintf(format, args...))
+	}
+
+	idColumn := ""
+	switch len(t.primaryKey) {
+	case 0:
+		warn("it has no primary key, the ids of the resources are generated")
+	case 1:
+		idColumn = t.primaryKey[0]
+		if !strings.EqualFold(idColumn, "id") {
+			warn("primary key %s is the id of the resource", idColumn)
+		}
+	default:
+		warn("composite primary key (%s) is not imported, the ids of the resources are generated", strings.Join(t.primaryKey, ", "))
+	}
+
+	references := map[string]string{}
+	for _, fk := range t.foreignKeys {
+		if len(fk.columns) != 1 {
+			warn("composite foreign key (%s) is not imported", strings.Join(fk.columns, ", "))
+			continue
+		}
+		resource := tableResourceName(fk.table)
+		if !resources[resource] {
+			warn("foreign key %s references table %s which is not imported, the reference is not checked", fk.columns[0], fk.table)
+			continue
+		}
+		if refTable := tables[strings.ToLower(fk.table)]; refTable != nil && len(fk.refColumns) == 1 &&
+			!(len(refTable.primaryKey) == 1 && strings.EqualFold(refTable.primaryKey[0], fk.refColumns[0])) {
+			warn("foreign key %s references column %s of table %s which is not its primary key, the reference is not checked", fk.columns[0], fk.refColumns[0], fk.table)
+			continue
+		}
+		references[strings.ToLower(fk.columns[0])] = resource
+	}
+
+	var fields []*Field
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, idColumn) {
+			if _, ok := references[strings.ToLower(c.name)]; ok {
+				warn("primary key %s is a foreign key as well, the reference is not checked", c.name)
+			}
+			continue
+		}
+		name := lowerCamel(words(c.name))
+		if reservedFieldNames[name] {
+			if name == "id" {
+				warn("column %s is not imported, the id of the resource is the primary key %s", c.name, idColumn)
+			}
+			continue
+		}
+
+		reference := references[strings.ToLower(c.name)]
+		typ, ok := c.fieldType(dialect)
+		if !ok {
+			warn("column %s of type %s is not imported, it has no field type", c.name, c.describeType())
+			continue
+		}
+		if reference != "" {
+			// the ids of the resources are strings
+			typ = StringFieldType
+		}
+		f, err := NewField(c.name, typ)
+		if err != nil {
+			return nil, nil, fmt.Errorf("table %s: %w", t.name, err)
+		}
+		if reference != "" {
+			if err = f.SetReferences(reference); err != nil {
+				return nil, nil, fmt.Errorf("table %s: %w", t.name, err)
+			}
+		}
+		f.Nullable = !c.notNull
+		if err = f.SetValidation(c.validation(f, reference != "", warn)); err != nil {
+			return nil, nil, fmt.Errorf("table %s: %w", t.name, err)
+		}
+		if (c.typ == "json" || c.typ == "jsonb") && reference == "" {
+			warn("column %s of type %s is a string holding the json document", c.name, c.typ)
+		}
+		fields = append(fields, f)
+	}
+
+	r, err := NewResource(tableResourceName(t.name), fields, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("table %s: %w", t.name, err)
+	}
+	return r, warnings, nil
+}
+
+// validation returns the validation rules of the column, the length of strings is their maximum length, the values
+// of enums are an enum rule and the not null references are required. The other not null columns without a default
+// are required as well, except the numbers and bools, required would reject their zero values 0 and false which the
+// column holds
+func (c *sqlColumn) validation(f *Field, reference bool, warn func(string, ...interface{})) *Validation {
+	v := &Validation{}
+	if reference {
+		v.Required = c.notNull
+		return validationOrNil(v)
+	}
+	v.Required = c.notNull && !c.hasDefault && (f.Type == StringFieldType || f.Type == TimeFieldType)
+	switch c.typ {
+	case "char", "character", "varchar", "character varying", "nchar", "nvarchar", "varchar2", "nvarchar2", "national character", "national character varying":
+		if len(c.args) == 1 {
+			if n, err := strconv.Atoi(c.args[0]); err == nil && n > 0 {
+				max := float64(n)
+				v.Max = &max
+			}
+		}
+	case "enum":
+		for _, value := range c.args {
+			if value == "" || strings.ContainsAny(value, " ,|") {
+				warn("values of enum column %s are not validated, %q can't be an enum value", c.name, value)
+				v.Enum = nil
+				break
+			}
+			v.Enum = append(v.Enum, value)
+		}
+	}
+	if v.check(f) != nil {
+		return nil
+	}
+	return validationOrNil(v)
+}
+
+// validationOrNil returns nil for the validation without rules
+func validationOrNil(v *Validation) *Validation {
+	if v.empty() {
+		return nil
+	}
+	return v
+}
+
+// fieldType returns the field type of the column, the integers of sqlite are 64 bit, the unsigned integers of mysql
+// are int64 and its tinyint(1) is a bool, the columns of arrays and binary data have no field type
+func (c *sqlColumn) fieldType(dialect string) (string, bool) {
+	if c.array {
+		return "", false
+	}
+	switch c.typ {
+	case "bool", "boolean":
+		return BoolFieldType, true
+	case "tinyint", "bit":
+		if dialect == MySQLDialect && len(c.args) == 1 && c.args[0] == "1" {
+			return BoolFieldType, true
+		}
+	}
+	switch c.typ {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "int2", "int4", "serial", "serial2", "serial4",
+		"smallserial", "year":
+		if dialect == SQLiteDialect || c.unsigned && c.typ != "tinyint" && c.typ != "smallint" {
+			return Int64FieldType, true
+		}
+		return IntFieldType, true
+	case "bigint", "int8", "bigserial", "serial8":
+		return Int64FieldType, true
+	case "real", "float", "float4", "float8", "double", "double precision", "decimal", "numeric", "dec", "fixed",
+		"money", "smallmoney":
+		return Float64FieldType, true
+	case "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone", "datetime",
+		"datetime2", "smalldatetime", "datetimeoffset", "date":
+		return TimeFieldType, true
+	case "char", "character", "varchar", "character varying", "nchar", "nvarchar", "varchar2", "nvarchar2",
+		"national character", "national character varying", "text", "tinytext", "mediumtext", "longtext", "clob",
+		"citext", "uuid", "enum", "set", "inet", "cidr", "macaddr", "xml", "json", "jsonb", "interval", "time",
+		"timetz", "time with time zone", "time without time zone", "name", "string":
+		return StringFieldType, true
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bit varying", "varbit":
+		return "", false
+	}
+
+	// the other types of sqlite have the affinity of the name of the type
+	if dialect == SQLiteDialect {
+		switch {
+		case strings.Contains(c.typ, "int"):
+			return Int64FieldType, true
+		case strings.Contains(c.typ, "char") || strings.Contains(c.typ, "clob") || strings.Contains(c.typ, "text"):
+			return StringFieldType, true
+		case strings.Contains(c.typ, "blob") || c.typ == "":
+			return "", false
+		}
+		return Float64FieldType, true
+	}
+	return "", false
+}
+
+// describeType returns the type of the column as it is written in the DDL
+func (c *sqlColumn) describeType() string {
+	typ := c.typ
+	if typ == "" {
+		typ = "untyped"
+	}
+	if len(c.args) > 0 {
+		typ += "(" + strings.Join(c.args, ",") + ")"
+	}
+	if c.array {
+		typ += "[]"
+	}
+	return typ
+}
+
+// tableResourceName returns the name of the resource of the table, the singular of its name, e.g. order_items is
+// order-item
+func tableResourceName(table string) string {
+	w := words(table)
+	if len(w) > 0 {
+		w[len(w)-1] = singular(w[len(w)-1])
+	}
+	return strings.Join(w, "-")
+}
+
+// irregularPlurals are the singulars of the common irregular plurals of table names
+var irregularPlurals = map[string]string{"people": "person", "children": "child", "men": "man", "women": "woman"}
+
+// singular returns the singular of the plural word, words which are not plural are returned as they are
+func singular(word string) string {
+	if s, ok := irregularPlurals[word]; ok {
+		return s
+	}
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "xes") || strings.HasSuffix(word, "zes") ||
+		strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "uses") && !strings.HasSuffix(word, "ouses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss") || strings.HasSuffix(word, "us") || strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// detectDialect detects the dialect from the identifiers quoted with backticks and the keywords specific to mysql
+// and sqlite, it is postgres otherwise
+func detectDialect(tokens []sqlToken) string {
+	for i, t := range tokens {
+		switch {
+		case t.quote == '`' || t.is("AUTO_INCREMENT") || t.is("ENGINE") || t.is("UNSIGNED"):
+			return MySQLDialect
+		case t.is("AUTOINCREMENT") || t.is("WITHOUT") && i+1 < len(tokens) && tokens[i+1].is("ROWID"):
+			return SQLiteDialect
+		}
+	}
+	return PostgresDialect
+}
+
+// tokenizeSQL splits the DDL into tokens, the comments are skipped, identifiers quoted with double quotes, backticks
+// or brackets and string literals are unquoted and the dollar quoted strings of postgres are single tokens
+func tokenizeSQL(ddl string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(ddl)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-', r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2 + len([]rune(string(runes[i+2:])[:end])) + 2
+		case r == '\'' || r == '"' || r == '`' || r == '[' && i+1 < len(runes) && runes[i+1] != ']' && !unicode.IsDigit(runes[i+1]):
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if r == '\'' && runes[j] == '\\' && j+1 < len(runes) {
+					j++
+					b.WriteRune(runes[j])
+					continue
+				}
+				if runes[j] == closing {
+					// a doubled quote is the quote itself
+					if j+1 < len(runes) && runes[j+1] == closing && closing != ']' {
+						b.WriteRune(closing)
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote %c", r)
+			}
+			tokens = append(tokens, sqlToken{text: b.String(), quoted: r != '\'', str: r == '\'', quote: r})
+			i = j + 1
+		case r == '$' && i+1 < len(runes) && (runes[i+1] == '$' || unicode.IsLetter(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			if j >= len(runes) || runes[j] != '$' {
+				tokens = append(tokens, sqlToken{text: "$"})
+				i++
+				continue
+			}
+			tag := string(runes[i : j+1])
+			end := strings.Index(string(runes[j+1:]), tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated dollar quoted string %s", tag)
+			}
+			body := string(runes[j+1:])[:end]
+			tokens = append(tokens, sqlToken{text: body, str: true})
+			i = j + 1 + len([]rune(body)) + len([]rune(tag))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '$') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: string(runes[i:j])})
+			i = j
+		default:
+			tokens = append(tokens, sqlToken{text: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// parseSQL parses the CREATE TABLE statements and the primary and foreign keys added with ALTER TABLE, the other
+// statements are skipped
+func parseSQL(tokens []sqlToken) ([]*sqlTable, error) {
+	var tables []*sqlTable
+	byName := map[string]*sqlTable{}
+	for _, stmt := range splitTokens(tokens, ";") {
+		if len(stmt) == 0 {
+			continue
+		}
+		switch {
+		case stmt[0].is("CREATE"):
+			t, err := parseCreateTable(stmt)
+			if err != nil {
+				return nil, err
+			}
+			if t == nil {
+				continue
+			}
+			if byName[strings.ToLower(t.name)] != nil {
+				return nil, fmt.Errorf("table %s is created twice", t.name)
+			}
+			byName[strings.ToLower(t.name)] = t
+			tables = append(tables, t)
+		case stmt[0].is("ALTER"):
+			if err := parseAlterTable(stmt, byName); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tables, nil
+}
+
+// parseCreateTable parses the CREATE TABLE statement, it returns nil for the other CREATE statements and the tables
+// created from queries
+func parseCreateTable(stmt []sqlToken) (*sqlTable, error) {
+	i := 1
+	for i < len(stmt) && (stmt[i].is("OR") || stmt[i].is("REPLACE") || stmt[i].is("GLOBAL") || stmt[i].is("LOCAL") ||
+		stmt[i].is("TEMP") || stmt[i].is("TEMPORARY") || stmt[i].is("UNLOGGED")) {
+		i++
+	}
+	if i >= len(stmt) || !stmt[i].is("TABLE") {
+		return nil, nil
+	}
+	i++
+	if i+2 < len(stmt) && stmt[i].is("IF") && stmt[i+1].is("NOT") && stmt[i+2].is("EXISTS") {
+		i += 3
+	}
+	name, i := parseQualifiedName(stmt, i)
+	if name == "" {
+		return nil, fmt.Errorf("CREATE TABLE without table name")
+	}
+	if i >= len(stmt) || stmt[i].text != "(" || stmt[i].quoted || stmt[i].str {
+		return nil, nil
+	}
+	body, _ := parenthesized(stmt, i)
+
+	t := &sqlTable{name: name}
+	for _, element := range splitTokens(body, ",") {
+		if len(element) == 0 {
+			continue
+		}
+		if err := t.parseElement(element); err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+	}
+	if len(t.columns) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", name)
+	}
+	return t, nil
+}
+
+// parseAlterTable parses the primary and foreign keys added to the tables with ALTER TABLE, e.g. by pg_dump
+func parseAlterTable(stmt []sqlToken, tables map[string]*sqlTable) error {
+	i := 1
+	if i >= len(stmt) || !stmt[i].is("TABLE") {
+		return nil
+	}
+	i++
+	for i < len(stmt) && (stmt[i].is("ONLY") || stmt[i].is("IF") || stmt[i].is("EXISTS")) {
+		i++
+	}
+	name, i := parseQualifiedName(stmt, i)
+	t := tables[strings.ToLower(name)]
+	if t == nil {
+		return nil
+	}
+	for _, action := range splitTokens(stmt[i:], ",") {
+		if len(action) > 1 && action[0].is("ADD") {
+			if err := t.parseConstraint(action[1:]); err != nil {
+				return fmt.Errorf("table %s: %w", t.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseElement parses a column or a constraint of the table
+func (t *sqlTable) parseElement(element []sqlToken) error {
+	first := element[0]
+	for _, keyword := range []string{"CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK", "KEY", "INDEX", "FULLTEXT", "SPATIAL", "EXCLUDE", "LIKE"} {
+		if first.is(keyword) {
+			return t.parseConstraint(element)
+		}
+	}
+
+	c := &sqlColumn{name: first.text}
+	i := c.parseType(element, 1)
+	c.hasDefault = strings.Contains(c.typ, "serial")
+	for i < len(element) {
+		tok := element[i]
+		switch {
+		case tok.is("NOT") && i+1 < len(element) && element[i+1].is("NULL"):
+			c.notNull = true
+			i += 2
+		case tok.is("PRIMARY") && i+1 < len(element) && element[i+1].is("KEY"):
+			c.notNull = true
+			t.primaryKey = []string{c.name}
+			i += 2
+		case tok.is("DEFAULT") || tok.is("AUTO_INCREMENT") || tok.is("AUTOINCREMENT") || tok.is("GENERATED") ||
+			tok.is("IDENTITY") || tok.is("AS"):
+			c.hasDefault = true
+			i++
+		case tok.is("REFERENCES"):
+			table, next := parseQualifiedName(element, i+1)
+			fk := sqlForeignKey{columns: []string{c.name}, table: table}
+			if next < len(element) && element[next].text == "(" && !element[next].quoted {
+				var cols []sqlToken
+				cols, next = parenthesized(element, next)
+				fk.refColumns = tokenTexts(cols)
+			}
+			t.foreignKeys = append(t.foreignKeys, fk)
+			i = next
+		case tok.text == "(" && !tok.quoted && !tok.str:
+			_, i = parenthesized(element, i)
+		default:
+			i++
+		}
+	}
+	t.columns = append(t.columns, c)
+	return nil
+}
+
+// parseType parses the type of the column starting at the index and returns the index after it, the type is made
+// of the words before the constraints of the column, its arguments, e.g. varchar(255), and its modifiers
+func (c *sqlColumn) parseType(element []sqlToken, i int) int {
+	var typeWords []string
+	for i < len(element) && !element[i].quoted && !element[i].str && isWord(element[i].text) && !isColumnConstraint(element, i) {
+		typeWords = append(typeWords, strings.ToLower(element[i].text))
+		i++
+	}
+	if i < len(element) && element[i].text == "(" && !element[i].quoted && !element[i].str {
+		var args []sqlToken
+		args, i = parenthesized(element, i)
+		c.args = tokenTexts(args)
+	}
+	// the modifiers after the arguments, e.g. timestamp(3) with time zone or int(10) unsigned
+	for i < len(element) {
+		switch {
+		case element[i].is("WITH") || element[i].is("WITHOUT"):
+			if i+2 < len(element) && element[i+1].is("TIME") && element[i+2].is("ZONE") {
+				typeWords = append(typeWords, strings.ToLower(element[i].text), "time", "zone")
+				i += 3
+				continue
+			}
+		case element[i].is("UNSIGNED"):
+			c.unsigned = true
+			i++
+			continue
+		case element[i].is("SIGNED") || element[i].is("ZEROFILL") || element[i].is("VARYING"):
+			i++
+			continue
+		case element[i].text == "[" && !element[i].quoted:
+			for i < len(element) && element[i].text != "]" {
+				i++
+			}
+			c.array = true
+			i++
+			continue
+		case element[i].is("ARRAY"):
+			c.array = true
+			i++
+			continue
+		}
+		break
+	}
+	for len(typeWords) > 0 && (typeWords[len(typeWords)-1] == "unsigned" || typeWords[len(typeWords)-1] == "signed") {
+		c.unsigned = typeWords[len(typeWords)-1] == "unsigned"
+		typeWords = typeWords[:len(typeWords)-1]
+	}
+	c.typ = strings.Join(typeWords, " ")
+	return i
+}
+
+// isColumnConstraint reports whether the word at the index starts a constraint or option of the column
+func isColumnConstraint(element []sqlToken, i int) bool {
+	for _, keyword := range []string{"NOT", "NULL", "PRIMARY", "REFERENCES", "DEFAULT", "UNIQUE", "CHECK", "CONSTRAINT",
+		"AUTO_INCREMENT", "AUTOINCREMENT", "GENERATED", "COLLATE", "COMMENT", "ON", "AS", "IDENTITY", "KEY", "CHARSET",
+		"STORED", "VIRTUAL", "INVISIBLE", "VISIBLE"} {
+		if element[i].is(keyword) {
+			return true
+		}
+	}
+	// the character set of mysql columns, character varying is a type
+	return element[i].is("CHARACTER") && i+1 < len(element) && element[i+1].is("SET")
+}
+
+// parseConstraint parses the primary key and foreign key constraints of the table, the other constraints are skipped
+func (t *sqlTable) parseConstraint(tokens []sqlToken) error {
+	i := 0
+	if i < len(tokens) && tokens[i].is("CONSTRAINT") {
+		i += 2
+	}
+	if i+1 >= len(tokens) {
+		return nil
+	}
+	switch {
+	case tokens[i].is("PRIMARY") && tokens[i+1].is("KEY"):
+		cols, _ := parenthesized(tokens, indexOf(tokens, i+2, "("))
+		t.primaryKey = tokenTexts(cols)
+		for _, name := range t.primaryKey {
+			if c := t.column(name); c != nil {
+				c.notNull = true
+			}
+		}
+	case tokens[i].is("FOREIGN") && tokens[i+1].is("KEY"):
+		start := indexOf(tokens, i+2, "(")
+		cols, next := parenthesized(tokens, start)
+		if next >= len(tokens) || !tokens[next].is("REFERENCES") {
+			return fmt.Errorf("foreign key (%s) without REFERENCES", strings.Join(tokenTexts(cols), ", "))
+		}
+		table, next := parseQualifiedName(tokens, next+1)
+		fk := sqlForeignKey{columns: tokenTexts(cols), table: table}
+		if next < len(tokens) && tokens[next].text == "(" && !tokens[next].quoted {
+			refCols, _ := parenthesized(tokens, next)
+			fk.refColumns = tokenTexts(refCols)
+		}
+		t.foreignKeys = append(t.foreignKeys, fk)
+	}
+	return nil
+}
+
+// parseQualifiedName parses the name, e.g. public.items, and returns its last element and the index after it
+func parseQualifiedName(tokens []sqlToken, i int) (string, int) {
+	name := ""
+	for i < len(tokens) {
+		if tokens[i].str || !tokens[i].quoted && !isWord(tokens[i].text) {
+			break
+		}
+		name = tokens[i].text
+		i++
+		if i+1 < len(tokens) && tokens[i].text == "." && !tokens[i].quoted {
+			i++
+			continue
+		}
+		break
+	}
+	return name, i
+}
+
+// parenthesized returns the tokens between the parenthesis at the index and its closing parenthesis along with
+// the index after it, the nested parentheses are included
+func parenthesized(tokens []sqlToken, i int) ([]sqlToken, int) {
+	if i < 0 || i >= len(tokens) {
+		return nil, len(tokens)
+	}
+	depth := 0
+	for j := i; j < len(tokens); j++ {
+		if tokens[j].quoted || tokens[j].str {
+			continue
+		}
+		switch tokens[j].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return tokens[i+1 : j], j + 1
+			}
+		}
+	}
+	return tokens[i+1:], len(tokens)
+}
+
+// splitTokens splits the tokens on the separator outside of parentheses
+func splitTokens(tokens []sqlToken, separator string) [][]sqlToken {
+	var parts [][]sqlToken
+	depth, start := 0, 0
+	for i, t := range tokens {
+		if t.quoted || t.str {
+			continue
+		}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case separator:
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[start:])
+}
+
+// indexOf returns the index of the first unquoted token with the text from the index or -1
+func indexOf(tokens []sqlToken, from int, text string) int {
+	for i := from; i < len(tokens); i++ {
+		if tokens[i].text == text && !tokens[i].quoted && !tokens[i].str {
+			return i
+		}
+	}
+	return -1
+}
+
+// tokenTexts returns the texts of the tokens without the commas, e.g. the columns of a key or the values of an enum
+func tokenTexts(tokens []sqlToken) []string {
+	var texts []string
+	for _, t := range tokens {
+		if t.text != "," || t.quoted || t.str {
+			texts = append(texts, t.text)
+		}
+	}
+	return texts
+}
+
+// isWord reports whether the text is a word, an identifier or a keyword
+func isWord(text string) bool {
+	if text == "" {
+		return false
+	}
+	r := []rune(text)[0]
+	return unicode.IsLetter(r) || r == '_'
+}
@@ -0,0 +1,284 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ddl      string
+		dialect  string
+		tables   []string
+		want     []string
+		warnings []string
+	}{
+		{
+			name: "column constraints",
+			ddl: `CREATE TABLE customers (
+				id uuid PRIMARY KEY,
+				email varchar(255) NOT NULL,
+				name text,
+				created_at timestamptz NOT NULL DEFAULT now()
+			);`,
+			want: []string{"crud add resource customer --field email:string:required,max=255 --field name:string:nullable"},
+		},
+		{
+			name: "not null columns with and without a default",
+			ddl: `CREATE TABLE tickets (
+				id bigserial PRIMARY KEY,
+				title text NOT NULL,
+				status text NOT NULL DEFAULT 'open',
+				due_at timestamp NOT NULL,
+				number integer GENERATED ALWAYS AS IDENTITY,
+				code text NOT NULL GENERATED ALWAYS AS (upper(title)) STORED,
+				quantity integer NOT NULL,
+				paid boolean NOT NULL
+			);`,
+			want: []string{"crud add resource ticket --field title:string:required --field status:string --field dueAt:time:required " +
+				"--field number:int:nullable --field code:string --field quantity:int --field paid:bool"},
+		},
+		{
+			name: "table constraints",
+			ddl: `CREATE TABLE IF NOT EXISTS public.orders (
+				order_id bigint NOT NULL,
+				customer_id uuid NOT NULL,
+				total numeric(10, 2) NOT NULL,
+				shipped_at timestamptz,
+				CONSTRAINT orders_pkey PRIMARY KEY (order_id),
+				CONSTRAINT orders_customer_fkey FOREIGN KEY (customer_id) REFERENCES public.customers (id) ON DELETE CASCADE,
+				UNIQUE (customer_id, total),
+				CHECK (total >= 0)
+			);
+			CREATE TABLE customers (id uuid, CONSTRAINT customers_pkey PRIMARY KEY (id));`,
+			want: []string{
+				"crud add resource order --field customerId:string:required,ref=customer --field total:float64 --field shippedAt:time:nullable",
+				"crud add resource customer",
+			},
+			warnings: []string{"table orders: primary key order_id is the id of the resource"},
+		},
+		{
+			name: "keys added with alter table",
+			ddl: `CREATE TABLE public.customers (id uuid NOT NULL);
+			CREATE TABLE public.orders (id integer NOT NULL, customer_id uuid);
+			CREATE SEQUENCE public.orders_id_seq START WITH 1;
+			ALTER TABLE ONLY public.customers ADD CONSTRAINT customers_pkey PRIMARY KEY (id);
+			ALTER TABLE ONLY public.orders
+				ADD CONSTRAINT orders_pkey PRIMARY KEY (id),
+				ADD CONSTRAINT orders_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES public.customers(id);
+			ALTER TABLE public.unknown ADD CONSTRAINT unknown_pkey PRIMARY KEY (id);
+			CREATE INDEX orders_customer_id_idx ON public.orders USING btree (customer_id);`,
+			want: []string{"crud add resource customer", "crud add resource order --field customerId:string:ref=customer,nullable"},
+		},
+		{
+			name: "mysql",
+			ddl: "-- mysqldump\n/*!40101 SET NAMES utf8 */;\n" +
+				"CREATE TABLE `products` (\n" +
+				"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+				"  `name` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL COMMENT 'the ''name''',\n" +
+				"  `stock` int(10) unsigned NOT NULL DEFAULT '0',\n" +
+				"  `active` tinyint(1) NOT NULL DEFAULT '1',\n" +
+				"  `size` enum('small','medium','large') NOT NULL,\n" +
+				"  `price` decimal(10,2) DEFAULT NULL,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  KEY `products_name` (`name`)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+			want: []string{"crud add resource product --field name:string:required,max=100 --field stock:int64 --field active:bool " +
+				"--field 'size:string:required,enum=small|medium|large' --field price:float64:nullable"},
+		},
+		{
+			name: "sqlite",
+			ddl: `CREATE TABLE "people" (
+				"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+				[full name] TEXT NOT NULL,
+				age INT,
+				score REAL,
+				avatar BLOB,
+				meta
+			) WITHOUT ROWID;`,
+			want: []string{"crud add resource person --field fullName:string:required --field age:int64:nullable --field score:float64:nullable"},
+			warnings: []string{
+				"table people: column avatar of type blob is not imported, it has no field type",
+				"table people: column meta of type untyped is not imported, it has no field type",
+			},
+		},
+		{
+			name: "postgres types",
+			ddl: `CREATE UNLOGGED TABLE events (
+				id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+				kind character varying(20) NOT NULL,
+				amount double precision,
+				at timestamp(3) without time zone,
+				payload jsonb NOT NULL DEFAULT '{}',
+				tags text[],
+				body bytea,
+				note text DEFAULT $$it's$$
+			);`,
+			want: []string{"crud add resource event --field kind:string:required,max=20 --field amount:float64:nullable " +
+				"--field at:time:nullable --field payload:string --field note:string:nullable"},
+			warnings: []string{
+				"table events: column payload of type jsonb is a string holding the json document",
+				"table events: column tags of type text[] is not imported, it has no field type",
+				"table events: column body of type bytea is not imported, it has no field type",
+			},
+		},
+		{
+			name:    "dialect provided",
+			ddl:     `CREATE TABLE counters (id integer PRIMARY KEY, hits integer NOT NULL);`,
+			dialect: SQLiteDialect,
+			want:    []string{"crud add resource counter --field hits:int64"},
+		},
+		{
+			name: "tables filtered",
+			ddl: `CREATE TABLE customers (id uuid PRIMARY KEY);
+			CREATE TABLE orders (id uuid PRIMARY KEY, customer_id uuid NOT NULL REFERENCES customers, note varchar(10));`,
+			tables: []string{"ORDERS"},
+			want:   []string{"crud add resource order --field customerId:string:required --field note:string:max=10,nullable"},
+			warnings: []string{
+				"table orders: foreign key customer_id references table customers which is not imported, the reference is not checked",
+			},
+		},
+		{
+			name: "keys which are not imported",
+			ddl: `CREATE TABLE order_items (
+				order_id uuid NOT NULL,
+				line integer NOT NULL,
+				product_code text REFERENCES products (code),
+				PRIMARY KEY (order_id, line),
+				FOREIGN KEY (order_id, line) REFERENCES shipments (order_id, line)
+			);
+			CREATE TABLE products (id uuid PRIMARY KEY, code text UNIQUE);
+			CREATE TABLE notes (body text, updated_at timestamp);`,
+			want: []string{
+				"crud add resource order-item --field orderId:string:required --field line:int --field productCode:string:nullable",
+				"crud add resource product --field code:string:nullable",
+				"crud add resource note --field body:string:nullable",
+			},
+			warnings: []string{
+				"table order_items: composite primary key (order_id, line) is not imported, the ids of the resources are generated",
+				"table order_items: foreign key product_code references column code of table products which is not its primary key, the reference is not checked",
+				"table order_items: composite foreign key (order_id, line) is not imported",
+				"table notes: it has no primary key, the ids of the resources are generated",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, warnings, err := (&Project{}).ImportSQL(tt.ddl, tt.dialect, tt.tables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range resources {
+				got = append(got, r.AddCommand())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ImportSQL() resources =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("ImportSQL() warnings =\n%s\nwant\n%s", strings.Join(warnings, "\n"), strings.Join(tt.warnings, "\n"))
+			}
+		})
+	}
+}
+
+func TestImportSQLReferencesProject(t *testing.T) {
+	p := &Project{Resources: []*Resource{{Name: "customer"}}}
+	resources, warnings, err := p.ImportSQL(`CREATE TABLE orders (id uuid PRIMARY KEY, customer_id uuid NOT NULL REFERENCES customers (id));`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 || len(resources) != 1 || resources[0].Fields[0].References != "customer" {
+		t.Errorf("ImportSQL() = %v, %v, want the field referencing the customer of the project", resources, warnings)
+	}
+}
+
+func TestImportSQLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ddl     string
+		tables  []string
+		wantErr string
+	}{
+		{name: "no tables", ddl: "CREATE INDEX items_name ON items (name);", wantErr: "no CREATE TABLE statement found"},
+		{name: "table not created", ddl: "CREATE TABLE items (id uuid);", tables: []string{"orders"}, wantErr: "table orders is not created"},
+		{name: "table created twice", ddl: "CREATE TABLE items (id uuid); CREATE TABLE ITEMS (id uuid);", wantErr: "table ITEMS is created twice"},
+		{name: "table without columns", ddl: "CREATE TABLE items (PRIMARY KEY (id));", wantErr: "table items has no columns"},
+		{name: "foreign key without references", ddl: "CREATE TABLE items (id uuid, FOREIGN KEY (id));", wantErr: "foreign key (id) without REFERENCES"},
+		{name: "unterminated quote", ddl: "CREATE TABLE items (name text DEFAULT 'x);", wantErr: "unterminated quote '"},
+		{name: "unterminated comment", ddl: "CREATE TABLE items (id uuid); /* the end", wantErr: "unterminated comment"},
+		{name: "unterminated dollar quote", ddl: "CREATE TABLE items (name text DEFAULT $x$abc);", wantErr: "unterminated dollar quoted string $x$"},
+		{name: "invalid field name", ddl: "CREATE TABLE items (id uuid PRIMARY KEY, \"1st\" text);", wantErr: "table items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := (&Project{}).ImportSQL(tt.ddl, "", tt.tables)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ImportSQL() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectDialect(t *testing.T) {
+	tests := []struct {
+		ddl  string
+		want string
+	}{
+		{ddl: "CREATE TABLE `items` (id int);", want: MySQLDialect},
+		{ddl: "CREATE TABLE items (id int AUTO_INCREMENT);", want: MySQLDialect},
+		{ddl: "CREATE TABLE items (id int) ENGINE=InnoDB;", want: MySQLDialect},
+		{ddl: "CREATE TABLE items (id integer PRIMARY KEY AUTOINCREMENT);", want: SQLiteDialect},
+		{ddl: "CREATE TABLE items (id integer PRIMARY KEY) WITHOUT ROWID;", want: SQLiteDialect},
+		{ddl: `CREATE TABLE "items" (id serial, at timestamp without time zone);`, want: PostgresDialect},
+		{ddl: "CREATE TABLE items (note text DEFAULT 'AUTO_INCREMENT');", want: PostgresDialect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ddl, func(t *testing.T) {
+			tokens, err := tokenizeSQL(tt.ddl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := detectDialect(tokens); got != tt.want {
+				t.Errorf("detectDialect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableResourceName(t *testing.T) {
+	tests := map[string]string{
+		"items":       "item",
+		"order_items": "order-item",
+		"OrderLines":  "order-line",
+		"categories":  "category",
+		"addresses":   "address",
+		"boxes":       "box",
+		"batches":     "batch",
+		"statuses":    "status",
+		"houses":      "house",
+		"people":      "person",
+		"children":    "child",
+		"status":      "status",
+		"analysis":    "analysis",
+		"staff":       "staff",
+	}
+	for table, want := range tests {
+		if got := tableResourceName(table); got != want {
+			t.Errorf("tableResourceName(%q) = %q, want %q", table, got, want)
+		}
+	}
+}
+
+func TestValidateDialect(t *testing.T) {
+	for _, dialect := range []string{"", PostgresDialect, MySQLDialect, SQLiteDialect} {
+		if err := ValidateDialect(dialect); err != nil {
+			t.Errorf("ValidateDialect(%q) = %v", dialect, err)
+		}
+	}
+	if err := ValidateDialect("oracle"); err == nil || !strings.Contains(err.Error(), `invalid dialect "oracle"`) {
+		t.Errorf("ValidateDialect(oracle) = %v, want the invalid dialect error", err)
+	}
+}
@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/piyushjajoo/crud/tpl"
+)
+
+// languages the clients of the api can be generated in, the go client is generated in client/ by crud init
+const (
+	TypeScriptLang = "ts"
+)
+
+// DefaultTSClientDir is the directory of the typescript client relative to the project root
+const DefaultTSClientDir = "client/ts"
+
+// tsTypes maps the field types to their typescript types, times are RFC 3339 strings in json
+var tsTypes = map[string]string{
+	StringFieldType:  "string",
+	BoolFieldType:    "boolean",
+	IntFieldType:     "number",
+	Int64FieldType:   "number",
+	Float64FieldType: "number",
+	TimeFieldType:    "string",
+}
+
+// ValidateClientLang validates the language of the client
+func ValidateClientLang(lang string) error {
+	if lang != TypeScriptLang {
+		return fmt.Errorf("invalid lang %q, must be %s", lang, TypeScriptLang)
+	}
+	return nil
+}
+
+// GenerateTSClient generates the typescript client of the resources in the directory relative to the project root
+// and records it in the manifest, so it is generated again every time a resource is added
+func (p *Project) GenerateTSClient(dir string) error {
+	if p.Worker() {
+		return fmt.Errorf("the client can't be generated, the project is a %s", WorkerType)
+	}
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return fmt.Errorf("invalid client directory %q, it must be inside the project", dir)
+	}
+	p.TSClient = dir
+	if err := p.writeTSClient(); err != nil {
+		return err
+	}
+	if err := p.writeBaseFiles((*Project).writeTSClient); err != nil {
+		return err
+	}
+	return p.WriteManifest()
+}
+
+// writeTSClient writes the interfaces of the models and the fetch client of the resources into the directory of
+// the typescript client
+func (p *Project) writeTSClient() error {
+	dir := p.AbsolutePath + "/" + p.TSClient
+	if err := os.MkdirAll(dir, 0754); err != nil {
+		log.Println("error creating typescript client directory at", dir, ":", err)
+		return err
+	}
+	if err := p.createFileFromTemplate(dir+"/models.ts", "tsmodels", tpl.TSModelsTemplate(), p); err != nil {
+		return err
+	}
+	return p.createFileFromTemplate(dir+"/client.ts", "tsclient", tpl.TSClientTemplate(), p)
+}
+
+// VarPluralName returns the lower camel case plural name of the resource, e.g. orderLines
+func (r *Resource) VarPluralName() string {
+	return lowerCamel(pluralWords(r.Name))
+}
+
+// TSType returns the typescript type of the field, the fields with enum validation are unions of their values
+// along with the zero value of the type unless they are required, the nullable fields may be null
+func (f *Field) TSType() string {
+	if f.Nullable {
+		return tsType(f) + " | null"
+	}
+	return tsType(f)
+}
+
+// tsType returns the typescript type of the values of the field
+func tsType(f *Field) string {
+	if f.Validation == nil || len(f.Validation.Enum) == 0 {
+		return tsTypes[f.Type]
+	}
+	var values []string
+	for _, v := range f.Validation.Enum {
+		if f.Type == StringFieldType {
+			v = strconv.Quote(v)
+		}
+		values = append(values, v)
+	}
+	// the non-null values of nullable fields are validated, so they can't be the zero value either
+	if !f.Validation.Required && !f.Nullable {
+		if f.Type == StringFieldType {
+			values = append(values, `""`)
+		} else {
+			values = append(values, "0")
+		}
+	}
+	return strings.Join(values, " | ")
+}
+
+// Required reports whether the field must be provided when the resource is created or updated
+func (f *Field) Required() bool {
+	return f.Validation != nil && f.Validation.Required
+}
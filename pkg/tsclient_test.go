@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTSClient(t *testing.T) {
+	p := shopProject(t, &Project{ModuleName: "github.com/acme/shop", AbsolutePath: t.TempDir(), Router: MuxRouter})
+	if err := p.GenerateTSClient("web/src/../api/"); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]string{
+		"web/api/models.ts": {
+			"export interface Order {\n  id: string;\n  customerId: string;\n  parentId: string | null;\n  status: \"open\" | \"paid\" | \"\";\n",
+			"export interface OrderInput {\n  customerId: string;\n  parentId?: string | null;\n  status?: \"open\" | \"paid\" | \"\";\n}\n",
+			"export interface CustomerInput {\n  name: string;\n}\n",
+		},
+		"web/api/client.ts": {
+			"import type {\n  Customer,\n  CustomerInput,\n  Order,\n  OrderInput,\n} from \"./models\";\n",
+			"  readonly orders: ResourceClient<Order, OrderInput>;\n",
+			"    this.customers = new ResourceClient(this, \"/customers\");\n",
+		},
+	}
+	for name, wants := range files {
+		content, err := os.ReadFile(filepath.Join(p.AbsolutePath, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		base, err := os.ReadFile(filepath.Join(p.AbsolutePath, BaseDir, name))
+		if err != nil || string(base) != string(content) {
+			t.Errorf("base of %s isn't recorded, error = %v", name, err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("%s doesn't contain %q\n%s", name, want, content)
+			}
+		}
+	}
+
+	loaded, err := LoadProject(p.AbsolutePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.TSClient != "web/api" {
+		t.Errorf("tsClient of the manifest = %q, want web/api", loaded.TSClient)
+	}
+}
+
+func TestGenerateTSClientErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		project *Project
+		dir     string
+		wantErr string
+	}{
+		{name: "outside the project", project: &Project{Router: MuxRouter}, dir: "web/../../api", wantErr: `invalid client directory "../api"`},
+		{name: "absolute", project: &Project{Router: MuxRouter}, dir: "/tmp/api", wantErr: "it must be inside the project"},
+		{name: "worker", project: &Project{Type: WorkerType, Events: KafkaEvents}, dir: DefaultTSClientDir, wantErr: "the project is a worker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.project.AbsolutePath = t.TempDir()
+			err := tt.project.GenerateTSClient(tt.dir)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("GenerateTSClient() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if entries, _ := os.ReadDir(tt.project.AbsolutePath); len(entries) != 0 {
+				t.Errorf("GenerateTSClient() wrote %s", entries[0].Name())
+			}
+		})
+	}
+}
+
+func TestFieldTSType(t *testing.T) {
+	tests := []struct {
+		definition string
+		want       string
+	}{
+		{definition: "name:string", want: "string"},
+		{definition: "active:bool", want: "boolean"},
+		{definition: "count:int64", want: "number"},
+		{definition: "price:float64:nullable", want: "number | null"},
+		{definition: "dueAt:time", want: "string"},
+		{definition: "status:string:required,enum=open|paid", want: `"open" | "paid"`},
+		{definition: "status:string:enum=open|paid", want: `"open" | "paid" | ""`},
+		{definition: "status:string:enum=open|paid,nullable", want: `"open" | "paid" | null`},
+		{definition: "priority:int:enum=1|2", want: "1 | 2 | 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			f, err := ParseField(tt.definition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.TSType(); got != tt.want {
+				t.Errorf("TSType() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateClientLang(t *testing.T) {
+	if err := ValidateClientLang(TypeScriptLang); err != nil {
+		t.Errorf("ValidateClientLang(%q) error = %v", TypeScriptLang, err)
+	}
+	if err := ValidateClientLang("python"); err == nil || !strings.Contains(err.Error(), `invalid lang "python"`) {
+		t.Errorf("ValidateClientLang(python) error = %v, want the lang rejected", err)
+	}
+}
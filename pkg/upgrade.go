@@ -0,0 +1,216 @@
+package pkg
+
+import (
+	"bytes"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BaseDir is the directory relative to the project root where the files are recorded as they were generated,
+// crud upgrade merges the changes of the templates into the project against them
+const BaseDir = ".crud/base"
+
+// generatedHeader marks the files which are generated again every time a resource is added and are not edited
+var generatedHeader = []byte("Code generated by crud. DO NOT EDIT.")
+
+// how crud upgrade changed a file of the project
+const (
+	// UpgradeAdded is a file the templates added
+	UpgradeAdded = "added"
+	// UpgradeUpdated is a file which wasn't changed in the project, it is replaced by the rendered file
+	UpgradeUpdated = "updated"
+	// UpgradeMerged is a file changed in the project and by the templates whose changes are merged
+	UpgradeMerged = "merged"
+	// UpgradeConflict is a file whose lines are changed differently in the project and by the templates, they are
+	// written between conflict markers
+	UpgradeConflict = "conflict"
+	// UpgradeDeleted is a file deleted from the project, it is not added again
+	UpgradeDeleted = "deleted"
+	// UpgradeSkipped is a file changed in the project which was generated before its base was recorded, it is kept
+	// as it is since its changes can't be told apart from the changes of the templates
+	UpgradeSkipped = "skipped"
+	// UpgradeUnchanged is a file the templates didn't change
+	UpgradeUnchanged = "unchanged"
+)
+
+// FileUpgrade is how crud upgrade changed the file at Path relative to the project root
+type FileUpgrade struct {
+	Path   string
+	Status string
+}
+
+// Upgrade renders the templates of the project and merges the changes since the files were generated into the
+// project, the files are written only if dryRun is false. The rendered files are recorded as the base of the next
+// upgrade.
+func (p *Project) Upgrade(dryRun bool) ([]FileUpgrade, error) {
+	rendered, err := os.MkdirTemp("", "crud-upgrade-")
+	if err != nil {
+		log.Println("error creating temporary directory:", err)
+		return nil, err
+	}
+	defer os.RemoveAll(rendered)
+	if err = p.renderInto(rendered); err != nil {
+		return nil, err
+	}
+
+	var upgrades []FileUpgrade
+	err = filepath.WalkDir(rendered, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(rendered, path)
+		if err != nil {
+			return err
+		}
+		status, err := p.upgradeFile(filepath.ToSlash(rel), path, dryRun)
+		if err != nil {
+			return err
+		}
+		upgrades = append(upgrades, FileUpgrade{Path: filepath.ToSlash(rel), Status: status})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(upgrades, func(i, j int) bool { return upgrades[i].Path < upgrades[j].Path })
+
+	if dryRun {
+		return upgrades, nil
+	}
+	if err = p.writeBase(); err != nil {
+		return nil, err
+	}
+	return upgrades, p.WriteManifest()
+}
+
+// upgradeFile merges the rendered file into the file of the project at the relative path
+func (p *Project) upgradeFile(rel, renderedPath string, dryRun bool) (string, error) {
+	theirs, err := os.ReadFile(renderedPath)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(renderedPath)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(p.AbsolutePath, filepath.FromSlash(rel))
+	base, baseErr := os.ReadFile(filepath.Join(p.AbsolutePath, BaseDir, filepath.FromSlash(rel)))
+	if baseErr != nil && !os.IsNotExist(baseErr) {
+		return "", baseErr
+	}
+
+	ours, err := os.ReadFile(target)
+	switch {
+	case os.IsNotExist(err) && baseErr == nil:
+		// the file was deleted from the project after it was generated
+		return UpgradeDeleted, nil
+	case os.IsNotExist(err):
+		return UpgradeAdded, writeUpgrade(target, theirs, info.Mode(), dryRun)
+	case err != nil:
+		return "", err
+	}
+
+	switch {
+	case bytes.Equal(ours, theirs), baseErr == nil && bytes.Equal(theirs, base):
+		// the templates didn't change the file since it was generated, the changes of the project are kept
+		return UpgradeUnchanged, nil
+	case baseErr == nil && bytes.Equal(ours, base), bytes.Contains(theirs, generatedHeader):
+		// the generated files which weren't changed in the project are replaced
+		return UpgradeUpdated, writeUpgrade(target, theirs, 0, dryRun)
+	case baseErr != nil:
+		// the files generated before the base was recorded are kept, the rendered file is recorded as their base
+		// so they can be compared and the next upgrade merges against it
+		return UpgradeSkipped, nil
+	}
+
+	merged, conflict := merge3(string(base), string(ours), string(theirs))
+	status := UpgradeMerged
+	if conflict {
+		status = UpgradeConflict
+	}
+	return status, writeUpgrade(target, []byte(merged), 0, dryRun)
+}
+
+// writeUpgrade writes the upgraded file, the mode of existing files is kept
+func writeUpgrade(path string, content []byte, mode os.FileMode, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if mode == 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		mode = info.Mode()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0754); err != nil {
+		log.Println("error creating directory of", path, ":", err)
+		return err
+	}
+	if err := os.WriteFile(path, content, mode.Perm()); err != nil {
+		log.Println("error writing", path, ":", err)
+		return err
+	}
+	return nil
+}
+
+// writeBase records the files of the templates in the base directory as they are generated
+func (p *Project) writeBase() error {
+	base := filepath.Join(p.AbsolutePath, BaseDir)
+	if err := os.RemoveAll(base); err != nil {
+		log.Println("error removing", base, ":", err)
+		return err
+	}
+	return p.renderInto(base)
+}
+
+// writeBaseFiles records the files written by the write function, e.g. the files of the resources being added, in
+// the base directory, the other files keep the base they were recorded with so crud upgrade still merges the changes
+// of the templates since then
+func (p *Project) writeBaseFiles(write func(rendered *Project) error) error {
+	dir, err := os.MkdirTemp("", "crud-base-")
+	if err != nil {
+		log.Println("error creating temporary directory:", err)
+		return err
+	}
+	defer os.RemoveAll(dir)
+	rendered := *p
+	rendered.AbsolutePath = dir
+	if err = write(&rendered); err != nil {
+		return err
+	}
+
+	base := filepath.Join(p.AbsolutePath, BaseDir)
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return writeUpgrade(filepath.Join(base, rel), content, info.Mode(), false)
+	})
+}
+
+// renderInto renders the templates of the project into the directory instead of the project directory
+func (p *Project) renderInto(dir string) error {
+	if err := os.MkdirAll(dir, 0754); err != nil {
+		log.Println("error creating directory", dir, ":", err)
+		return err
+	}
+	rendered := *p
+	rendered.AbsolutePath = dir
+	return rendered.render()
+}
@@ -0,0 +1,253 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// upgradeProject generates a project in a temporary directory with its base recorded, as crud init does
+func upgradeProject(t *testing.T) *Project {
+	t.Helper()
+	p := &Project{
+		ModuleName:     "github.com/acme/shop",
+		ProjectDirName: "shop",
+		AbsolutePath:   t.TempDir(),
+		Router:         MuxRouter,
+		BaseImage:      DistrolessBaseImage,
+	}
+	if err := p.renderInto(p.AbsolutePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.writeBase(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func readUpgradeFile(t *testing.T, path string) (string, bool) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content), true
+}
+
+func writeUpgradeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func removeUpgradeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	// the templates didn't change since the project was generated, the older templates are simulated by changing
+	// the recorded base, oldTemplate is the line they rendered instead of the first line
+	const oldTemplate = "# rendered by the older templates\n"
+	older := func(rendered string) string {
+		return oldTemplate + rendered[strings.Index(rendered, "\n")+1:]
+	}
+	const edit = "# changed in the project\n"
+
+	tests := []struct {
+		name   string
+		path   string
+		dryRun bool
+		// setup changes the file and its base, rendered is the file rendered by the templates
+		setup      func(t *testing.T, file, base, rendered string)
+		wantStatus string
+		// wantFile is the file after the upgrade, empty if it doesn't exist
+		wantFile func(rendered string) string
+		// wantBase is the base after the upgrade, it is the rendered file unless the upgrade is a dry run
+		wantBase func(rendered string) string
+	}{
+		{
+			name:       "unchanged",
+			path:       "main.go",
+			setup:      func(t *testing.T, file, base, rendered string) {},
+			wantStatus: UpgradeUnchanged,
+			wantFile:   func(rendered string) string { return rendered },
+		},
+		{
+			name: "changed only in the project",
+			path: "main.go",
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, rendered+edit)
+			},
+			wantStatus: UpgradeUnchanged,
+			wantFile:   func(rendered string) string { return rendered + edit },
+		},
+		{
+			name: "changed only by the templates",
+			path: "Dockerfile",
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, older(rendered))
+				writeUpgradeFile(t, base, older(rendered))
+			},
+			wantStatus: UpgradeUpdated,
+			wantFile:   func(rendered string) string { return rendered },
+		},
+		{
+			name: "changed in the project and by the templates",
+			path: "Dockerfile",
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, older(rendered)+edit)
+				writeUpgradeFile(t, base, older(rendered))
+			},
+			wantStatus: UpgradeMerged,
+			wantFile:   func(rendered string) string { return rendered + edit },
+		},
+		{
+			name: "same lines changed in the project and by the templates",
+			path: "Dockerfile",
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, edit+older(rendered)[len(oldTemplate):])
+				writeUpgradeFile(t, base, older(rendered))
+			},
+			wantStatus: UpgradeConflict,
+			wantFile: func(rendered string) string {
+				firstLine := rendered[:strings.Index(rendered, "\n")+1]
+				return conflictStart + edit + conflictSeparator + firstLine + conflictEnd + rendered[len(firstLine):]
+			},
+		},
+		{
+			name: "deleted",
+			path: "Dockerfile",
+			setup: func(t *testing.T, file, base, rendered string) {
+				removeUpgradeFile(t, file)
+				writeUpgradeFile(t, base, older(rendered))
+			},
+			wantStatus: UpgradeDeleted,
+			wantFile:   func(rendered string) string { return "" },
+		},
+		{
+			name: "added",
+			path: "Dockerfile",
+			setup: func(t *testing.T, file, base, rendered string) {
+				removeUpgradeFile(t, file)
+				removeUpgradeFile(t, base)
+			},
+			wantStatus: UpgradeAdded,
+			wantFile:   func(rendered string) string { return rendered },
+		},
+		{
+			name: "generated header",
+			path: "pkg/repository/repositories.go",
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, older(rendered)+edit)
+				writeUpgradeFile(t, base, older(rendered))
+			},
+			wantStatus: UpgradeUpdated,
+			wantFile:   func(rendered string) string { return rendered },
+		},
+		{
+			name: "generated header without base",
+			path: "pkg/repository/repositories.go",
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, older(rendered))
+				removeUpgradeFile(t, base)
+			},
+			wantStatus: UpgradeUpdated,
+			wantFile:   func(rendered string) string { return rendered },
+		},
+		{
+			name: "missing base",
+			path: "Dockerfile",
+			setup: func(t *testing.T, file, base, rendered string) {
+				removeUpgradeFile(t, base)
+			},
+			wantStatus: UpgradeUnchanged,
+			wantFile:   func(rendered string) string { return rendered },
+		},
+		{
+			name: "missing base of a changed file",
+			path: "Dockerfile",
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, older(rendered)+edit)
+				removeUpgradeFile(t, base)
+			},
+			wantStatus: UpgradeSkipped,
+			wantFile:   func(rendered string) string { return older(rendered) + edit },
+		},
+		{
+			name:   "dry run",
+			path:   "Dockerfile",
+			dryRun: true,
+			setup: func(t *testing.T, file, base, rendered string) {
+				writeUpgradeFile(t, file, older(rendered)+edit)
+				writeUpgradeFile(t, base, older(rendered))
+			},
+			wantStatus: UpgradeMerged,
+			wantFile:   func(rendered string) string { return older(rendered) + edit },
+			wantBase:   func(rendered string) string { return older(rendered) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := upgradeProject(t)
+			file := filepath.Join(p.AbsolutePath, filepath.FromSlash(tt.path))
+			base := filepath.Join(p.AbsolutePath, BaseDir, filepath.FromSlash(tt.path))
+			rendered, _ := readUpgradeFile(t, file)
+			tt.setup(t, file, base, rendered)
+
+			upgrades, err := p.Upgrade(tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, u := range upgrades {
+				want := UpgradeUnchanged
+				if u.Path == tt.path {
+					want = tt.wantStatus
+				}
+				if u.Status != want {
+					t.Errorf("Upgrade() %s = %s, want %s", u.Path, u.Status, want)
+				}
+			}
+
+			got, exists := readUpgradeFile(t, file)
+			if want := tt.wantFile(rendered); got != want || exists != (want != "") {
+				t.Errorf("%s after Upgrade() = %q, want %q", tt.path, got, want)
+			}
+			wantBase := rendered
+			if tt.wantBase != nil {
+				wantBase = tt.wantBase(rendered)
+			}
+			if got, _ := readUpgradeFile(t, base); got != wantBase {
+				t.Errorf("base of %s after Upgrade() = %q, want %q", tt.path, got, wantBase)
+			}
+		})
+	}
+}
+
+func TestUpgradeWritesManifest(t *testing.T) {
+	p := upgradeProject(t)
+	if _, err := p.Upgrade(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := readUpgradeFile(t, filepath.Join(p.AbsolutePath, ManifestFileName)); exists {
+		t.Fatalf("Upgrade(true) wrote %s", ManifestFileName)
+	}
+
+	if _, err := p.Upgrade(false); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadProject(p.AbsolutePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ModuleName != p.ModuleName || loaded.Router != p.Router {
+		t.Errorf("LoadProject() after Upgrade(false) = %+v, want the manifest of the project", loaded)
+	}
+}
@@ -0,0 +1,250 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validation holds the rules a resource field is validated with in the create and update handlers,
+// they are written as validate tags of the model
+type Validation struct {
+	Required bool `yaml:"required,omitempty"`
+	// Min and Max are the length of string fields and the value of number fields
+	Min   *float64 `yaml:"min,omitempty"`
+	Max   *float64 `yaml:"max,omitempty"`
+	Email bool     `yaml:"email,omitempty"`
+	Regex string   `yaml:"regex,omitempty"`
+	Enum  []string `yaml:"enum,omitempty"`
+}
+
+// ParseValidation parses the comma separated validation rules of the field, e.g. required,min=0,max=100,
+// the rules are required, min=<n>, max=<n>, email, enum=<a>|<b>, ref=<resource>, which sets the resource referenced
+// by the field, nullable, which makes the field a pointer, and regex=<pattern>, which must be the last rule as the
+// pattern may contain commas
+func ParseValidation(rules string, f *Field) (*Validation, error) {
+	v := &Validation{}
+	for rules != "" {
+		rule := rules
+		if strings.HasPrefix(rules, "regex=") {
+			rules = ""
+		} else if i := strings.Index(rules, ","); i >= 0 {
+			rule, rules = rules[:i], rules[i+1:]
+		} else {
+			rules = ""
+		}
+
+		name, value := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, value = rule[:i], rule[i+1:]
+		}
+		switch name {
+		case "required":
+			v.Required = true
+		case "email":
+			v.Email = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q of field %s, %s must be a number", rule, f.Name, name)
+			}
+			if name == "min" {
+				v.Min = &n
+			} else {
+				v.Max = &n
+			}
+		case "enum":
+			v.Enum = strings.Split(value, "|")
+		case "regex":
+			v.Regex = value
+		case "ref":
+			if err := f.SetReferences(value); err != nil {
+				return nil, err
+			}
+		case "nullable":
+			f.Nullable = true
+		default:
+			return nil, fmt.Errorf("invalid rule %q of field %s, must be one of required, min, max, email, enum, ref, nullable or regex", rule, f.Name)
+		}
+	}
+	return v, v.check(f)
+}
+
+// empty reports whether the validation has no rules
+func (v *Validation) empty() bool {
+	return !v.Required && v.Min == nil && v.Max == nil && !v.Email && v.Regex == "" && len(v.Enum) == 0
+}
+
+// check reports the rules which can't be applied to the type of the field
+func (v *Validation) check(f *Field) error {
+	isString := f.Type == StringFieldType
+	isNumber := f.Type == IntFieldType || f.Type == Int64FieldType || f.Type == Float64FieldType
+	isInteger := f.Type == IntFieldType || f.Type == Int64FieldType
+
+	if v.Required && f.Type == BoolFieldType {
+		return fmt.Errorf("required can't be applied to bool field %s, false would be rejected", f.Name)
+	}
+	if v.Required && f.Nullable {
+		return fmt.Errorf("required can't be applied to nullable field %s", f.Name)
+	}
+	if (v.Min != nil || v.Max != nil) && !isString && !isNumber {
+		return fmt.Errorf("min and max can't be applied to %s field %s", f.Type, f.Name)
+	}
+	for _, n := range []*float64{v.Min, v.Max} {
+		if n != nil && (isString || isInteger) && *n != float64(int64(*n)) {
+			return fmt.Errorf("min and max of field %s must be integers", f.Name)
+		}
+		if n != nil && isString && *n < 0 {
+			return fmt.Errorf("min and max of string field %s are lengths, they can't be negative", f.Name)
+		}
+	}
+	if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+		return fmt.Errorf("min of field %s is greater than max", f.Name)
+	}
+	if (v.Email || v.Regex != "") && !isString {
+		return fmt.Errorf("email and regex can only be applied to string fields, %s is %s", f.Name, f.Type)
+	}
+	if strings.Contains(v.Regex, "`") {
+		return fmt.Errorf("regex of field %s can't contain backticks, they can't be written in a struct tag", f.Name)
+	}
+	if v.Regex != "" {
+		if _, err := regexp.Compile(v.Regex); err != nil {
+			return fmt.Errorf("invalid regex of field %s: %w", f.Name, err)
+		}
+	}
+	if len(v.Enum) > 0 && !isString && !isInteger {
+		return fmt.Errorf("enum can only be applied to string and integer fields, %s is %s", f.Name, f.Type)
+	}
+	for _, value := range v.Enum {
+		if value == "" || strings.ContainsAny(value, " ,|") {
+			return fmt.Errorf("invalid enum value %q of field %s, it can't be empty or contain spaces, commas or pipes", value, f.Name)
+		}
+		if _, err := strconv.ParseInt(value, 10, 64); isInteger && err != nil {
+			return fmt.Errorf("invalid enum value %q of integer field %s", value, f.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateTag returns the validate tag of the field or an empty string if it has no rules, fields which are not
+// required may have their zero value, e.g. an empty string for an optional email
+func (f *Field) ValidateTag() string {
+	v := f.Validation
+	if v == nil {
+		return ""
+	}
+
+	var rules []string
+	if v.Min != nil {
+		rules = append(rules, "min="+formatNumber(*v.Min))
+	}
+	if v.Max != nil {
+		rules = append(rules, "max="+formatNumber(*v.Max))
+	}
+	if v.Email {
+		rules = append(rules, "email")
+	}
+	if len(v.Enum) > 0 {
+		rules = append(rules, "oneof="+strings.Join(v.Enum, " "))
+	}
+	if v.Regex != "" {
+		// commas and pipes separate the rules of the validate tag, the validator unescapes them in the param
+		pattern := strings.NewReplacer(",", "0x2C", "|", "0x7C").Replace(v.Regex)
+		rules = append(rules, "regex="+pattern)
+	}
+
+	switch {
+	case v.Required:
+		rules = append([]string{"required"}, rules...)
+	case len(rules) > 0:
+		rules = append([]string{"omitempty"}, rules...)
+	}
+	return strings.Join(rules, ",")
+}
+
+// Definition returns the definition of the field in the form name:type[:rules] of crud add resource --field, the
+// inverse of ParseField
+func (f *Field) Definition() string {
+	var rules []string
+	if v := f.Validation; v != nil {
+		if v.Required {
+			rules = append(rules, "required")
+		}
+		if v.Min != nil {
+			rules = append(rules, "min="+formatNumber(*v.Min))
+		}
+		if v.Max != nil {
+			rules = append(rules, "max="+formatNumber(*v.Max))
+		}
+		if v.Email {
+			rules = append(rules, "email")
+		}
+		if len(v.Enum) > 0 {
+			rules = append(rules, "enum="+strings.Join(v.Enum, "|"))
+		}
+	}
+	if f.References != "" {
+		rules = append(rules, "ref="+f.References)
+	}
+	if f.Nullable {
+		rules = append(rules, "nullable")
+	}
+	if f.Validation != nil && f.Validation.Regex != "" {
+		rules = append(rules, "regex="+f.Validation.Regex)
+	}
+	if len(rules) == 0 {
+		return f.Name + ":" + f.Type
+	}
+	return f.Name + ":" + f.Type + ":" + strings.Join(rules, ",")
+}
+
+// Tag returns the struct tag of the field in the model
+func (f *Field) Tag() string {
+	tag := fmt.Sprintf(`json:"%s"`, f.JSONName())
+	if validate := f.ValidateTag(); validate != "" {
+		// the tag value is unquoted by reflect, so backslashes of regex patterns are escaped
+		tag += " validate:" + strconv.Quote(validate)
+	}
+	return tag
+}
+
+// schemaConstraints adds the validation rules of the field to its json schema
+func (f *Field) schemaConstraints(schema object) {
+	v := f.Validation
+	if v == nil {
+		return
+	}
+
+	minKey, maxKey := "minimum", "maximum"
+	if f.Type == StringFieldType {
+		minKey, maxKey = "minLength", "maxLength"
+	}
+	if v.Min != nil {
+		schema[minKey] = *v.Min
+	}
+	if v.Max != nil {
+		schema[maxKey] = *v.Max
+	}
+	if v.Email {
+		schema["format"] = "email"
+	}
+	if v.Regex != "" {
+		schema["pattern"] = v.Regex
+	}
+	if len(v.Enum) > 0 {
+		var enum []interface{}
+		for _, value := range v.Enum {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && f.Type != StringFieldType {
+				enum = append(enum, n)
+			} else {
+				enum = append(enum, value)
+			}
+		}
+		schema["enum"] = enum
+	}
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
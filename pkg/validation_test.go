@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValidation(t *testing.T) {
+	tests := []struct {
+		definition string
+		// wantTag is the validate tag of the model, wantDefinition the definition the field is written back as
+		wantTag        string
+		wantDefinition string
+		wantErr        string
+	}{
+		{definition: "name:string", wantTag: "", wantDefinition: "name:string"},
+		{definition: "name:string:required,min=1,max=100", wantTag: "required,min=1,max=100", wantDefinition: "name:string:required,min=1,max=100"},
+		{definition: "email:string:email", wantTag: "omitempty,email", wantDefinition: "email:string:email"},
+		{definition: "price:float64:min=0.5,max=10", wantTag: "omitempty,min=0.5,max=10", wantDefinition: "price:float64:min=0.5,max=10"},
+		{definition: "status:string:required,enum=active|archived", wantTag: "required,oneof=active archived", wantDefinition: "status:string:required,enum=active|archived"},
+		{definition: "priority:int:enum=1|2|3", wantTag: "omitempty,oneof=1 2 3", wantDefinition: "priority:int:enum=1|2|3"},
+		{definition: "sku:string:required,regex=^[A-Z]{2,4}|[0-9]+$", wantTag: "required,regex=^[A-Z]{20x2C4}0x7C[0-9]+$", wantDefinition: "sku:string:required,regex=^[A-Z]{2,4}|[0-9]+$"},
+		{definition: "customerId:string:ref=Customer,nullable", wantTag: "", wantDefinition: "customerId:string:ref=customer,nullable"},
+		{definition: "name:string:required,nullable", wantErr: "required can't be applied to nullable field name"},
+		{definition: "active:bool:required", wantErr: "false would be rejected"},
+		{definition: "active:bool:min=1", wantErr: "min and max can't be applied to bool field active"},
+		{definition: "name:string:min=1.5", wantErr: "must be integers"},
+		{definition: "name:string:min=-1", wantErr: "can't be negative"},
+		{definition: "count:int:min=10,max=1", wantErr: "min of field count is greater than max"},
+		{definition: "count:int:min=ten", wantErr: "min must be a number"},
+		{definition: "count:int:email", wantErr: "email and regex can only be applied to string fields"},
+		{definition: "name:string:regex=a`b", wantErr: "can't contain backticks"},
+		{definition: "name:string:regex=(", wantErr: "invalid regex of field name"},
+		{definition: "price:float64:enum=1|2", wantErr: "enum can only be applied to string and integer fields"},
+		{definition: "status:string:enum=a||b", wantErr: `invalid enum value ""`},
+		{definition: "priority:int:enum=1|high", wantErr: `invalid enum value "high" of integer field priority`},
+		{definition: "count:int:ref=item", wantErr: "the ids are strings"},
+		{definition: "name:string:unique", wantErr: `invalid rule "unique" of field name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			f, err := ParseField(tt.definition)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseField() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.ValidateTag(); got != tt.wantTag {
+				t.Errorf("ValidateTag() = %q, want %q", got, tt.wantTag)
+			}
+			if got := f.Definition(); got != tt.wantDefinition {
+				t.Errorf("Definition() = %q, want %q", got, tt.wantDefinition)
+			}
+		})
+	}
+}
+
+func TestFieldTag(t *testing.T) {
+	tests := []struct {
+		definition string
+		want       string
+	}{
+		{definition: "name:string", want: `json:"name"`},
+		{definition: "sku-code:string:required", want: `json:"skuCode" validate:"required"`},
+		{definition: `code:string:regex=^\d+$`, want: `json:"code" validate:"omitempty,regex=^\\d+$"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			f, err := ParseField(tt.definition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Tag(); got != tt.want {
+				t.Errorf("Tag() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaConstraints(t *testing.T) {
+	tests := []struct {
+		definition string
+		want       object
+	}{
+		{definition: "name:string", want: object{"type": "string"}},
+		{definition: "name:string:required,min=1,max=10", want: object{"type": "string", "minLength": 1.0, "maxLength": 10.0}},
+		{definition: "price:float64:min=0", want: object{"type": "number", "format": "double", "minimum": 0.0}},
+		{definition: "email:string:email,regex=@acme\\.com$", want: object{"type": "string", "format": "email", "pattern": "@acme\\.com$"}},
+		{definition: "status:string:enum=1|active", want: object{"type": "string", "enum": []interface{}{"1", "active"}}},
+		{definition: "priority:int:enum=1|2", want: object{"type": "integer", "format": "int64", "enum": []interface{}{int64(1), int64(2)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			f, err := ParseField(tt.definition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			schema := f.schema()
+			f.schemaConstraints(schema)
+			if !reflect.DeepEqual(schema, tt.want) {
+				t.Errorf("schemaConstraints() = %v, want %v", schema, tt.want)
+			}
+		})
+	}
+}
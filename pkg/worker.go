@@ -0,0 +1,42 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// types of the projects, the service serves the resources over http and the worker consumes the messages of
+// a broker
+const (
+	ServiceType = "service"
+	WorkerType  = "worker"
+)
+
+// ValidateType validates the project type, empty means a service
+func ValidateType(typ string) error {
+	switch typ {
+	case "", ServiceType, WorkerType:
+		return nil
+	}
+	return fmt.Errorf("invalid type %q, must be one of %s or %s", typ, ServiceType, WorkerType)
+}
+
+// Worker reports whether the project is a worker consuming the messages of a broker
+func (p *Project) Worker() bool {
+	return p.Type == WorkerType
+}
+
+// ValidateWorker validates the options of a worker, the events broker is the broker the messages are consumed
+// from and the options of the resource endpoints don't apply
+func (p *Project) ValidateWorker() error {
+	if !p.Worker() {
+		return nil
+	}
+	if p.Events == LogEvents {
+		return fmt.Errorf("worker can't consume the %s events, provide --events with %s or %s", LogEvents, KafkaEvents, NATSEvents)
+	}
+	if p.CreateApiDoc || p.GRPC || p.Authenticated() || p.RateLimit || p.Cached() || p.Outbox || p.Metrics {
+		return errors.New("worker has no resource endpoints, --swagger, --grpc, --auth, --rate-limit, --cache, --outbox and --metrics can't be provided")
+	}
+	return nil
+}
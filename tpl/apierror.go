@@ -0,0 +1,173 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// APIErrorTemplate returns template for pkg/apierror/apierror.go
+func APIErrorTemplate() []byte {
+	return []byte(`// Package apierror writes the errors of the http handlers as RFC 7807 problem details
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+
+	"{{ .ModuleName }}/pkg/repository"
+
+	"github.com/go-playground/validator"
+)
+
+// ContentType is the media type of the problem details responses
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response, it is an error so that it can be returned as is
+type Problem struct {
+	// Type is a URI identifying the problem type, about:blank means the problem is described by the status code
+	Type   string ` + "`" + `json:"type"` + "`" + `
+	Title  string ` + "`" + `json:"title"` + "`" + `
+	Status int    ` + "`" + `json:"status"` + "`" + `
+	Detail string ` + "`" + `json:"detail,omitempty"` + "`" + `
+	// Instance is the path of the request the problem occurred on
+	Instance string ` + "`" + `json:"instance,omitempty"` + "`" + `
+	// InvalidParams are the request fields which failed the validation
+	InvalidParams []InvalidParam ` + "`" + `json:"invalid-params,omitempty"` + "`" + `
+}
+
+// InvalidParam is a request field which failed the validation along with the reasons
+type InvalidParam struct {
+	Name    string   ` + "`" + `json:"name"` + "`" + `
+	Reasons []string ` + "`" + `json:"reasons"` + "`" + `
+}
+
+// New returns the problem of the status code with the detail
+func New(status int, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// BadRequest returns the 400 problem with the detail, e.g. for a malformed request body
+func BadRequest(detail string) *Problem {
+	return New(http.StatusBadRequest, detail)
+}
+
+// Error returns the detail of the problem or its title if there is no detail
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Detail
+}
+
+// From maps the error to its problem, problems are returned as is, validation errors and repository.ReferenceError
+// are 400 with the invalid fields, repository.ErrInvalidListOptions is 400, repository.ErrNotFound is 404,
+// repository.ErrConflict, along with the repository.ReferencedError of the deletes, is 409 and any other error is 500
+// without details
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p = BadRequest("the request has invalid fields")
+		index := map[string]int{}
+		for _, fe := range validationErrs {
+			i, ok := index[fe.Field()]
+			if !ok {
+				i = len(p.InvalidParams)
+				index[fe.Field()] = i
+				p.InvalidParams = append(p.InvalidParams, InvalidParam{Name: fe.Field()})
+			}
+			p.InvalidParams[i].Reasons = append(p.InvalidParams[i].Reasons, reason(fe))
+		}
+		return p
+	}
+
+	var refErr *repository.ReferenceError
+	if errors.As(err, &refErr) {
+		p = BadRequest("the request has invalid fields")
+		param := InvalidParam{Name: refErr.Field, Reasons: []string{"must reference an existing " + refErr.Resource}}
+		p.InvalidParams = append(p.InvalidParams, param)
+		return p
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrInvalidListOptions):
+		return BadRequest(err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		return New(http.StatusNotFound, err.Error())
+	case errors.Is(err, repository.ErrConflict):
+		return New(http.StatusConflict, err.Error())
+	}
+	return New(http.StatusInternalServerError, "")
+}
+
+// Write writes the error as problem details response, the unexpected errors mapped to 500 are logged
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	problem := From(err)
+	if problem != err && problem.Status >= http.StatusInternalServerError {
+		log.Printf("error serving %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	p := *problem
+	p.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Println("error writing problem response:", err)
+	}
+}
+
+// reason returns the human readable reason of the failed validation
+func reason(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", fe.Param())
+	case "regex":
+		return fmt.Sprintf("must match %s", fe.Param())
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed the %s=%s validation", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed the %s validation", fe.Tag())
+}
+
+`)
+}
@@ -0,0 +1,1089 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// AuthTemplate returns template for pkg/auth/auth.go
+func AuthTemplate() []byte {
+	return []byte(`// Package auth authenticates the requests with {{ .AuthMethodNames }} and authorizes them with the scopes and roles
+// granted to the credentials and the ownership of the resources
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"{{ .ModuleName }}/pkg/apierror"
+
+	"github.com/golang-jwt/jwt/v5"
+{{- if .GRPC }}
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+{{- end }}
+)
+
+var (
+	// ErrNoCredentials is returned by a Method when the request has none of its credentials
+	ErrNoCredentials = errors.New("the request has no credentials")
+	// ErrInvalidCredentials is wrapped by the errors of the credentials which are rejected
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// RateLimitError is returned by a Method when the requests of the client exceed its rate limit
+type RateLimitError struct {
+	// RetryAfter is the duration after which the request would be allowed
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return "the rate limit of the client is exceeded"
+}
+
+// Method authenticates the requests with one kind of credentials, header returns the value of the http header
+// or the grpc metadata with the name
+type Method interface {
+	Authenticate(ctx context.Context, header func(name string) string) (*Claims, error)
+}
+
+// Claims are the claims of the authenticated credentials, api keys are authenticated with the subject, scopes
+// and roles of the key
+type Claims struct {
+	jwt.RegisteredClaims
+	// Scope is the space separated list of the scopes granted to the credentials
+	Scope string ` + "`" + `json:"scope,omitempty"` + "`" + `
+	// Roles are the roles granted to the subject of the credentials
+	Roles []string ` + "`" + `json:"roles,omitempty"` + "`" + `
+}
+
+// HasScope reports whether the scope is granted to the credentials
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyRole reports whether any of the roles is granted to the credentials
+func (c *Claims) HasAnyRole(roles ...string) bool {
+	for _, granted := range c.Roles {
+		for _, role := range roles {
+			if granted == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimsKey is the context key of the claims
+type claimsKey struct{}
+
+// WithClaims returns the context with the claims of the authenticated request
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated request
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// authenticate authenticates the request with the first method whose credentials it has
+func authenticate(ctx context.Context, header func(name string) string, methods []Method) (*Claims, error) {
+	for _, m := range methods {
+		claims, err := m.Authenticate(ctx, header)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return claims, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// Middleware authenticates the requests with the first of the methods whose credentials they have, the claims are
+// added to the request context. Requests without valid credentials are rejected with 401 Unauthorized and the
+// clients exceeding their rate limit with 429 Too Many Requests.
+func Middleware(methods ...Method) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := authenticate(r.Context(), r.Header.Get, methods)
+			var rateLimitErr *RateLimitError
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+			case errors.Is(err, ErrNoCredentials), errors.Is(err, ErrInvalidCredentials):
+				unauthorized(w, r, err.Error())
+			case errors.As(err, &rateLimitErr):
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+				apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, err.Error()))
+			default:
+				apierror.Write(w, r, err)
+			}
+		})
+	}
+}
+
+// RequireScopes returns the middleware which responds with 403 Forbidden unless all the scopes are granted to the
+// credentials of the request, it must be applied after Middleware
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				unauthorized(w, r, "the request is not authenticated")
+				return
+			}
+			if missing := missingScopes(claims, scopes); len(missing) > 0 {
+{{- if .HasAuth "jwt" }}
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(` + "`" + `Bearer error="insufficient_scope", scope="%s"` + "`" + `, strings.Join(scopes, " ")))
+{{- end }}
+				apierror.Write(w, r, apierror.New(http.StatusForbidden, "missing scopes "+strings.Join(missing, " ")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRoles returns the middleware which responds with 403 Forbidden unless any of the roles is granted to the
+// credentials of the request, it must be applied after Middleware
+func RequireRoles(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				unauthorized(w, r, "the request is not authenticated")
+				return
+			}
+			if !claims.HasAnyRole(roles...) {
+				apierror.Write(w, r, apierror.New(http.StatusForbidden, "requires one of the roles "+strings.Join(roles, " ")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Subject returns the subject of the authenticated request, it is empty when the request is not authenticated
+func Subject(ctx context.Context) string {
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+// CheckOwner returns a 403 Forbidden problem unless the subject of the authenticated request is the owner
+// of the resource, the action and resource describe the problem, e.g. update and item
+func CheckOwner(ctx context.Context, owner, action, resource string) error {
+	if subject := Subject(ctx); subject == "" || subject != owner {
+		return apierror.New(http.StatusForbidden, fmt.Sprintf("only the owner of the %s may %s it", resource, action))
+	}
+	return nil
+}
+{{- if .GRPC }}
+
+// Rule is what the credentials must be granted to call an rpc, all the scopes and any of the roles
+type Rule struct {
+	Scopes []string
+	Roles  []string
+}
+
+// UnaryServerInterceptor authenticates the rpcs with the first of the methods whose credentials are in the metadata
+// and requires the rule of the rpc, the rpcs of the health service are not authenticated
+func UnaryServerInterceptor(methodRules map[string]Rule, methods ...Method) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		header := func(name string) string {
+			if values := md.Get(name); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+		claims, err := authenticate(ctx, header, methods)
+		var rateLimitErr *RateLimitError
+		switch {
+		case errors.Is(err, ErrNoCredentials), errors.Is(err, ErrInvalidCredentials):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.As(err, &rateLimitErr):
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		rule := methodRules[info.FullMethod]
+		if missing := missingScopes(claims, rule.Scopes); len(missing) > 0 {
+			return nil, status.Error(codes.PermissionDenied, "missing scopes "+strings.Join(missing, " "))
+		}
+		if len(rule.Roles) > 0 && !claims.HasAnyRole(rule.Roles...) {
+			return nil, status.Error(codes.PermissionDenied, "requires one of the roles "+strings.Join(rule.Roles, " "))
+		}
+		return handler(WithClaims(ctx, claims), req)
+	}
+}
+{{- end }}
+
+// unauthorized responds with 401 Unauthorized{{ if .HasAuth "jwt" }} and the Bearer challenge{{ end }}
+func unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+{{- if .HasAuth "jwt" }}
+	w.Header().Set("WWW-Authenticate", "Bearer")
+{{- end }}
+	apierror.Write(w, r, apierror.New(http.StatusUnauthorized, detail))
+}
+
+// missingScopes returns the scopes which are not granted to the credentials
+func missingScopes(claims *Claims, scopes []string) []string {
+	var missing []string
+	for _, scope := range scopes {
+		if !claims.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+`)
+}
+
+// JWTTemplate returns template for pkg/auth/jwt.go
+func JWTTemplate() []byte {
+	return []byte(`package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config configures the verification of the bearer tokens, the key is the JWKS url, the public key or the secret
+// in that order of precedence
+type Config struct {
+	// Issuer is the required iss claim
+	Issuer string
+	// Audience is the audience the tokens must be issued for
+	Audience string
+	// JWKSURL is the url of the json web key set, the keys are refreshed in the background
+	JWKSURL string
+	// PublicKey is the PEM encoded RSA, ECDSA or Ed25519 public key
+	PublicKey string
+	// Secret is the HMAC secret
+	Secret string
+	// Leeway is the allowed clock skew when validating the exp, nbf and iat claims
+	Leeway time.Duration
+}
+
+// Authenticator verifies the bearer tokens of the requests, it is the Method of the Authorization header
+type Authenticator struct {
+	keys   jwt.Keyfunc
+	parser *jwt.Parser
+}
+
+// New returns the authenticator verifying the tokens with the key of the config, the JWKS is refreshed
+// in the background until the context is done
+func New(ctx context.Context, cfg Config) (*Authenticator, error) {
+	a := &Authenticator{}
+	var methods []string
+	switch {
+	case cfg.JWKSURL != "":
+		jwks, err := keyfunc.NewDefaultCtx(ctx, []string{cfg.JWKSURL})
+		if err != nil {
+			return nil, fmt.Errorf("error loading the key set from %s: %w", cfg.JWKSURL, err)
+		}
+		a.keys = jwks.Keyfunc
+		methods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+	case cfg.PublicKey != "":
+		key, keyMethods, err := parsePublicKey(cfg.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = func(*jwt.Token) (interface{}, error) { return key, nil }
+		methods = keyMethods
+	case cfg.Secret != "":
+		secret := []byte(cfg.Secret)
+		a.keys = func(*jwt.Token) (interface{}, error) { return secret, nil }
+		methods = []string{"HS256", "HS384", "HS512"}
+	default:
+		return nil, errors.New("the JWKS url, public key or secret is required to verify the tokens")
+	}
+
+	a.parser = jwt.NewParser(
+		jwt.WithValidMethods(methods),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(cfg.Leeway),
+	)
+	return a, nil
+}
+
+// Verify verifies the signature, issuer, audience and expiry of the token and returns its claims
+func (a *Authenticator) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, a.keys); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Authenticate verifies the bearer token of the Authorization header
+func (a *Authenticator) Authenticate(ctx context.Context, header func(name string) string) (*Claims, error) {
+	token, ok := bearerToken(header("Authorization"))
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	claims, err := a.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w, bearer token: %v", ErrInvalidCredentials, err)
+	}
+	return claims, nil
+}
+
+// Middleware authenticates the requests with the bearer token only, see the Middleware function to accept
+// other credentials as well
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return Middleware(a)(next)
+}
+
+// bearerToken returns the token of the Bearer authorization header
+func bearerToken(header string) (string, bool) {
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+// parsePublicKey parses the PEM encoded public key and returns the signing methods it verifies
+func parsePublicKey(data string) (interface{}, []string, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, nil, errors.New("the public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing the public key: %w", err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return key, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	case *ecdsa.PublicKey:
+		return key, []string{"ES256", "ES384", "ES512"}, nil
+	case ed25519.PublicKey:
+		return key, []string{"EdDSA"}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported public key type %T", key)
+}
+
+`)
+}
+
+// APIKeyTemplate returns template for pkg/auth/apikey.go
+func APIKeyTemplate() []byte {
+	return []byte(`package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrUnknownAPIKey is returned by the key stores for the hashes of keys they don't store
+var ErrUnknownAPIKey = errors.New("unknown api key")
+
+// APIKey is a hashed api key of a client, the key itself is never stored
+type APIKey struct {
+	// ID identifies the client, it is the subject of the claims of the requests authenticated with the key
+	ID string ` + "`" + `json:"id"` + "`" + `
+	// Hash is the hex encoded SHA-256 hash of the key, see HashAPIKey
+	Hash   string   ` + "`" + `json:"hash"` + "`" + `
+	Scopes []string ` + "`" + `json:"scopes,omitempty"` + "`" + `
+	Roles  []string ` + "`" + `json:"roles,omitempty"` + "`" + `
+	// ExpiresAt is when the key stops being accepted, it is set on the previous key of the client when it is rotated
+	ExpiresAt *time.Time ` + "`" + `json:"expiresAt,omitempty"` + "`" + `
+	// RateLimit is the number of requests per second allowed for the client, the default rate limit is used if it is 0
+	RateLimit float64 ` + "`" + `json:"rateLimit,omitempty"` + "`" + `
+	// Burst is the number of requests allowed at once above the rate limit
+	Burst int ` + "`" + `json:"burst,omitempty"` + "`" + `
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of the key, the keys are random so a fast hash is enough
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyStore looks up the api keys by their hash, implement it to load the keys from a database
+type KeyStore interface {
+	// Lookup returns the key with the hash or ErrUnknownAPIKey
+	Lookup(ctx context.Context, hash string) (APIKey, error)
+}
+
+// MemoryKeyStore is a KeyStore of the keys it is set with, e.g. in tests
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+// NewMemoryKeyStore returns the key store of the keys
+func NewMemoryKeyStore(keys ...APIKey) *MemoryKeyStore {
+	s := &MemoryKeyStore{}
+	s.Set(keys...)
+	return s
+}
+
+// Set replaces the keys of the store
+func (s *MemoryKeyStore) Set(keys ...APIKey) {
+	byHash := make(map[string]APIKey, len(keys))
+	for _, k := range keys {
+		byHash[strings.ToLower(k.Hash)] = k
+	}
+	s.mu.Lock()
+	s.keys = byHash
+	s.mu.Unlock()
+}
+
+// Lookup returns the key with the hash or ErrUnknownAPIKey
+func (s *MemoryKeyStore) Lookup(ctx context.Context, hash string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, ok := s.keys[hash]
+	if !ok {
+		return APIKey{}, ErrUnknownAPIKey
+	}
+	return k, nil
+}
+
+// FileKeyStore is a KeyStore of the keys of a json file holding an array of APIKey, the file is read again when
+// it changes so the keys are added, rotated and revoked without restarting the service
+type FileKeyStore struct {
+	*MemoryKeyStore
+	path     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewFileKeyStore loads the keys of the file, the file is checked for changes at most once every interval
+func NewFileKeyStore(path string, interval time.Duration) (*FileKeyStore, error) {
+	s := &FileKeyStore{MemoryKeyStore: NewMemoryKeyStore(), path: path, interval: interval}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup returns the key with the hash or ErrUnknownAPIKey
+func (s *FileKeyStore) Lookup(ctx context.Context, hash string) (APIKey, error) {
+	// keep the previous keys if the file is being rewritten and can't be loaded yet
+	if err := s.reload(); err != nil {
+		log.Println("error reloading the api keys from", s.path, ":", err)
+	}
+	return s.MemoryKeyStore.Lookup(ctx, hash)
+}
+
+// reload loads the keys again if the modification time of the file changed
+func (s *FileKeyStore) reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastCheck.IsZero() && time.Since(s.lastCheck) < s.interval {
+		return nil
+	}
+	s.lastCheck = time.Now()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var keys []APIKey
+	if err = json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("error parsing the api keys: %w", err)
+	}
+	for i, k := range keys {
+		if k.ID == "" || len(k.Hash) != sha256.Size*2 {
+			return fmt.Errorf("api key %d needs the id and the hex encoded SHA-256 hash", i)
+		}
+	}
+	s.Set(keys...)
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// APIKeyConfig configures the authentication with api keys
+type APIKeyConfig struct {
+	// Header is the request header the key is read from, e.g. X-API-Key
+	Header string
+	Store  KeyStore
+	// RateLimit is the number of requests per second allowed for the clients whose keys have no rate limit,
+	// 0 disables it
+	RateLimit float64
+	// Burst is the number of requests allowed at once above the rate limit
+	Burst int
+}
+
+// APIKeyAuthenticator authenticates the requests with the api key of the header, it is the Method of the api keys
+type APIKeyAuthenticator struct {
+	cfg APIKeyConfig
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewAPIKeyAuthenticator returns the authenticator of the api keys of the store
+func NewAPIKeyAuthenticator(cfg APIKeyConfig) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{cfg: cfg, limiters: map[string]*rate.Limiter{}}
+}
+
+// Authenticate looks up the hash of the api key of the header, the claims have the id of the key as subject
+// along with its scopes and roles
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, header func(name string) string) (*Claims, error) {
+	key := header(a.cfg.Header)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	k, err := a.cfg.Store.Lookup(ctx, HashAPIKey(key))
+	if errors.Is(err, ErrUnknownAPIKey) {
+		return nil, fmt.Errorf("%w, unknown api key", ErrInvalidCredentials)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return nil, fmt.Errorf("%w, the api key is expired", ErrInvalidCredentials)
+	}
+	if err = a.allow(k); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{Scope: strings.Join(k.Scopes, " "), Roles: k.Roles}
+	claims.Subject = k.ID
+	return claims, nil
+}
+
+// allow takes a token of the bucket of the client, the keys of a client share its bucket so rotating the key
+// doesn't reset the limit
+func (a *APIKeyAuthenticator) allow(k APIKey) error {
+	limit, burst := k.RateLimit, k.Burst
+	if limit == 0 {
+		limit, burst = a.cfg.RateLimit, a.cfg.Burst
+	}
+	if limit <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(limit))
+	}
+
+	a.mu.Lock()
+	limiter, ok := a.limiters[k.ID]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit), burst)
+		a.limiters[k.ID] = limiter
+	} else if limiter.Limit() != rate.Limit(limit) || limiter.Burst() != burst {
+		// the limits of the key file changed
+		limiter.SetLimit(rate.Limit(limit))
+		limiter.SetBurst(burst)
+	}
+	a.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return &RateLimitError{RetryAfter: delay}
+	}
+	return nil
+}
+
+`)
+}
+
+// AuthTestTemplate returns template for pkg/auth/authtest/authtest.go
+func AuthTestTemplate() []byte {
+	return []byte(`// Package authtest issues tokens signed with a locally generated key to test the authenticated endpoints
+// without an identity provider
+package authtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"{{ .ModuleName }}/pkg/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyID is the kid of the generated key in the token header and the key set
+const keyID = "authtest"
+
+// Issuer issues tokens signed with a generated ECDSA P-256 key
+type Issuer struct {
+	// Issuer and Audience are the iss and aud claims of the issued tokens
+	Issuer   string
+	Audience string
+	key      *ecdsa.PrivateKey
+}
+
+// NewIssuer returns the issuer of the tokens with the iss and aud claims
+func NewIssuer(issuer, audience string) (*Issuer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{Issuer: issuer, Audience: audience, key: key}, nil
+}
+
+// Config returns the auth config verifying the issued tokens with the PEM encoded public key
+func (i *Issuer) Config() (auth.Config, error) {
+	der, err := x509.MarshalPKIXPublicKey(&i.key.PublicKey)
+	if err != nil {
+		return auth.Config{}, err
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return auth.Config{Issuer: i.Issuer, Audience: i.Audience, PublicKey: string(publicKey)}, nil
+}
+
+// Token returns a token of the subject granted the scopes which expires after the ttl
+func (i *Issuer) Token(subject string, ttl time.Duration, scopes ...string) (string, error) {
+	return i.Sign(auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+		Scope: strings.Join(scopes, " "),
+	})
+}
+
+// Sign returns a token of the claims, e.g. with roles, the iss, aud and iat claims are set by the issuer and the
+// token expires after an hour unless exp is set
+func (i *Issuer) Sign(claims auth.Claims) (string, error) {
+	now := time.Now()
+	claims.Issuer = i.Issuer
+	claims.Audience = jwt.ClaimStrings{i.Audience}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Hour))
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = keyID
+	return token.SignedString(i.key)
+}
+
+// JWKSServer returns the server serving the key set of the generated key, its URL is the JWKS url of the auth config,
+// the server must be closed after the test
+func (i *Issuer) JWKSServer() (*httptest.Server, error) {
+	point, err := i.key.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	// the uncompressed point is 0x04 followed by the x and y coordinates
+	size := (len(point) - 1) / 2
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{"{{"}}
+			"kty": "EC",
+			"crv": "P-256",
+			"kid": keyID,
+			"alg": "ES256",
+			"use": "sig",
+			"x":   base64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+			"y":   base64.RawURLEncoding.EncodeToString(point[1+size:]),
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jwks)
+	})), nil
+}
+
+`)
+}
+
+// AuthMiddlewareTestTemplate returns template for pkg/auth/auth_test.go
+func AuthMiddlewareTestTemplate() []byte {
+	return []byte(`package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+{{- if .HasAuth "apikey" }}
+	"os"
+	"path/filepath"
+{{- end }}
+	"strings"
+	"testing"
+	"time"
+
+	"{{ .ModuleName }}/pkg/apierror"
+	"{{ .ModuleName }}/pkg/auth"
+{{- if .HasAuth "jwt" }}
+	"{{ .ModuleName }}/pkg/auth/authtest"
+{{- end }}
+)
+
+// headerMethod authenticates the requests with the subject of the X-Subject header, which is granted the scopes
+// of the X-Scope header and the roles of the X-Roles header, the subject invalid is rejected
+type headerMethod struct{}
+
+func (headerMethod) Authenticate(_ context.Context, header func(name string) string) (*auth.Claims, error) {
+	switch subject := header("X-Subject"); subject {
+	case "":
+		return nil, auth.ErrNoCredentials
+	case "invalid":
+		return nil, fmt.Errorf("%w, rejected subject", auth.ErrInvalidCredentials)
+	default:
+		claims := &auth.Claims{Scope: header("X-Scope"), Roles: strings.Fields(header("X-Roles"))}
+		claims.Subject = subject
+		return claims, nil
+	}
+}
+
+// subject responds with the subject of the authenticated request
+var subject = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(auth.Subject(r.Context())))
+})
+
+// serve serves the request with the headers with h
+func serve(h http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	h := auth.Middleware(headerMethod{})(auth.RequireScopes("items:write")(auth.RequireRoles("admin", "owner")(subject)))
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "no credentials", want: http.StatusUnauthorized},
+		{name: "invalid credentials", headers: map[string]string{"X-Subject": "invalid"}, want: http.StatusUnauthorized},
+		{name: "missing scope", headers: map[string]string{"X-Subject": "alice", "X-Scope": "items:read", "X-Roles": "admin"}, want: http.StatusForbidden},
+		{name: "missing role", headers: map[string]string{"X-Subject": "alice", "X-Scope": "items:read items:write", "X-Roles": "viewer"}, want: http.StatusForbidden},
+		{name: "authorized", headers: map[string]string{"X-Subject": "alice", "X-Scope": "items:read items:write", "X-Roles": "viewer owner"}, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, tt.headers)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d\n%s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusOK && rec.Body.String() != "alice" {
+				t.Errorf("subject = %q, want alice", rec.Body.String())
+			}
+			if tt.want != http.StatusOK && !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/problem+json") {
+				t.Errorf("Content-Type = %q, want the problem details", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestRequireScopesWithoutMiddleware(t *testing.T) {
+	if rec := serve(auth.RequireScopes("items:write")(subject), nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := serve(auth.RequireRoles("admin")(subject), nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestCheckOwner(t *testing.T) {
+	claims := &auth.Claims{}
+	claims.Subject = "alice"
+	ctx := auth.WithClaims(context.Background(), claims)
+
+	if err := auth.CheckOwner(ctx, "alice", "update", "item"); err != nil {
+		t.Errorf("CheckOwner() of the owner error = %v", err)
+	}
+	for _, c := range []struct {
+		name  string
+		ctx   context.Context
+		owner string
+	}{
+		{name: "other owner", ctx: ctx, owner: "bob"},
+		{name: "not authenticated", ctx: context.Background(), owner: ""},
+	} {
+		var problem *apierror.Problem
+		if err := auth.CheckOwner(c.ctx, c.owner, "update", "item"); !errors.As(err, &problem) || problem.Status != http.StatusForbidden {
+			t.Errorf("CheckOwner() of %s error = %v, want 403", c.name, err)
+		}
+	}
+}
+{{- if .HasAuth "jwt" }}
+
+func TestAuthenticator(t *testing.T) {
+	issuer, err := authtest.NewIssuer("https://issuer.test", "{{ .ProjectDirName }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := authtest.NewIssuer("https://issuer.test", "{{ .ProjectDirName }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := issuer.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := issuer.JWKSServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jwks.Close()
+
+	valid, _ := issuer.Token("alice", time.Hour, "items:read", "items:write")
+	expired, _ := issuer.Token("alice", -time.Hour)
+	otherKey, _ := other.Token("alice", time.Hour)
+	// the issuer of another audience signs with the same key
+	audience := *issuer
+	audience.Audience = "other"
+	otherAudience, _ := audience.Token("alice", time.Hour)
+
+	for name, cfg := range map[string]auth.Config{"public key": cfg, "jwks": {Issuer: cfg.Issuer, Audience: cfg.Audience, JWKSURL: jwks.URL}} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			a, err := auth.New(ctx, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := a.Verify(valid)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.Subject != "alice" || !claims.HasScope("items:write") || claims.HasScope("items") {
+				t.Errorf("Verify() = %+v, want the subject and the scopes of the token", claims)
+			}
+
+			tests := []struct {
+				name          string
+				authorization string
+				wantErr       error
+			}{
+				{name: "valid", authorization: "Bearer " + valid},
+				{name: "lower case scheme", authorization: "bearer " + valid},
+				{name: "no token", wantErr: auth.ErrNoCredentials},
+				{name: "basic", authorization: "Basic YWxpY2U6c2VjcmV0", wantErr: auth.ErrNoCredentials},
+				{name: "malformed", authorization: "Bearer token", wantErr: auth.ErrInvalidCredentials},
+				{name: "expired", authorization: "Bearer " + expired, wantErr: auth.ErrInvalidCredentials},
+				{name: "other key", authorization: "Bearer " + otherKey, wantErr: auth.ErrInvalidCredentials},
+				{name: "other audience", authorization: "Bearer " + otherAudience, wantErr: auth.ErrInvalidCredentials},
+			}
+			for _, tt := range tests {
+				_, err := a.Authenticate(ctx, func(string) string { return tt.authorization })
+				if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("Authenticate() of the %s token error = %v, want %v", tt.name, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticatorMiddleware(t *testing.T) {
+	issuer, err := authtest.NewIssuer("https://issuer.test", "{{ .ProjectDirName }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := issuer.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := auth.New(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.Token("alice", time.Hour, "items:read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := a.Middleware(auth.RequireScopes("items:write")(subject))
+
+	rec := serve(h, nil)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("status = %d, WWW-Authenticate %q, want 401 with the Bearer challenge", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+	rec = serve(h, map[string]string{"Authorization": "Bearer " + token})
+	if want := ` + "`" + `Bearer error="insufficient_scope", scope="items:write"` + "`" + `; rec.Code != http.StatusForbidden || rec.Header().Get("WWW-Authenticate") != want {
+		t.Errorf("status = %d, WWW-Authenticate %q, want 403 with %s", rec.Code, rec.Header().Get("WWW-Authenticate"), want)
+	}
+}
+
+func TestNewWithoutKey(t *testing.T) {
+	if _, err := auth.New(context.Background(), auth.Config{Issuer: "https://issuer.test"}); err == nil {
+		t.Error("New() without the key succeeded, want an error")
+	}
+	if _, err := auth.New(context.Background(), auth.Config{PublicKey: "not pem"}); err == nil {
+		t.Error("New() with an invalid public key succeeded, want an error")
+	}
+}
+{{- end }}
+{{- if .HasAuth "apikey" }}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	expiredAt := time.Now().Add(-time.Minute)
+	store := auth.NewMemoryKeyStore(
+		auth.APIKey{ID: "billing", Hash: auth.HashAPIKey("billing-key"), Scopes: []string{"items:read"}, Roles: []string{"service"}},
+		auth.APIKey{ID: "billing", Hash: auth.HashAPIKey("rotated-key"), ExpiresAt: &expiredAt},
+	)
+	a := auth.NewAPIKeyAuthenticator(auth.APIKeyConfig{Header: "X-API-Key", Store: store})
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "valid", key: "billing-key"},
+		{name: "no key", wantErr: auth.ErrNoCredentials},
+		{name: "unknown", key: "stolen-key", wantErr: auth.ErrInvalidCredentials},
+		{name: "expired", key: "rotated-key", wantErr: auth.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := a.Authenticate(context.Background(), func(name string) string {
+				if name != "X-API-Key" {
+					return ""
+				}
+				return tt.key
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != "billing" || !claims.HasScope("items:read") || !claims.HasAnyRole("service") {
+				t.Errorf("Authenticate() = %+v, want the id, scopes and roles of the key", claims)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRateLimit(t *testing.T) {
+	store := auth.NewMemoryKeyStore(auth.APIKey{ID: "billing", Hash: auth.HashAPIKey("billing-key")})
+	a := auth.NewAPIKeyAuthenticator(auth.APIKeyConfig{Header: "X-API-Key", Store: store, RateLimit: 0.5, Burst: 1})
+	h := auth.Middleware(a)(subject)
+
+	headers := map[string]string{"X-API-Key": "billing-key"}
+	if rec := serve(h, headers); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	rec := serve(h, headers)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("status = %d, Retry-After %q, want 429 after 2 seconds", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestFileKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	keys := fmt.Sprintf(` + "`" + `[{"id": "billing", "hash": %q, "scopes": ["items:read"]}]` + "`" + `, auth.HashAPIKey("billing-key"))
+	if err := os.WriteFile(path, []byte(keys), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := auth.NewFileKeyStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, err := store.Lookup(context.Background(), auth.HashAPIKey("billing-key")); err != nil || k.ID != "billing" {
+		t.Errorf("Lookup() = %+v, %v, want the billing key", k, err)
+	}
+	if _, err = store.Lookup(context.Background(), auth.HashAPIKey("stolen-key")); !errors.Is(err, auth.ErrUnknownAPIKey) {
+		t.Errorf("Lookup() of an unknown key error = %v, want %v", err, auth.ErrUnknownAPIKey)
+	}
+
+	if err = os.WriteFile(path, []byte(` + "`" + `[{"id": "billing", "hash": "billing-key"}]` + "`" + `), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = auth.NewFileKeyStore(path, 0); err == nil {
+		t.Error("NewFileKeyStore() of a key which isn't hashed succeeded, want an error")
+	}
+}
+{{- end }}
+
+`)
+}
@@ -0,0 +1,571 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// CacheTemplate returns template for pkg/cache/cache.go
+func CacheTemplate() []byte {
+	return []byte(`// Package cache caches the values of the repositories in redis or in the process
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss is returned by Get when the key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores values by key for a time to live
+type Cache interface {
+	// Get returns the value of the key or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the value of the key for ttl, the value doesn't expire if ttl is 0
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the keys, the keys which are not cached are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Redis caches the values in redis, the cache is shared by the instances of the service so the values
+// invalidated by one instance are invalidated for every instance
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis returns a Redis cache using the client, the keys are prefixed with prefix so that services can share
+// a redis, the client of a miniredis server stands in for redis in tests
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Get returns the value of the key or ErrMiss
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set stores the value of the key for ttl
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+// Delete removes the keys
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// LRU caches the values in the process, the least recently used values are evicted when it is full. It is used
+// when there is no redis, every instance of the service then invalidates only the values it cached.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+	// expires is the time the value expires, it doesn't expire if it is zero
+	expires time.Time
+}
+
+// NewLRU returns an LRU cache of at most size values
+func NewLRU(size int) *LRU {
+	return &LRU{size: size, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// Get returns the value of the key or ErrMiss
+func (c *LRU) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	entry := e.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(e)
+		return nil, ErrMiss
+	}
+	c.order.MoveToFront(e)
+	return entry.value, nil
+}
+
+// Set stores the value of the key for ttl, the least recently used value is evicted if the cache is full
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(e)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete removes the keys
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if e, ok := c.entries[key]; ok {
+			c.remove(e)
+		}
+	}
+	return nil
+}
+
+// remove removes the element of an entry, the lock must be held
+func (c *LRU) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.entries, e.Value.(*lruEntry).key)
+}
+
+`)
+}
+
+// CachedRepositoryTemplate returns template for pkg/repository/cache.go
+func CachedRepositoryTemplate() []byte {
+	return []byte(`package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"{{ .ModuleName }}/pkg/cache"
+)
+
+// CacheTTL are the durations the resources are cached for, the reads are not cached if the duration is 0
+type CacheTTL struct {
+	// Get is the ttl of the resources read by Get, they are invalidated when they are updated or deleted
+	Get time.Duration
+	// List is the ttl of the pages read by List, they are invalidated when any resource of the repository is written
+	List time.Duration
+}
+
+// cachedRepository decorates the repository of the model T with a read-through cache, the reads are served from
+// the cache and the writes invalidate it. The errors of the cache are logged and the reads are served by the
+// repository, so the service keeps working while the cache is unavailable.
+type cachedRepository[T any] struct {
+	repo  crudRepository[T]
+	cache cache.Cache
+	ttl   CacheTTL
+	// name prefixes the keys of the resources, e.g. items
+	name string
+	// id returns the id of the resource
+	id func(m *T) string
+}
+
+// newCachedRepository returns the repo decorated with the cache
+func newCachedRepository[T any](repo crudRepository[T], c cache.Cache, ttl CacheTTL, name string, id func(m *T) string) *cachedRepository[T] {
+	return &cachedRepository[T]{repo: repo, cache: c, ttl: ttl, name: name, id: id}
+}
+
+// cachedPage is a page read by List
+type cachedPage[T any] struct {
+	Items []T
+	Page  Page
+}
+
+// List returns the cached page of the options or lists it from the repository and caches it
+func (r *cachedRepository[T]) List(ctx context.Context, opts ListOptions) ([]T, Page, error) {
+	if r.ttl.List <= 0 {
+		return r.repo.List(ctx, opts)
+	}
+	key, err := r.listKey(ctx, opts)
+	if err != nil {
+		log.Println("error getting the list key of", r.name, "from cache:", err)
+		return r.repo.List(ctx, opts)
+	}
+
+	var cached cachedPage[T]
+	if r.get(ctx, key, &cached) {
+		return cached.Items, cached.Page, nil
+	}
+	items, page, err := r.repo.List(ctx, opts)
+	if err != nil {
+		return items, page, err
+	}
+	r.set(ctx, key, cachedPage[T]{Items: items, Page: page}, r.ttl.List)
+	return items, page, nil
+}
+
+// Get returns the cached resource with the id or gets it from the repository and caches it
+func (r *cachedRepository[T]) Get(ctx context.Context, id string) (T, error) {
+	if r.ttl.Get <= 0 {
+		return r.repo.Get(ctx, id)
+	}
+	key := r.name + ":" + id
+
+	var m T
+	if r.get(ctx, key, &m) {
+		return m, nil
+	}
+	m, err := r.repo.Get(ctx, id)
+	if err != nil {
+		return m, err
+	}
+	r.set(ctx, key, m, r.ttl.Get)
+	return m, nil
+}
+
+// Create stores the resource and invalidates the cached pages
+func (r *cachedRepository[T]) Create(ctx context.Context, m *T) error {
+	err := r.repo.Create(ctx, m)
+	r.invalidate(ctx)
+	return err
+}
+
+// Update replaces the resource and invalidates it along with the cached pages
+func (r *cachedRepository[T]) Update(ctx context.Context, m *T) error {
+	err := r.repo.Update(ctx, m)
+	r.invalidate(ctx, r.id(m))
+	return err
+}
+
+// Delete removes the resource and invalidates it along with the cached pages
+func (r *cachedRepository[T]) Delete(ctx context.Context, id string) error {
+	err := r.repo.Delete(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+// listKey returns the key of the page of the options, the key contains the version of the pages which every write
+// changes, so the pages cached before the write are not read anymore and expire
+func (r *cachedRepository[T]) listKey(ctx context.Context, opts ListOptions) (string, error) {
+	version, err := r.cache.Get(ctx, r.name+":version")
+	if errors.Is(err, cache.ErrMiss) {
+		version = []byte(NewID())
+		err = r.cache.Set(ctx, r.name+":version", version, 0)
+	}
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return r.name + ":list:" + string(version) + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// invalidate removes the resources with the ids from the cache and changes the version of the pages, it is called
+// after every write, even a failed one as it may have been applied, and even if the request is canceled
+func (r *cachedRepository[T]) invalidate(ctx context.Context, ids ...string) {
+	ctx = context.WithoutCancel(ctx)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = r.name + ":" + id
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		log.Println("error invalidating", keys, "in cache:", err)
+	}
+	if err := r.cache.Set(ctx, r.name+":version", []byte(NewID()), 0); err != nil {
+		log.Println("error invalidating the pages of", r.name, "in cache:", err)
+	}
+}
+
+// get unmarshals the cached value of the key into v and reports whether it was cached
+func (r *cachedRepository[T]) get(ctx context.Context, key string, v interface{}) bool {
+	data, err := r.cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			log.Println("error getting", key, "from cache:", err)
+		}
+		return false
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		log.Println("error unmarshalling", key, "from cache:", err)
+		return false
+	}
+	return true
+}
+
+// set caches v as the value of the key for ttl
+func (r *cachedRepository[T]) set(ctx context.Context, key string, v interface{}, ttl time.Duration) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = r.cache.Set(ctx, key, data, ttl)
+	}
+	if err != nil {
+		log.Println("error setting", key, "in cache:", err)
+	}
+}
+
+`)
+}
+
+// CacheTestTemplate returns template for pkg/cache/cache_test.go
+func CacheTestTemplate() []byte {
+	return []byte(`package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get() of a missing key error = %v, want %v", err, ErrMiss)
+	}
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if value, err := c.Get(ctx, "a"); err != nil || string(value) != "a" {
+		t.Fatalf("Get() = %q, %v, want a", value, err)
+	}
+
+	// b is the least recently used value since a was read, it is evicted
+	if err := c.Set(ctx, "c", []byte("c"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get() of the evicted key error = %v, want %v", err, ErrMiss)
+	}
+	for _, key := range []string{"a", "c"} {
+		if value, err := c.Get(ctx, key); err != nil || string(value) != key {
+			t.Errorf("Get() = %q, %v, want %s", value, err, key)
+		}
+	}
+
+	if err := c.Set(ctx, "a", []byte("A"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := c.Get(ctx, "a"); err != nil || string(value) != "A" {
+		t.Errorf("Get() of the replaced value = %q, %v, want A", value, err)
+	}
+	if err := c.Delete(ctx, "a", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get() of the deleted key error = %v, want %v", err, ErrMiss)
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	if err := c.Set(ctx, "short", []byte("1"), 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "long", []byte("2"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, err := c.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get() of the expired key error = %v, want %v", err, ErrMiss)
+	}
+	if _, err := c.Get(ctx, "long"); err != nil {
+		t.Errorf("Get() of the key which didn't expire error = %v", err)
+	}
+}
+
+`)
+}
+
+// CachedRepositoryTestTemplate returns template for pkg/repository/cache_test.go
+func CachedRepositoryTestTemplate() []byte {
+	return []byte(`package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"{{ .ModuleName }}/pkg/cache"
+)
+
+// book is the model of the tests
+type book struct {
+	ID    string
+	Title string
+}
+
+// bookRepository keeps the books in a map and counts the reads
+type bookRepository struct {
+	books map[string]book
+	reads int
+}
+
+func (r *bookRepository) List(context.Context, ListOptions) ([]book, Page, error) {
+	r.reads++
+	var books []book
+	for _, b := range r.books {
+		books = append(books, b)
+	}
+	return books, Page{Total: len(books)}, nil
+}
+
+func (r *bookRepository) Get(_ context.Context, id string) (book, error) {
+	r.reads++
+	if b, ok := r.books[id]; ok {
+		return b, nil
+	}
+	return book{}, ErrNotFound
+}
+
+func (r *bookRepository) Create(_ context.Context, m *book) error {
+	r.books[m.ID] = *m
+	return nil
+}
+
+func (r *bookRepository) Update(_ context.Context, m *book) error {
+	if _, ok := r.books[m.ID]; !ok {
+		return ErrNotFound
+	}
+	r.books[m.ID] = *m
+	return nil
+}
+
+func (r *bookRepository) Delete(_ context.Context, id string) error {
+	if _, ok := r.books[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.books, id)
+	return nil
+}
+
+func newBookRepository(ttl CacheTTL) (*cachedRepository[book], *bookRepository) {
+	repo := &bookRepository{books: map[string]book{"1": {ID: "1", Title: "Dune"}}}
+	return newCachedRepository[book](repo, cache.NewLRU(100), ttl, "books", func(m *book) string { return m.ID }), repo
+}
+
+func TestCachedRepositoryGet(t *testing.T) {
+	ctx := context.Background()
+	cached, repo := newBookRepository(CacheTTL{Get: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if b, err := cached.Get(ctx, "1"); err != nil || b.Title != "Dune" {
+			t.Fatalf("Get() = %+v, %v, want Dune", b, err)
+		}
+	}
+	if repo.reads != 1 {
+		t.Errorf("repository read %d times, want the second Get served by the cache", repo.reads)
+	}
+
+	if err := cached.Update(ctx, &book{ID: "1", Title: "Dune Messiah"}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := cached.Get(ctx, "1"); err != nil || b.Title != "Dune Messiah" {
+		t.Errorf("Get() after Update() = %+v, %v, want the updated book", b, err)
+	}
+
+	if err := cached.Delete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cached.Get(ctx, "1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+	}
+	// the missing books are not cached
+	repo.books["1"] = book{ID: "1", Title: "Dune"}
+	if _, err := cached.Get(ctx, "1"); err != nil {
+		t.Errorf("Get() of the book created again error = %v", err)
+	}
+}
+
+func TestCachedRepositoryList(t *testing.T) {
+	ctx := context.Background()
+	cached, repo := newBookRepository(CacheTTL{List: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if books, page, err := cached.List(ctx, ListOptions{Limit: 10}); err != nil || len(books) != 1 || page.Total != 1 {
+			t.Fatalf("List() = %+v, %+v, %v, want the book", books, page, err)
+		}
+	}
+	if repo.reads != 1 {
+		t.Errorf("repository read %d times, want the second List served by the cache", repo.reads)
+	}
+	if _, _, err := cached.List(ctx, ListOptions{Limit: 5}); err != nil || repo.reads != 2 {
+		t.Errorf("List() of other options read the repository %d times, %v, want them cached apart", repo.reads, err)
+	}
+
+	// every write invalidates the pages
+	if err := cached.Create(ctx, &book{ID: "2", Title: "Emma"}); err != nil {
+		t.Fatal(err)
+	}
+	if books, page, err := cached.List(ctx, ListOptions{Limit: 10}); err != nil || len(books) != 2 || page.Total != 2 {
+		t.Errorf("List() after Create() = %+v, %+v, %v, want both books", books, page, err)
+	}
+}
+
+func TestCachedRepositoryWithoutTTL(t *testing.T) {
+	ctx := context.Background()
+	cached, repo := newBookRepository(CacheTTL{})
+
+	for i := 0; i < 2; i++ {
+		if _, err := cached.Get(ctx, "1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := cached.List(ctx, ListOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if repo.reads != 4 {
+		t.Errorf("repository read %d times, want every read served by the repository", repo.reads)
+	}
+}
+
+`)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// GitHubWorkflowTemplate returns template for .github/workflows/ci.yaml
+func GitHubWorkflowTemplate() []byte {
+	return []byte(`name: ci
+
+on:
+  push:
+    branches: [ main ]
+  pull_request:
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+      - name: vet
+        run: go vet ./...
+      - name: test
+        run: go test -race -cover ./...
+      - name: lint
+        uses: golangci/golangci-lint-action@v6
+        with:
+          version: latest
+{{- if .CreateHelmChart }}
+
+  helm-lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: azure/setup-helm@v4
+      - name: lint chart
+        run: helm lint charts/{{ .ProjectDirName }}
+{{- end }}
+
+  docker-build:
+    runs-on: ubuntu-latest
+    needs: test
+    steps:
+      - uses: actions/checkout@v4
+      - uses: docker/setup-buildx-action@v3
+      - name: build image
+        uses: docker/build-push-action@v6
+        with:
+          context: .
+          push: false
+          build-args: VERSION=${{"{{"}} github.sha }}
+          tags: {{ .ProjectDirName }}:${{"{{"}} github.sha }}
+          cache-from: type=gha
+          cache-to: type=gha,mode=max
+`)
+}
+
+// GitLabCITemplate returns template for .gitlab-ci.yml
+func GitLabCITemplate() []byte {
+	return []byte(`stages:
+  - test
+  - build
+
+variables:
+  GOPATH: $CI_PROJECT_DIR/.go
+
+.go:
+  image: golang:{{ .GoVersion }}
+  cache:
+    key:
+      files:
+        - go.sum
+    paths:
+      - .go/pkg/mod/
+
+vet:
+  extends: .go
+  stage: test
+  script:
+    - go vet ./...
+
+test:
+  extends: .go
+  stage: test
+  script:
+    - go test -race -cover ./...
+
+lint:
+  stage: test
+  image: golangci/golangci-lint:latest
+  script:
+    - golangci-lint run ./...
+{{- if .CreateHelmChart }}
+
+helm-lint:
+  stage: test
+  image:
+    name: alpine/helm:latest
+    entrypoint: [ "" ]
+  script:
+    - helm lint charts/{{ .ProjectDirName }}
+{{- end }}
+
+docker-build:
+  stage: build
+  image: docker:27
+  services:
+    - docker:27-dind
+  variables:
+    DOCKER_BUILDKIT: "1"
+  script:
+    - docker build --build-arg VERSION=$CI_COMMIT_SHORT_SHA -t {{ .ProjectDirName }}:$CI_COMMIT_SHORT_SHA .
+`)
+}
@@ -0,0 +1,655 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// ClientTemplate returns template for client/client.go
+func ClientTemplate() []byte {
+	return []byte(`// Package client is the typed go client of the {{ .ProjectDirName }} api, the resources are served by the
+// clients returned by Client, e.g. c.Items().Get(ctx, id)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client sends the requests to the api, it is safe for concurrent use
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	headers    http.Header
+	auth       func(ctx context.Context, r *http.Request) error
+
+	retries    int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Option configures the Client
+type Option func(c *Client)
+
+// WithHTTPClient sends the requests with the http client, e.g. with a custom transport
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout limits the duration of every attempt of a request, the deadline of the context limits all of them
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries retries the idempotent requests which failed to connect or whose status is 429, 502, 503 or 504 up
+// to retries times, with jittered exponential backoff from minBackoff to maxBackoff unless the Retry-After header
+// of the response says otherwise
+func WithRetries(retries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries, c.minBackoff, c.maxBackoff = retries, minBackoff, maxBackoff
+	}
+}
+
+// WithHeader sets the header on every request
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(name, value)
+	}
+}
+
+// WithAuth authenticates every request with the function, e.g. to set a token which is refreshed
+func WithAuth(auth func(ctx context.Context, r *http.Request) error) Option {
+	return func(c *Client) {
+		c.auth = auth
+	}
+}
+{{- if .HasAuth "jwt" }}
+
+// WithBearerToken authenticates every request with the bearer token
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+{{- end }}
+{{- if .HasAuth "apikey" }}
+
+// WithAPIKey authenticates every request with the api key, in the X-API-Key header unless the api is configured
+// with another API_KEY_HEADER
+func WithAPIKey(key string) Option {
+	return WithHeader("X-API-Key", key)
+}
+{{- end }}
+
+// New returns the Client of the api served at the base url, e.g. http://{{ .ProjectDirName }}:8080, the requests
+// time out after 30s and are retried 3 times by default
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url %q: %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: the scheme must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		headers:    http.Header{"User-Agent": {"{{ .ProjectDirName }}-go-client"}},
+		retries:    3,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// ListOptions are the pagination, filter and sort query parameters of the list requests
+type ListOptions struct {
+	// Limit is the maximum number of items listed
+	Limit int
+	// Offset skips the first items, it is ignored when Cursor is set
+	Offset int
+	// Cursor is the NextCursor of the previous page listed with the same filters and sort
+	Cursor string
+	// Sort orders the items by the fields in order, a - prefix sorts in descending order, e.g. -price
+	Sort []string
+	// Filters are the filters of the fields, e.g. {"status": {"active"}, "price_gt": {"10"}}
+	Filters url.Values
+}
+
+// query returns the query parameters of the options
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	for name, values := range o.Filters {
+		q[name] = append([]string(nil), values...)
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	if len(o.Sort) > 0 {
+		q.Set("sort", strings.Join(o.Sort, ","))
+	}
+	return q
+}
+
+// Page is a listed page of items
+type Page[T any] struct {
+	Items []T ` + "`" + `json:"items"` + "`" + `
+	// NextCursor is the Cursor of the next page, it is empty on the last page
+	NextCursor string ` + "`" + `json:"nextCursor,omitempty"` + "`" + `
+	// Total is the number of items matching the filters
+	Total int ` + "`" + `json:"total"` + "`" + `
+}
+
+// do sends the request with the json of in as body and decodes the json response into out, the error responses
+// are returned as *Problem
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	// only the idempotent requests are retried, e.g. the resources of a POST may have been created before the
+	// response failed
+	retries := c.retries
+	if !idempotent(method) {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, u.String(), body)
+		if err == nil && resp.StatusCode < 300 {
+			if out != nil {
+				err = json.NewDecoder(resp.Body).Decode(out)
+			}
+			resp.Body.Close()
+			if err != nil {
+				return fmt.Errorf("error decoding the response of %s %s: %w", method, path, err)
+			}
+			return nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			problem := readProblem(resp)
+			if attempt >= retries || !retryable(resp.StatusCode) {
+				return problem
+			}
+			err, wait = problem, retryAfter(resp)
+		} else if attempt >= retries || ctx.Err() != nil {
+			return err
+		}
+		if wait <= 0 {
+			wait = c.backoff(attempt)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// send sends a single attempt of the request, the body is read from the start on every attempt
+func (c *Client) send(ctx context.Context, method, u string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		if err = c.auth(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	return c.httpClient.Do(req)
+}
+
+// backoff returns the jittered exponential backoff after the attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.minBackoff << attempt
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// idempotent reports whether sending the request of the method more than once has the effect of sending it once
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether the request failed with a status which may succeed when it is retried
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the wait of the Retry-After header in seconds of the response, 0 if there is none
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// readProblem reads the problem details of the error response, the responses which are not problem details, e.g.
+// of a proxy, are described by their status
+func readProblem(resp *http.Response) *Problem {
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	p := &Problem{}
+	if json.Unmarshal(data, p) != nil || p.Status == 0 {
+		p = &Problem{Type: "about:blank", Title: http.StatusText(resp.StatusCode), Status: resp.StatusCode}
+	}
+	p.Status = resp.StatusCode
+	return p
+}
+
+`)
+}
+
+// ClientErrorsTemplate returns template for client/errors.go
+func ClientErrorsTemplate() []byte {
+	return []byte(`package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// the errors the problems of the statuses are, e.g. errors.Is(err, client.ErrNotFound)
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrServer          = errors.New("server error")
+)
+
+// Problem is the RFC 7807 problem details error response of the api, it mirrors apierror.Problem
+type Problem struct {
+	// Type is a URI identifying the problem type, about:blank means the problem is described by the status code
+	Type   string ` + "`" + `json:"type"` + "`" + `
+	Title  string ` + "`" + `json:"title"` + "`" + `
+	Status int    ` + "`" + `json:"status"` + "`" + `
+	Detail string ` + "`" + `json:"detail,omitempty"` + "`" + `
+	// Instance is the path of the request the problem occurred on
+	Instance string ` + "`" + `json:"instance,omitempty"` + "`" + `
+	// InvalidParams are the request fields which failed the validation
+	InvalidParams []InvalidParam ` + "`" + `json:"invalid-params,omitempty"` + "`" + `
+}
+
+// InvalidParam is a request field which failed the validation along with the reasons
+type InvalidParam struct {
+	Name    string   ` + "`" + `json:"name"` + "`" + `
+	Reasons []string ` + "`" + `json:"reasons"` + "`" + `
+}
+
+// Error returns the status along with the detail of the problem or its title if there is no detail
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+}
+
+// Is matches the problem with the error of its status
+func (p *Problem) Is(target error) bool {
+	switch p.Status {
+	case http.StatusBadRequest:
+		return target == ErrBadRequest
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrTooManyRequests
+	}
+	return p.Status >= http.StatusInternalServerError && target == ErrServer
+}
+
+// AsProblem returns the problem of the error if it is a problem response of the api
+func AsProblem(err error) (*Problem, bool) {
+	var p *Problem
+	ok := errors.As(err, &p)
+	return p, ok
+}
+
+`)
+}
+
+// ClientTestTemplate returns template for client/client_test.go
+func ClientTestTemplate() []byte {
+	return []byte(`package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer answers the requests with the responses in order, the last one is repeated, and records the bodies
+// of the requests
+type testServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses []func(w http.ResponseWriter)
+	bodies    []string
+}
+
+func newTestServer(t *testing.T, responses ...func(w http.ResponseWriter)) *testServer {
+	s := &testServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		respond := s.responses[0]
+		if len(s.responses) > 1 {
+			s.responses = s.responses[1:]
+		}
+		s.mu.Unlock()
+		respond(w)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// requests returns the bodies of the requests the server received
+func (s *testServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func respond(status int, header, value, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		if header != "" {
+			w.Header().Set(header, value)
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func newTestClient(t *testing.T, s *testServer) *Client {
+	c, err := New(s.URL, WithRetries(2, time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   Problem
+		is     error
+	}{
+		{
+			name:   "problem",
+			status: http.StatusBadRequest,
+			body:   ` + "`" + `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid body","invalid-params":[{"name":"name","reasons":["required"]}]}` + "`" + `,
+			want:   Problem{Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "invalid body", InvalidParams: []InvalidParam{ {Name: "name", Reasons: []string{"required"}} }},
+			is:     ErrBadRequest,
+		},
+		{
+			name:   "not a problem",
+			status: http.StatusNotFound,
+			body:   "404 page not found",
+			want:   Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound},
+			is:     ErrNotFound,
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			body:   ` + "`" + `{"type":"about:blank","title":"Internal Server Error","status":500}` + "`" + `,
+			want:   Problem{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError},
+			is:     ErrServer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, respond(tt.status, "Content-Type", "application/problem+json", tt.body))
+			err := newTestClient(t, s).do(context.Background(), http.MethodGet, "/items", nil, nil, nil)
+			p, ok := AsProblem(err)
+			if !ok {
+				t.Fatalf("do() error = %v, want a problem", err)
+			}
+			if p.Error() != tt.want.Error() || p.Type != tt.want.Type || len(p.InvalidParams) != len(tt.want.InvalidParams) {
+				t.Errorf("do() problem = %+v, want %+v", p, tt.want)
+			}
+			if !errors.Is(err, tt.is) {
+				t.Errorf("do() error = %v, want it to be %v", err, tt.is)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	unavailable := respond(http.StatusServiceUnavailable, "", "", "")
+	ok := respond(http.StatusOK, "Content-Type", "application/json", ` + "`" + `{"name":"a"}` + "`" + `)
+	tests := []struct {
+		name      string
+		method    string
+		responses []func(w http.ResponseWriter)
+		wantErr   error
+		// wantRequests is the number of requests the server receives
+		wantRequests int
+	}{
+		{name: "get succeeds", method: http.MethodGet, responses: []func(w http.ResponseWriter){unavailable, unavailable, ok}, wantRequests: 3},
+		{name: "get fails", method: http.MethodGet, responses: []func(w http.ResponseWriter){unavailable}, wantErr: ErrServer, wantRequests: 3},
+		{name: "put is retried with the body", method: http.MethodPut, responses: []func(w http.ResponseWriter){unavailable, ok}, wantRequests: 2},
+		{name: "delete", method: http.MethodDelete, responses: []func(w http.ResponseWriter){unavailable, ok}, wantRequests: 2},
+		{name: "post is never retried", method: http.MethodPost, responses: []func(w http.ResponseWriter){unavailable, ok}, wantErr: ErrServer, wantRequests: 1},
+		{name: "patch is never retried", method: http.MethodPatch, responses: []func(w http.ResponseWriter){unavailable, ok}, wantErr: ErrServer, wantRequests: 1},
+		{name: "not retryable", method: http.MethodGet, responses: []func(w http.ResponseWriter){respond(http.StatusConflict, "", "", ""), ok}, wantErr: ErrConflict, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.responses...)
+			in := map[string]string{"name": "a"}
+			var out map[string]string
+			err := newTestClient(t, s).do(context.Background(), tt.method, "/items", nil, in, &out)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("do() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || out["name"] != "a" {
+				t.Fatalf("do() = %v, %v, want the response decoded", out, err)
+			}
+
+			requests := s.requests()
+			if len(requests) != tt.wantRequests {
+				t.Fatalf("server received %d requests, want %d", len(requests), tt.wantRequests)
+			}
+			for i, body := range requests {
+				if body != ` + "`" + `{"name":"a"}` + "`" + ` {
+					t.Errorf("body of the request %d = %q, want the body of every attempt", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	s := newTestServer(t, respond(http.StatusTooManyRequests, "Retry-After", "1", ""), respond(http.StatusNoContent, "", "", ""))
+	// the backoff would outlast the deadline of the context, so the request succeeds only after the Retry-After
+	c, err := New(s.URL, WithRetries(1, time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err = c.do(ctx, http.MethodDelete, "/items/1", nil, nil, nil); err != nil {
+		t.Fatalf("do() error = %v, want the request retried after the Retry-After", err)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("do() retried after %s, want the Retry-After of 1s", waited)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	s := newTestServer(t, respond(http.StatusServiceUnavailable, "", "", ""))
+	c, err := New(s.URL, WithRetries(3, time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = c.do(ctx, http.MethodGet, "/items", nil, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrServer) {
+		t.Errorf("do() error = %v, want the problem along with the deadline of the context", err)
+	}
+}
+`)
+}
+
+// ResourceClientTemplate returns template for client/<resource>.go
+func ResourceClientTemplate() []byte {
+	return []byte(`package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"{{ .Project.ModuleName }}/pkg/models"
+)
+{{ with .Resource }}
+// {{ .GoPluralName }}Client sends the requests of the {{ .HumanName }} resource
+type {{ .GoPluralName }}Client struct {
+	c *Client
+}
+
+// {{ .GoPluralName }} returns the client of the {{ .HumanPluralName }}
+func (c *Client) {{ .GoPluralName }}() *{{ .GoPluralName }}Client {
+	return &{{ .GoPluralName }}Client{c: c}
+}
+
+// List lists a page of the {{ .HumanPluralName }}
+func (c *{{ .GoPluralName }}Client) List(ctx context.Context, opts ListOptions) (*Page[models.{{ .GoName }}], error) {
+	var page Page[models.{{ .GoName }}]
+	if err := c.c.do(ctx, http.MethodGet, "/{{ .PathName }}", opts.query(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Get gets the {{ .HumanName }} with the id
+func (c *{{ .GoPluralName }}Client) Get(ctx context.Context, id string) (*models.{{ .GoName }}, error) {
+	var m models.{{ .GoName }}
+	if err := c.c.do(ctx, http.MethodGet, "/{{ .PathName }}/"+url.PathEscape(id), nil, nil, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Create creates the {{ .HumanName }} and returns it along with its id
+func (c *{{ .GoPluralName }}Client) Create(ctx context.Context, m *models.{{ .GoName }}) (*models.{{ .GoName }}, error) {
+	var created models.{{ .GoName }}
+	if err := c.c.do(ctx, http.MethodPost, "/{{ .PathName }}", nil, m, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update replaces the {{ .HumanName }} with the id
+func (c *{{ .GoPluralName }}Client) Update(ctx context.Context, id string, m *models.{{ .GoName }}) (*models.{{ .GoName }}, error) {
+	var updated models.{{ .GoName }}
+	if err := c.c.do(ctx, http.MethodPut, "/{{ .PathName }}/"+url.PathEscape(id), nil, m, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete deletes the {{ .HumanName }} with the id
+func (c *{{ .GoPluralName }}Client) Delete(ctx context.Context, id string) error {
+	return c.c.do(ctx, http.MethodDelete, "/{{ .PathName }}/"+url.PathEscape(id), nil, nil, nil)
+}
+{{ end }}
+`)
+}
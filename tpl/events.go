@@ -0,0 +1,631 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// EventsTemplate returns template for pkg/events/events.go
+func EventsTemplate() []byte {
+	return []byte(`// Package events publishes the domain events of the resources to the message broker
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Event is a domain event of a resource, it is published as json
+type Event struct {
+	// ID identifies the event, the consumers use it to ignore the events delivered more than once
+	ID string ` + "`" + `json:"id"` + "`" + `
+	// Type is the service, the resource and what happened to it, e.g. inventory.item.created, inventory.item.updated
+	// or inventory.item.deleted, so the events of the services are told apart on a shared topic
+	Type string ` + "`" + `json:"type"` + "`" + `
+	// Subject is the id of the resource, the events of a resource are delivered in order
+	Subject string ` + "`" + `json:"subject"` + "`" + `
+	Time    time.Time ` + "`" + `json:"time"` + "`" + `
+	// Data is the resource, or its id when it is deleted
+	Data json.RawMessage ` + "`" + `json:"data"` + "`" + `
+}
+
+// NewEvent returns an event of the type about the subject with the data marshalled as json
+func NewEvent(eventType, subject string, data interface{}) (Event, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return Event{}, err
+	}
+	return Event{ID: hex.EncodeToString(id), Type: eventType, Subject: subject, Time: time.Now().UTC(), Data: b}, nil
+}
+
+// Publisher publishes the events to the message broker
+type Publisher interface {
+	// Publish publishes the events in order, the events may be published again if an error is returned
+	Publish(ctx context.Context, events ...Event) error
+	// Close publishes the pending events and closes the connection to the broker
+	Close() error
+}
+
+// LogPublisher logs the events, it is the publisher of the services without a message broker
+type LogPublisher struct{}
+
+// NewLogPublisher returns a LogPublisher
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+// Publish logs the events as json
+func (p *LogPublisher) Publish(_ context.Context, events ...Event) error {
+	for _, e := range events {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		log.Println("event", e.Type, string(b))
+	}
+	return nil
+}
+
+// Close does nothing
+func (p *LogPublisher) Close() error {
+	return nil
+}
+
+// MemoryPublisher keeps the published events in memory, the tests assert the events of the writes with it
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewMemoryPublisher returns an empty MemoryPublisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish appends the events
+func (p *MemoryPublisher) Publish(_ context.Context, events ...Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// Events returns the published events in order
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
+
+// Close does nothing
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
+
+`)
+}
+
+// KafkaPublisherTemplate returns template for pkg/events/kafka.go
+func KafkaPublisherTemplate() []byte {
+	return []byte(`package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher publishes the events to a kafka topic, the events are keyed by their subject so the events of
+// a resource are in the same partition and consumed in order
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher returns a KafkaPublisher to the topic of the brokers, the writes are acknowledged by all
+// the in-sync replicas
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Topic:                  topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+	}}
+}
+
+// Publish writes the events to the topic, the type of the event is the type header of the message
+func (p *KafkaPublisher) Publish(ctx context.Context, events ...Event) error {
+	messages := make([]kafka.Message, len(events))
+	for i, e := range events {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		messages[i] = kafka.Message{
+			Key:     []byte(e.Subject),
+			Value:   value,
+			Headers: []kafka.Header{{"{{"}}Key: "type", Value: []byte(e.Type)}},
+		}
+	}
+	return p.writer.WriteMessages(ctx, messages...)
+}
+
+// Close flushes the pending messages and closes the writer
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+`)
+}
+
+// NATSPublisherTemplate returns template for pkg/events/nats.go
+func NATSPublisherTemplate() []byte {
+	return []byte(`package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// flushTimeout is how long Publish waits for the server to receive the events when ctx has no deadline
+const flushTimeout = 5 * time.Second
+
+// NATSPublisher publishes the events to the nats subjects of their types, e.g. inventory.item.created, prefixed
+// with the prefix if it is not empty. The id of the event is the Nats-Msg-Id header so that a JetStream stream on
+// the subjects drops the duplicates
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSPublisher connects to the nats server of the url
+func NewNATSPublisher(url, prefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("{{ .ProjectDirName }}"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSPublisher{conn: conn, prefix: prefix}, nil
+}
+
+// Publish publishes the events and waits for the server to receive them
+func (p *NATSPublisher) Publish(ctx context.Context, events ...Event) error {
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		subject := e.Type
+		if p.prefix != "" {
+			subject = p.prefix + "." + subject
+		}
+		msg := nats.NewMsg(subject)
+		msg.Data = data
+		msg.Header.Set(nats.MsgIdHdr, e.ID)
+		if err = p.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return p.conn.FlushTimeout(flushTimeout)
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+// Close publishes the pending events and closes the connection
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+`)
+}
+
+// OutboxTemplate returns template for pkg/events/outbox.go
+func OutboxTemplate() []byte {
+	return []byte(`package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// Outbox stores the events in the outbox table of a postgres database, the relay publishes them afterwards, so the
+// events are kept while the broker is down. The events written by Add in the transaction of a write of the same
+// database are published if and only if the write is committed.
+type Outbox struct {
+	db *sql.DB
+}
+
+// NewOutbox returns the Outbox of the database
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+// Migrate creates the outbox table if it doesn't exist
+func (o *Outbox) Migrate(ctx context.Context) error {
+	_, err := o.db.ExecContext(ctx, ` + "`" + `CREATE TABLE IF NOT EXISTS outbox (
+	id BIGSERIAL PRIMARY KEY,
+	event_id TEXT NOT NULL,
+	type TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	time TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL
+)` + "`" + `)
+	return err
+}
+
+// Add stores the events in the transaction, the repositories of a database add the events of a write in its
+// transaction
+func (o *Outbox) Add(ctx context.Context, tx *sql.Tx, events ...Event) error {
+	for _, e := range events {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO outbox (event_id, type, subject, time, data) VALUES ($1, $2, $3, $4, $5)",
+			e.ID, e.Type, e.Subject, e.Time, string(e.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publish stores the events in a transaction of their own, it makes the Outbox a Publisher of the repositories
+// which don't write to the database, e.g. the in-memory ones. The events are stored after the write, not atomically
+// with it, they are lost if the database can't be written.
+func (o *Outbox) Publish(ctx context.Context, events ...Event) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = o.Add(ctx, tx, events...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close does nothing, the database is closed by its owner
+func (o *Outbox) Close() error {
+	return nil
+}
+
+// Relay publishes the events of the outbox in order and deletes them once they are published, the events are
+// published at least once. Many instances can relay the same outbox, the rows are locked while they are published.
+type Relay struct {
+	outbox    *Outbox
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRelay returns a Relay which polls the outbox every interval for at most batchSize events
+func NewRelay(outbox *Outbox, publisher Publisher, interval time.Duration, batchSize int) *Relay {
+	return &Relay{outbox: outbox, publisher: publisher, interval: interval, batchSize: batchSize}
+}
+
+// Start creates the outbox table and starts relaying the events, it is a lifecycle.Component
+func (r *Relay) Start(ctx context.Context, _ chan<- error) error {
+	if err := r.outbox.Migrate(ctx); err != nil {
+		return err
+	}
+	r.stop, r.done = make(chan struct{}), make(chan struct{})
+	go r.run()
+	return nil
+}
+
+// Stop stops relaying the events, the batch being published is finished before the deadline of ctx
+func (r *Relay) Stop(ctx context.Context) error {
+	close(r.stop)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run relays the events every interval, the full batches are followed by the next one right away
+func (r *Relay) run() {
+	defer close(r.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		n, err := r.relay(ctx)
+		if err != nil {
+			log.Println("error relaying the outbox events:", err)
+		}
+		if err == nil && n == r.batchSize {
+			select {
+			case <-r.stop:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes the next batch of events and deletes them from the outbox, it returns the number of events
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	tx, err := r.outbox.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, event_id, type, subject, time, data FROM outbox ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED", r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	var events []Event
+	for rows.Next() {
+		var id int64
+		var e Event
+		var data []byte
+		if err = rows.Scan(&id, &e.ID, &e.Type, &e.Subject, &e.Time, &data); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		e.Data = data
+		ids = append(ids, id)
+		events = append(events, e)
+	}
+	if err = errors.Join(rows.Err(), rows.Close()); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err = r.publisher.Publish(ctx, events...); err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if _, err = tx.ExecContext(ctx, "DELETE FROM outbox WHERE id = $1", id); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), tx.Commit()
+}
+
+`)
+}
+
+// PublishingRepositoryTemplate returns template for pkg/repository/events.go
+func PublishingRepositoryTemplate() []byte {
+	return []byte(`package repository
+
+import (
+	"context"
+	"log"
+
+	"{{ .ModuleName }}/pkg/events"
+)
+
+// publishingRepository decorates the repository of the model T, it publishes the created, updated and deleted
+// events of the resources after the writes. The writes succeed even if their events can't be published, the error
+// is logged and the event is lost, the outbox keeps the events while the broker is down though.
+type publishingRepository[T any] struct {
+	crudRepository[T]
+	publisher events.Publisher
+	// name prefixes the types of the events with the service and the resource, e.g. inventory.item
+	name string
+	// id returns the id of the resource
+	id func(m *T) string
+}
+
+// newPublishingRepository returns the repo decorated with the publisher
+func newPublishingRepository[T any](repo crudRepository[T], publisher events.Publisher, name string, id func(m *T) string) *publishingRepository[T] {
+	return &publishingRepository[T]{crudRepository: repo, publisher: publisher, name: name, id: id}
+}
+
+// Create stores the resource and publishes the created event
+func (r *publishingRepository[T]) Create(ctx context.Context, m *T) error {
+	if err := r.crudRepository.Create(ctx, m); err != nil {
+		return err
+	}
+	r.publish(ctx, "created", r.id(m), m)
+	return nil
+}
+
+// Update replaces the resource and publishes the updated event
+func (r *publishingRepository[T]) Update(ctx context.Context, m *T) error {
+	if err := r.crudRepository.Update(ctx, m); err != nil {
+		return err
+	}
+	r.publish(ctx, "updated", r.id(m), m)
+	return nil
+}
+
+// Delete removes the resource and publishes the deleted event
+func (r *publishingRepository[T]) Delete(ctx context.Context, id string) error {
+	if err := r.crudRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.publish(ctx, "deleted", id, map[string]string{"id": id})
+	return nil
+}
+
+// publish publishes the event of the action, it is published even if the request is canceled after the write. The
+// write is done, so the errors are logged rather than returned to the client as a failed write.
+func (r *publishingRepository[T]) publish(ctx context.Context, action, id string, data interface{}) {
+	e, err := events.NewEvent(r.name+"."+action, id, data)
+	if err == nil {
+		err = r.publisher.Publish(context.WithoutCancel(ctx), e)
+	}
+	if err != nil {
+		log.Println("error publishing event", r.name+"."+action, "of", id, ":", err)
+	}
+}
+
+`)
+}
+
+// PublishingRepositoryTestTemplate returns template for pkg/repository/events_test.go
+func PublishingRepositoryTestTemplate() []byte {
+	return []byte(`package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"{{ .ModuleName }}/pkg/events"
+)
+
+// note is the model of the tests
+type note struct {
+	ID string
+}
+
+// noteRepository keeps the notes in a map, the note with the id fail can't be created
+type noteRepository map[string]note
+
+func (r noteRepository) List(context.Context, ListOptions) ([]note, Page, error) {
+	return nil, Page{}, nil
+}
+
+func (r noteRepository) Get(_ context.Context, id string) (note, error) {
+	if n, ok := r[id]; ok {
+		return n, nil
+	}
+	return note{}, ErrNotFound
+}
+
+func (r noteRepository) Create(_ context.Context, m *note) error {
+	if m.ID == "fail" {
+		return errors.New("write failed")
+	}
+	r[m.ID] = *m
+	return nil
+}
+
+func (r noteRepository) Update(ctx context.Context, m *note) error {
+	if _, err := r.Get(ctx, m.ID); err != nil {
+		return err
+	}
+	r[m.ID] = *m
+	return nil
+}
+
+func (r noteRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.Get(ctx, id); err != nil {
+		return err
+	}
+	delete(r, id)
+	return nil
+}
+
+// failingPublisher fails to publish the events, like a broker which is down
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, ...events.Event) error {
+	return errors.New("broker is down")
+}
+
+func (failingPublisher) Close() error {
+	return nil
+}
+
+func newNoteRepository(publisher events.Publisher) *publishingRepository[note] {
+	return newPublishingRepository[note](noteRepository{}, publisher, "{{ .ProjectDirName }}.note", func(m *note) string { return m.ID })
+}
+
+func TestPublishingRepository(t *testing.T) {
+	ctx := context.Background()
+	publisher := events.NewMemoryPublisher()
+	repo := newNoteRepository(publisher)
+
+	n := &note{ID: "1"}
+	if err := repo.Create(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, n.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"{{ .ProjectDirName }}.note.created", "{{ .ProjectDirName }}.note.updated", "{{ .ProjectDirName }}.note.deleted"}
+	published := publisher.Events()
+	if len(published) != len(want) {
+		t.Fatalf("published %d events, want %d", len(published), len(want))
+	}
+	for i, e := range published {
+		if e.Type != want[i] || e.Subject != n.ID || e.ID == "" {
+			t.Errorf("event %d = %s about %s, want %s about %s", i, e.Type, e.Subject, want[i], n.ID)
+		}
+	}
+}
+
+func TestPublishingRepositoryFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	publisher := events.NewMemoryPublisher()
+	repo := newNoteRepository(publisher)
+
+	if err := repo.Create(ctx, &note{ID: "fail"}); err == nil {
+		t.Error("Create() succeeded, want the error of the write")
+	}
+	if err := repo.Delete(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() error = %v, want %v", err, ErrNotFound)
+	}
+	if published := publisher.Events(); len(published) != 0 {
+		t.Errorf("published %d events of the failed writes, want none", len(published))
+	}
+}
+
+func TestPublishingRepositoryPublishError(t *testing.T) {
+	ctx := context.Background()
+	repo := newNoteRepository(failingPublisher{})
+
+	// the write is done, so it succeeds even if its event can't be published
+	if err := repo.Create(ctx, &note{ID: "1"}); err != nil {
+		t.Fatalf("Create() error = %v, want the write to succeed", err)
+	}
+	if _, err := repo.Get(ctx, "1"); err != nil {
+		t.Errorf("Get() error = %v, want the note written", err)
+	}
+}
+`)
+}
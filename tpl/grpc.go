@@ -0,0 +1,384 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// GRPCServerTemplate returns template for pkg/grpcserver/server.go
+func GRPCServerTemplate() []byte {
+	return []byte(`// Code generated by crud. DO NOT EDIT.
+
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"{{ .ModuleName }}/pkg/apierror"
+{{- if .Authenticated }}
+	"{{ .ModuleName }}/pkg/auth"
+{{- end }}
+{{- if .GRPCResources }}
+	"{{ .ModuleName }}/pkg/pb"
+{{- end }}
+	"{{ .ModuleName }}/pkg/repository"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Register registers the grpc services of the resources added with --grpc along with the health and reflection services
+func Register(s *grpc.Server, repos *repository.Repositories) {
+{{- range .GRPCResources }}
+	pb.Register{{ .GoName }}ServiceServer(s, New{{ .GoName }}Server(repos.{{ .GoName }}))
+{{- end }}
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	reflection.Register(s)
+}
+
+{{- if .Authenticated }}
+// MethodRules are the scopes and roles the bearer token must be granted for the rpcs of the resources
+var MethodRules = map[string]auth.Rule{
+{{- range $method, $rule := .GRPCMethodRules }}
+	"{{ $method }}": { {{- $rule -}} },
+{{- end }}
+}
+
+{{ end -}}
+// toStatus maps the repository and validation errors to grpc status errors, the invalid fields are returned
+// as BadRequest details
+func toStatus(err error) error {
+	if problem := apierror.From(err); len(problem.InvalidParams) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, param := range problem.InvalidParams {
+			for _, reason := range param.Reasons {
+				badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: param.Name, Description: reason})
+			}
+		}
+		st := status.New(codes.InvalidArgument, problem.Detail)
+		if detailed, err := st.WithDetails(badRequest); err == nil {
+			return detailed.Err()
+		}
+		return st.Err()
+	}
+
+	var problem *apierror.Problem
+	if errors.As(err, &problem) && problem.Status == http.StatusForbidden {
+		return status.Error(codes.PermissionDenied, problem.Detail)
+	}
+
+	var referencedErr *repository.ReferencedError
+	switch {
+	case errors.As(err, &referencedErr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, repository.ErrInvalidListOptions):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// listOptions returns the repository list options of the list request, order_by and filter have the syntax
+// of the sort and filter query parameters of the http list endpoints
+func listOptions(pageSize int32, pageToken, orderBy, filter string, fields map[string]repository.FieldType) (repository.ListOptions, error) {
+	opts := repository.ListOptions{Limit: int(pageSize), Cursor: pageToken}
+	if pageSize < 0 {
+		return opts, fmt.Errorf("%w: page_size must not be negative", repository.ErrInvalidListOptions)
+	}
+	q, err := url.ParseQuery(filter)
+	if err != nil {
+		return opts, fmt.Errorf("%w: invalid filter: %v", repository.ErrInvalidListOptions, err)
+	}
+	if opts.Filters, err = repository.ParseFilters(q, fields); err != nil {
+		return opts, err
+	}
+	opts.Sort, err = repository.ParseSort(orderBy, fields)
+	return opts, err
+}
+
+// toTimestamp converts the time to timestamp, the zero time is converted to nil
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// fromTimestamp converts the timestamp to time, nil is converted to the zero time
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+`)
+}
+
+// PbGenerateTemplate returns template for pkg/pb/generate.go
+func PbGenerateTemplate() []byte {
+	return []byte(`// Code generated by crud. DO NOT EDIT.
+
+// Package pb contains the go code generated from the proto files of the resources, run go generate after changing them
+package pb
+
+//go:generate protoc --proto_path=../../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative{{ range .GRPCResources }} {{ .FileName }}.proto{{ end }}
+`)
+}
+
+// ProtoTemplate returns template for proto/<resource>.proto
+func ProtoTemplate() []byte {
+	return []byte(`syntax = "proto3";
+
+package {{ .Project.ProtoPackage }};
+
+import "google/protobuf/empty.proto";
+import "google/protobuf/timestamp.proto";
+
+option go_package = "{{ .Project.ModuleName }}/pkg/pb";
+{{ with .Resource }}
+// {{ .GoName }}Service serves the CRUD rpcs of the {{ .HumanName }} resource
+service {{ .GoName }}Service {
+  rpc List{{ .GoPluralName }}(List{{ .GoPluralName }}Request) returns (List{{ .GoPluralName }}Response);
+  rpc Get{{ .GoName }}(Get{{ .GoName }}Request) returns ({{ .GoName }});
+  rpc Create{{ .GoName }}(Create{{ .GoName }}Request) returns ({{ .GoName }});
+  rpc Update{{ .GoName }}(Update{{ .GoName }}Request) returns ({{ .GoName }});
+  rpc Delete{{ .GoName }}(Delete{{ .GoName }}Request) returns (google.protobuf.Empty);
+}
+
+// {{ .GoName }} is the {{ .HumanName }} resource
+message {{ .GoName }} {
+  string id = 1;
+{{- range $i, $f := .Fields }}
+  {{ $f.ProtoType }} {{ $f.ProtoName }} = {{ add $i 2 }};
+{{- end }}
+  google.protobuf.Timestamp created_at = {{ add (len .Fields) 2 }};
+  google.protobuf.Timestamp updated_at = {{ add (len .Fields) 3 }};
+{{- if .Owned }}
+  // owner_id is the subject of the token which created the {{ .HumanName }}, it is set by the server
+  string owner_id = {{ add (len .Fields) 4 }};
+{{- end }}
+}
+
+message List{{ .GoPluralName }}Request {
+  // page_size is the maximum number of {{ .HumanPluralName }} returned, 20 by default and at most 100
+  int32 page_size = 1;
+  // page_token is the next_page_token of the previous page
+  string page_token = 2;
+  // order_by is the comma separated list of fields to sort by, a - prefix sorts in descending order, e.g. -createdAt
+  string order_by = 3;
+  // filter has the syntax of the http list query, e.g. status=active&price_gt=10
+  string filter = 4;
+}
+
+message List{{ .GoPluralName }}Response {
+  repeated {{ .GoName }} {{ .ProtoPluralName }} = 1;
+  // next_page_token lists the next page, it is empty on the last page
+  string next_page_token = 2;
+  // total_size is the number of {{ .HumanPluralName }} matching the filter
+  int32 total_size = 3;
+}
+
+message Get{{ .GoName }}Request {
+  string id = 1;
+}
+
+message Create{{ .GoName }}Request {
+  {{ .GoName }} {{ .ProtoName }} = 1;
+}
+
+message Update{{ .GoName }}Request {
+  {{ .GoName }} {{ .ProtoName }} = 1;
+}
+
+message Delete{{ .GoName }}Request {
+  string id = 1;
+}
+{{- end }}
+`)
+}
+
+// ResourceGRPCServerTemplate returns template for pkg/grpcserver/<resource>.go
+func ResourceGRPCServerTemplate() []byte {
+	return []byte(`package grpcserver
+
+import (
+	"context"
+{{ if .Resource.Owned }}
+	"{{ .Project.ModuleName }}/pkg/auth"
+{{- end }}
+	"{{ .Project.ModuleName }}/pkg/models"
+	"{{ .Project.ModuleName }}/pkg/pb"
+	"{{ .Project.ModuleName }}/pkg/repository"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+{{ with .Resource }}
+// {{ .GoName }}Server serves the {{ .GoName }}Service rpcs on the {{ .HumanName }} repository
+type {{ .GoName }}Server struct {
+	pb.Unimplemented{{ .GoName }}ServiceServer
+	repo repository.{{ .GoName }}Repository
+}
+
+// New{{ .GoName }}Server returns the server serving the {{ .HumanPluralName }} of the repository
+func New{{ .GoName }}Server(repo repository.{{ .GoName }}Repository) *{{ .GoName }}Server {
+	return &{{ .GoName }}Server{repo: repo}
+}
+
+// List{{ .GoPluralName }} returns a page of the {{ .HumanPluralName }} matching the filter in the requested order
+func (s *{{ .GoName }}Server) List{{ .GoPluralName }}(ctx context.Context, req *pb.List{{ .GoPluralName }}Request) (*pb.List{{ .GoPluralName }}Response, error) {
+	opts, err := listOptions(req.GetPageSize(), req.GetPageToken(), req.GetOrderBy(), req.GetFilter(), repository.{{ .GoName }}Fields)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+{{- if .OwnerOnly "list" }}
+	// only the {{ .HumanPluralName }} owned by the subject of the rpc are listed
+	opts.Filters = append(opts.Filters, repository.Filter{Field: "ownerId", Op: repository.OpEq, Value: auth.Subject(ctx)})
+{{- end }}
+	items, page, err := s.repo.List(ctx, opts)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.List{{ .GoPluralName }}Response{
+		{{ .PbGoPluralName }}: make([]*pb.{{ .GoName }}, 0, len(items)),
+		NextPageToken: page.NextCursor,
+		TotalSize:     int32(page.Total),
+	}
+	for i := range items {
+		resp.{{ .PbGoPluralName }} = append(resp.{{ .PbGoPluralName }}, {{ .VarName }}ToProto(&items[i]))
+	}
+	return resp, nil
+}
+
+// Get{{ .GoName }} returns the {{ .HumanName }} with the id
+func (s *{{ .GoName }}Server) Get{{ .GoName }}(ctx context.Context, req *pb.Get{{ .GoName }}Request) (*pb.{{ .GoName }}, error) {
+	m, err := s.repo.Get(ctx, req.GetId())
+{{- if .OwnerOnly "get" }}
+	if err == nil && m.OwnerID != auth.Subject(ctx) {
+		// the {{ .HumanPluralName }} of other owners are not revealed
+		err = repository.ErrNotFound
+	}
+{{- end }}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return {{ .VarName }}ToProto(&m), nil
+}
+
+// Create{{ .GoName }} stores a new {{ .HumanName }}
+func (s *{{ .GoName }}Server) Create{{ .GoName }}(ctx context.Context, req *pb.Create{{ .GoName }}Request) (*pb.{{ .GoName }}, error) {
+	m := {{ .VarName }}FromProto(req.Get{{ .PbGoName }}())
+	if err := models.Validate(m); err != nil {
+		return nil, toStatus(err)
+	}
+{{- if .Owned }}
+	m.OwnerID = auth.Subject(ctx)
+{{- end }}
+	if err := s.repo.Create(ctx, &m); err != nil {
+		return nil, toStatus(err)
+	}
+	return {{ .VarName }}ToProto(&m), nil
+}
+
+// Update{{ .GoName }} replaces the {{ .HumanName }} with the id of the request
+func (s *{{ .GoName }}Server) Update{{ .GoName }}(ctx context.Context, req *pb.Update{{ .GoName }}Request) (*pb.{{ .GoName }}, error) {
+	m := {{ .VarName }}FromProto(req.Get{{ .PbGoName }}())
+	if m.ID == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := models.Validate(m); err != nil {
+		return nil, toStatus(err)
+	}
+{{- if .OwnerOnly "update" }}
+	if err := s.checkOwner(ctx, m.ID, "update"); err != nil {
+		return nil, toStatus(err)
+	}
+{{- end }}
+	if err := s.repo.Update(ctx, &m); err != nil {
+		return nil, toStatus(err)
+	}
+	return {{ .VarName }}ToProto(&m), nil
+}
+
+// Delete{{ .GoName }} removes the {{ .HumanName }} with the id
+func (s *{{ .GoName }}Server) Delete{{ .GoName }}(ctx context.Context, req *pb.Delete{{ .GoName }}Request) (*emptypb.Empty, error) {
+{{- if .OwnerOnly "delete" }}
+	if err := s.checkOwner(ctx, req.GetId(), "delete"); err != nil {
+		return nil, toStatus(err)
+	}
+{{- end }}
+	if err := s.repo.Delete(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+{{- if or (.OwnerOnly "update") (.OwnerOnly "delete") }}
+
+// checkOwner returns ErrNotFound if the {{ .HumanName }} with the id doesn't exist or a 403 Forbidden problem,
+// mapped to PermissionDenied, unless the subject of the rpc owns it
+func (s *{{ .GoName }}Server) checkOwner(ctx context.Context, id, action string) error {
+	existing, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return auth.CheckOwner(ctx, existing.OwnerID, action, "{{ .HumanName }}")
+}
+{{- end }}
+
+// {{ .VarName }}ToProto converts the model to its proto message
+func {{ .VarName }}ToProto(m *models.{{ .GoName }}) *pb.{{ .GoName }} {
+	return &pb.{{ .GoName }}{
+		Id: m.ID,
+{{- range .Fields }}
+		{{ .PbGoName }}: {{ .ToProto "m" }},
+{{- end }}
+		CreatedAt: toTimestamp(m.CreatedAt),
+		UpdatedAt: toTimestamp(m.UpdatedAt),
+{{- if .Owned }}
+		OwnerId:   m.OwnerID,
+{{- end }}
+	}
+}
+
+// {{ .VarName }}FromProto converts the proto message to its model, the timestamps are set by the repository
+func {{ .VarName }}FromProto(p *pb.{{ .GoName }}) models.{{ .GoName }} {
+	return models.{{ .GoName }}{
+		ID: p.GetId(),
+{{- range .Fields }}
+		{{ .GoName }}: {{ .FromProto "p" }},
+{{- end }}
+	}
+}
+{{ end }}
+`)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// MetricsTemplate returns template for pkg/metrics/metrics.go
+func MetricsTemplate() []byte {
+	return []byte(`package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"{{ .ModuleName }}/pkg/middleware"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are the prometheus metrics of the http requests, they are registered on their own registry along with
+// the metrics of the go runtime and of the process
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// New returns the metrics registered on a new registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of the http requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of the http requests by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+	}
+	m.registry.MustRegister(m.requests, m.duration,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+// Middleware records the requests by the route pattern of their path returned by route, e.g. /items/{id}, so that
+// the ids in the paths don't make a series each. The paths matching no route are recorded as unmatched and the
+// methods which are not standard as other.
+func (m *Metrics) Middleware(route func(path string) string) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			pattern := route(r.URL.Path)
+			if pattern == "" {
+				pattern = "unmatched"
+			}
+			method := methodLabel(r.Method)
+			m.requests.WithLabelValues(pattern, method, strconv.Itoa(sw.status)).Inc()
+			m.duration.WithLabelValues(pattern, method).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// Handler serves the metrics in the prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// methodLabel returns the method, or other if it is not one of the standard methods clients may send any of
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodOptions:
+		return method
+	}
+	return "other"
+}
+
+// statusWriter records the status code written by the handler
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, it is used by http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+`)
+}
+
+// MetricsTestTemplate returns template for pkg/metrics/metrics_test.go
+func MetricsTestTemplate() []byte {
+	return []byte(`package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// route returns the pattern of the item paths
+func route(path string) string {
+	if strings.HasPrefix(path, "/items/") {
+		return "/items/{id}"
+	}
+	return ""
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+	h := m.Middleware(route)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nope" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/items/1", nil),
+		httptest.NewRequest(http.MethodGet, "/items/2", nil),
+		httptest.NewRequest(http.MethodGet, "/nope", nil),
+		httptest.NewRequest("PURGE", "/items/1", nil),
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		` + "`" + `http_requests_total{code="200",method="GET",route="/items/{id}"} 2` + "`" + `,
+		` + "`" + `http_requests_total{code="404",method="GET",route="unmatched"} 1` + "`" + `,
+		` + "`" + `http_requests_total{code="200",method="other",route="/items/{id}"} 1` + "`" + `,
+		` + "`" + `http_request_duration_seconds_count{method="GET",route="/items/{id}"} 2` + "`" + `,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics don't have %s", want)
+		}
+	}
+}
+`)
+}
@@ -0,0 +1,480 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// RateLimitTemplate returns template for pkg/ratelimit/ratelimit.go
+func RateLimitTemplate() []byte {
+	return []byte(`// Package ratelimit limits the requests of each client to the routes with token buckets
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"{{ .ModuleName }}/pkg/apierror"
+{{- if .Authenticated }}
+	"{{ .ModuleName }}/pkg/auth"
+{{- end }}
+)
+
+// Limit is the limit of a token bucket, Rate tokens are added per second up to Burst tokens and every request
+// takes one, the requests are not limited if Rate is 0
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit parses a limit in the form rate[/burst], e.g. 5/10
+func ParseLimit(s string) (Limit, error) {
+	rate, burst, hasBurst := strings.Cut(s, "/")
+	var limit Limit
+	var err error
+	if limit.Rate, err = strconv.ParseFloat(strings.TrimSpace(rate), 64); err != nil || limit.Rate < 0 || math.IsInf(limit.Rate, 0) {
+		return Limit{}, fmt.Errorf("invalid rate of limit %q, it must be a number of requests per second", s)
+	}
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil || limit.Burst < 0 {
+			return Limit{}, fmt.Errorf("invalid burst of limit %q, it must be a number of requests", s)
+		}
+	}
+	return limit, nil
+}
+
+// ParseRoutes parses the limits of the routes by name, e.g. items.create: 5/10
+func ParseRoutes(routes map[string]string) (map[string]Limit, error) {
+	limits := make(map[string]Limit, len(routes))
+	for name, s := range routes {
+		limit, err := ParseLimit(s)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", name, err)
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// burst returns the size of the bucket, it is the rate rounded up if Burst is not set
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Max(1, math.Ceil(l.Rate)))
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	// Allowed reports whether the bucket had a token for the request
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is the duration until the next token is added when the request is not allowed
+	RetryAfter time.Duration
+	// Reset is the duration until the bucket is full again
+	Reset time.Duration
+}
+
+// Bucket is the state of a token bucket, a shared store can persist it as is and call Take in a transaction
+type Bucket struct {
+	Tokens float64
+	Last   time.Time
+}
+
+// Take adds the tokens of the time elapsed since the last request and takes a token if there is one
+func (b *Bucket) Take(now time.Time, limit Limit) Result {
+	burst := float64(limit.burst())
+	if b.Last.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.Last).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*limit.Rate)
+	}
+	if now.After(b.Last) {
+		b.Last = now
+	}
+
+	var res Result
+	if b.Tokens >= 1 {
+		b.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.Tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.Tokens)
+	res.Reset = seconds((burst - b.Tokens) / limit.Rate)
+	return res
+}
+
+// Store keeps the buckets of the clients, the MemoryStore limits every instance of the service on its own while
+// a shared store, e.g. backed by redis, limits the clients across the instances
+type Store interface {
+	// Take takes a token from the bucket of the key, the bucket is created full if it doesn't exist
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// sweepInterval is how often the MemoryStore drops the buckets which are full again
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the buckets in memory, the buckets which are full again are dropped since a new bucket
+// is created full
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	// full is the time the bucket is full again
+	full time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*memoryBucket{}, lastSweep: time.Now()}
+}
+
+// Take takes a token from the bucket of the key
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, b := range s.buckets {
+			if now.After(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{}
+		s.buckets[key] = b
+	}
+	res := b.Take(now, limit)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// Config configures the Limiter
+type Config struct {
+	// Store keeps the buckets of the clients, a MemoryStore is used if it is nil
+	Store Store
+	// Default is the limit of the routes without a limit, the routes are not limited if its Rate is 0
+	Default Limit
+	// Routes are the limits of the routes by name, e.g. items.create, they win over the limits in the code
+	Routes map[string]Limit
+	// TrustProxy identifies the anonymous clients by the last address of the X-Forwarded-For header, it must
+	// only be set when the service is behind a proxy setting the header
+	TrustProxy bool
+{{- if .Authenticated }}
+	// IP is the limit of each ip address before the requests are authenticated, so the clients guessing credentials
+	// are limited as well, the requests are not limited by ip address if its Rate is 0
+	IP Limit
+{{- end }}
+}
+
+// Limiter limits the requests of each client to the routes
+type Limiter struct {
+	cfg Config
+}
+
+// New returns a Limiter with the configuration
+func New(cfg Config) *Limiter {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	return &Limiter{cfg: cfg}
+}
+
+// Route returns the middleware limiting the requests of each client to the route with the name, the limit of
+// Config.Routes wins over the provided limit which wins over Config.Default. The responses have the RateLimit
+// headers and the requests above the limit are responded with 429 Too Many Requests and the Retry-After header.
+func (l *Limiter) Route(name string, limit Limit) func(http.Handler) http.Handler {
+	if override, ok := l.cfg.Routes[name]; ok {
+		limit = override
+	} else if limit.Rate <= 0 {
+		limit = l.cfg.Default
+	}
+
+	return func(next http.Handler) http.Handler {
+		return l.limit(name, limit, "the rate limit of the route is exceeded", func(r *http.Request) string {
+			return name + "|" + l.client(r)
+		}, next)
+	}
+}
+{{- if .Authenticated }}
+
+// IP returns the middleware limiting the requests of each ip address with Config.IP, it is applied before the
+// requests are authenticated so that the requests with invalid credentials are limited as well
+func (l *Limiter) IP() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return l.limit("ip", l.cfg.IP, "the rate limit of the ip address is exceeded", func(r *http.Request) string {
+			return "ip|" + l.address(r)
+		}, next)
+	}
+}
+{{- end }}
+
+// limit limits the requests to next with a bucket of the limit for each key of the requests, the requests above
+// the limit are responded with the 429 problem of the detail
+func (l *Limiter) limit(name string, limit Limit, detail string, key func(r *http.Request) string, next http.Handler) http.Handler {
+	if limit.Rate <= 0 {
+		return next
+	}
+	policy := fmt.Sprintf("%d;w=%d", limit.burst(), ceilSeconds(seconds(float64(limit.burst())/limit.Rate)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := l.cfg.Store.Take(r.Context(), key(r), limit)
+		if err != nil {
+			// serve the request rather than failing every request while the store is unavailable
+			log.Println("error taking the rate limit token of", name, ":", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", policy)
+		h.Set("RateLimit-Limit", strconv.Itoa(limit.burst()))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, detail))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// client returns the key of the client of the request,
+{{- if .Authenticated }} the subject of the authenticated requests, i.e. the jwt
+// subject or the api key id, or the ip address of the anonymous ones
+{{- else }} the ip address of the request
+{{- end }}
+func (l *Limiter) client(r *http.Request) string {
+{{- if .Authenticated }}
+	if subject := auth.Subject(r.Context()); subject != "" {
+		return "sub:" + subject
+	}
+{{- end }}
+	return "ip:" + l.address(r)
+}
+
+// address returns the ip address of the request, the last address of the X-Forwarded-For header with TrustProxy
+func (l *Limiter) address(r *http.Request) string {
+	if l.cfg.TrustProxy {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(addrs[len(addrs)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ceilSeconds rounds the duration up to whole seconds as the headers require
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+`)
+}
+
+// RateLimitTestTemplate returns template for pkg/ratelimit/ratelimit_test.go
+func RateLimitTestTemplate() []byte {
+	return []byte(`package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+{{- if .Authenticated }}
+
+	"{{ .ModuleName }}/pkg/apierror"
+	"{{ .ModuleName }}/pkg/auth"
+{{- end }}
+)
+
+// ok responds with 200 OK
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// serve serves the request of the ip address with h and returns its status
+func serve(h http.Handler, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.RemoteAddr = ip + ":1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRoute(t *testing.T) {
+	limiter := New(Config{})
+	h := limiter.Route("items.list", Limit{Rate: 1, Burst: 2})(ok)
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		rec := serve(h, "192.0.2.1")
+		if rec.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i, rec.Code, want)
+		}
+		if rec.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want 2", i, rec.Header().Get("RateLimit-Limit"))
+		}
+	}
+	if rec := serve(h, "192.0.2.1"); rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+	}
+	// the buckets are kept for each client
+	if rec := serve(h, "192.0.2.2"); rec.Code != http.StatusOK {
+		t.Errorf("status of another client = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRouteLimits(t *testing.T) {
+	limiter := New(Config{
+		Default: Limit{Rate: 1, Burst: 1},
+		Routes:  map[string]Limit{"items.create": {Rate: 1, Burst: 3}},
+	})
+	tests := []struct {
+		name  string
+		limit Limit
+		want  string
+	}{
+		{name: "items.list", want: "1"},
+		{name: "items.get", limit: Limit{Rate: 1, Burst: 2}, want: "2"},
+		{name: "items.create", limit: Limit{Rate: 1, Burst: 2}, want: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(limiter.Route(tt.name, tt.limit)(ok), "192.0.2.1")
+			if got := rec.Header().Get("RateLimit-Limit"); got != tt.want {
+				t.Errorf("RateLimit-Limit = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+{{- if .Authenticated }}
+
+// authenticate authenticates the requests with the X-Subject header, the other requests are responded with 401
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := r.Header.Get("X-Subject")
+		if subject == "" {
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid credentials"))
+			return
+		}
+		claims := &auth.Claims{}
+		claims.Subject = subject
+		next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+	})
+}
+
+func TestIPLimitsInvalidCredentials(t *testing.T) {
+	limiter := New(Config{IP: Limit{Rate: 1, Burst: 2}})
+	// the resource routes are limited by ip address before they are authenticated
+	h := limiter.IP()(authenticate(limiter.Route("items.list", Limit{})(ok)))
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if rec := serve(h, "192.0.2.1"); rec.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i, rec.Code, want)
+		}
+	}
+}
+
+func TestRouteLimitsSubjects(t *testing.T) {
+	limiter := New(Config{})
+	h := authenticate(limiter.Route("items.list", Limit{Rate: 1, Burst: 1})(ok))
+
+	serveSubject := func(subject string) int {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("X-Subject", subject)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// the authenticated clients are limited by subject, not by ip address
+	if got := serveSubject("alice"); got != http.StatusOK {
+		t.Fatalf("status = %d, want %d", got, http.StatusOK)
+	}
+	if got := serveSubject("bob"); got != http.StatusOK {
+		t.Errorf("status of another subject = %d, want %d", got, http.StatusOK)
+	}
+	if got := serveSubject("alice"); got != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestRouteLimitsDeniedRequests(t *testing.T) {
+	// the requests the owner check of the handler denies
+	notOwner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, "not the owner"))
+	})
+	tests := []struct {
+		name    string
+		handler http.Handler
+	}{
+		{name: "missing scopes", handler: auth.RequireScopes("items:write")(ok)},
+		{name: "missing roles", handler: auth.RequireRoles("admin")(ok)},
+		{name: "not the owner", handler: notOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := New(Config{})
+			// the resource routes are limited once authenticated, before the scopes, roles and owner are checked
+			h := authenticate(limiter.Route("items.update", Limit{Rate: 1, Burst: 2})(tt.handler))
+
+			for i, want := range []int{http.StatusForbidden, http.StatusForbidden, http.StatusTooManyRequests} {
+				req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+				req.Header.Set("X-Subject", "mallory")
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				if rec.Code != want {
+					t.Fatalf("request %d: status = %d, want %d", i, rec.Code, want)
+				}
+			}
+		})
+	}
+}
+{{- end }}
+`)
+}
@@ -0,0 +1,1158 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// RepositoryTemplate returns template for pkg/repository/repository.go
+func RepositoryTemplate() []byte {
+	return []byte(`package repository
+
+import (
+{{- if .DecoratesRepositories }}
+	"context"
+{{- end }}
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when the requested resource doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a resource with the same id already exists
+	ErrConflict = errors.New("conflict")
+)
+
+// ReferenceError is returned when a resource is written with a field referencing a resource which doesn't exist,
+// e.g. the customerId of an order
+type ReferenceError struct {
+	// Field is the json name of the field and Resource the name of the referenced resource
+	Field    string
+	Resource string
+	ID       string
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("%s %s referenced by %s doesn't exist", e.Resource, e.ID, e.Field)
+}
+
+// NewID returns a random id for a new resource
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+{{- if .DecoratesRepositories }}
+
+// crudRepository is the repository of the resources of the model T, every resource repository is one, the
+// repositories are decorated through it
+type crudRepository[T any] interface {
+	List(ctx context.Context, opts ListOptions) ([]T, Page, error)
+	Get(ctx context.Context, id string) (T, error)
+	Create(ctx context.Context, m *T) error
+	Update(ctx context.Context, m *T) error
+	Delete(ctx context.Context, id string) error
+}
+{{- end }}
+
+`)
+}
+
+// ListTemplate returns template for pkg/repository/list.go
+func ListTemplate() []byte {
+	return []byte(`package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLimit is the number of items listed when no limit is requested
+	DefaultLimit = 20
+	// MaxLimit is the maximum number of items listed, larger limits are lowered to it
+	MaxLimit = 100
+)
+
+// ErrInvalidListOptions is returned for unknown fields and operators or invalid values of the list options
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// FieldType is the type of a model field, it is used to validate the filters and sort fields
+type FieldType int
+
+// types of the model fields
+const (
+	StringField FieldType = iota
+	BoolField
+	IntField
+	FloatField
+	TimeField
+)
+
+// filter operators, the operator is the suffix of the field name in the query, e.g. price_gt=10, eq has no suffix
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpContains = "contains"
+)
+
+// FieldOps are the filter operators supported by each field type
+var FieldOps = map[FieldType][]string{
+	StringField: {OpEq, OpNe, OpContains},
+	BoolField:   {OpEq, OpNe},
+	IntField:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+	FloatField:  {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+	TimeField:   {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+}
+
+// Filter restricts the listed items to the ones whose field compares to the value with the operator
+type Filter struct {
+	Field string
+	Op    string
+	// Value is a string, bool, int64, float64 or time.Time depending on the field type
+	Value interface{}
+}
+
+// Sort orders the listed items by the field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions are the pagination, filter and sort options of List
+type ListOptions struct {
+	// Limit is the maximum number of items listed
+	Limit int
+	// Offset skips the first items, it is ignored when Cursor is set
+	Offset int
+	// Cursor continues listing after the last item of the previous page listed with the same filters and sort
+	Cursor  string
+	Filters []Filter
+	// Sort orders the items by the fields in order, items are ordered by createdAt by default
+	Sort []Sort
+}
+
+// Page describes the listed page
+type Page struct {
+	// NextCursor lists the next page, it is empty on the last page
+	NextCursor string
+	// Total is the number of items matching the filters
+	Total int
+}
+
+// ParseListQuery parses the limit, offset, cursor, sort and filter query parameters validated against the model fields,
+// e.g. ?limit=10&sort=-price,name&status=active&price_gt=10
+func ParseListQuery(q url.Values, fields map[string]FieldType) (ListOptions, error) {
+	var opts ListOptions
+	var err error
+	for name, values := range q {
+		switch name {
+		case "limit":
+			if opts.Limit, err = strconv.Atoi(values[0]); err != nil || opts.Limit < 1 {
+				return opts, fmt.Errorf("%w: limit must be a positive number", ErrInvalidListOptions)
+			}
+		case "offset":
+			if opts.Offset, err = strconv.Atoi(values[0]); err != nil || opts.Offset < 0 {
+				return opts, fmt.Errorf("%w: offset must be a non negative number", ErrInvalidListOptions)
+			}
+		case "cursor":
+			opts.Cursor = values[0]
+		case "sort":
+			if opts.Sort, err = ParseSort(values[0], fields); err != nil {
+				return opts, err
+			}
+		}
+	}
+	opts.Filters, err = ParseFilters(q, fields)
+	return opts, err
+}
+
+// ParseFilters parses the filters from the query parameters other than limit, offset, cursor and sort
+func ParseFilters(q url.Values, fields map[string]FieldType) ([]Filter, error) {
+	var filters []Filter
+	for name, values := range q {
+		switch name {
+		case "limit", "offset", "cursor", "sort":
+			continue
+		}
+		for _, value := range values {
+			f, err := ParseFilter(name, value, fields)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, f)
+		}
+	}
+	return filters, nil
+}
+
+// ParseFilter parses the filter from the query parameter name and value, e.g. price_gt and 10
+func ParseFilter(name, value string, fields map[string]FieldType) (Filter, error) {
+	f := Filter{Field: name, Op: OpEq}
+	if i := strings.LastIndex(name, "_"); i > 0 {
+		f.Field, f.Op = name[:i], name[i+1:]
+	}
+
+	fieldType, ok := fields[f.Field]
+	if !ok {
+		return f, fmt.Errorf("%w: unknown filter %s", ErrInvalidListOptions, name)
+	}
+	if !supportsOp(fieldType, f.Op) {
+		return f, fmt.Errorf("%w: operator %s is not supported by %s", ErrInvalidListOptions, f.Op, f.Field)
+	}
+
+	var err error
+	switch fieldType {
+	case BoolField:
+		f.Value, err = strconv.ParseBool(value)
+	case IntField:
+		f.Value, err = strconv.ParseInt(value, 10, 64)
+	case FloatField:
+		f.Value, err = strconv.ParseFloat(value, 64)
+	case TimeField:
+		f.Value, err = time.Parse(time.RFC3339, value)
+	default:
+		f.Value = value
+	}
+	if err != nil {
+		return f, fmt.Errorf("%w: invalid value %q of filter %s", ErrInvalidListOptions, value, name)
+	}
+	return f, nil
+}
+
+// ParseSort parses the comma separated sort fields, a - prefix sorts in descending order, e.g. -price,name
+func ParseSort(s string, fields map[string]FieldType) ([]Sort, error) {
+	var sorts []Sort
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		sort := Sort{Field: field}
+		if strings.HasPrefix(field, "-") {
+			sort = Sort{Field: field[1:], Desc: true}
+		}
+		if _, ok := fields[sort.Field]; !ok {
+			return nil, fmt.Errorf("%w: unknown sort field %s", ErrInvalidListOptions, sort.Field)
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// normalize applies the default and maximum limit and the default sort, the id is always the last sort field
+// so that the order, and therefore the cursor, is deterministic
+func (o ListOptions) normalize() ListOptions {
+	if o.Limit <= 0 {
+		o.Limit = DefaultLimit
+	}
+	if o.Limit > MaxLimit {
+		o.Limit = MaxLimit
+	}
+	sorts := append([]Sort{}, o.Sort...)
+	if len(sorts) == 0 {
+		sorts = append(sorts, Sort{Field: "createdAt"})
+	}
+	for _, s := range sorts {
+		if s.Field == "id" {
+			o.Sort = sorts
+			return o
+		}
+	}
+	o.Sort = append(sorts, Sort{Field: "id"})
+	return o
+}
+
+func supportsOp(fieldType FieldType, op string) bool {
+	for _, supported := range FieldOps[fieldType] {
+		if supported == op {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCursor encodes the sort key of the last listed item into an opaque cursor
+func encodeCursor(key interface{}) string {
+	b, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor decodes the cursor into the sort key of the last listed item
+func decodeCursor(cursor string, key interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(b, key)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: invalid cursor", ErrInvalidListOptions)
+	}
+	return nil
+}
+
+func matchString(v string, f Filter) bool {
+	s := f.Value.(string)
+	switch f.Op {
+	case OpNe:
+		return v != s
+	case OpContains:
+		return strings.Contains(v, s)
+	}
+	return v == s
+}
+
+func matchBool(v bool, f Filter) bool {
+	if f.Op == OpNe {
+		return v != f.Value.(bool)
+	}
+	return v == f.Value.(bool)
+}
+
+func matchInt(v int64, f Filter) bool {
+	return matchComparison(compareInt(v, f.Value.(int64)), f.Op)
+}
+
+func matchFloat(v float64, f Filter) bool {
+	return matchComparison(compareFloat(v, f.Value.(float64)), f.Op)
+}
+
+func matchTime(v time.Time, f Filter) bool {
+	return matchComparison(compareTime(v, f.Value.(time.Time)), f.Op)
+}
+
+// matchComparison reports whether the result of a comparison satisfies the operator
+func matchComparison(c int, op string) bool {
+	switch op {
+	case OpNe:
+		return c != 0
+	case OpGt:
+		return c > 0
+	case OpGte:
+		return c >= 0
+	case OpLt:
+		return c < 0
+	case OpLte:
+		return c <= 0
+	}
+	return c == 0
+}
+
+func compareString(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	}
+	return 1
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+`)
+}
+
+// RepositoriesTemplate returns template for pkg/repository/repositories.go
+func RepositoriesTemplate() []byte {
+	return []byte(`// Code generated by crud. DO NOT EDIT.
+
+package repository
+
+import (
+{{- if .HasReferences }}
+	"context"
+{{- end }}
+	"fmt"
+{{- if .DecoratesRepositories }}
+{{ end }}
+{{- if .Cached }}
+	"{{ .ModuleName }}/pkg/cache"
+{{- end }}
+{{- if .Publishes }}
+	"{{ .ModuleName }}/pkg/events"
+{{- end }}
+{{- if and .DecoratesRepositories .Resources }}
+	"{{ .ModuleName }}/pkg/models"
+{{- end }}
+)
+
+// Repositories holds the repositories of the resources added with crud add resource
+type Repositories struct {
+{{- range .Resources }}
+	{{ .GoName }} {{ .GoName }}Repository
+{{- end }}
+}
+
+// NewRepositories returns the in-memory repositories of the resources, the repositories of the resources which
+// reference other resources check that they exist
+func NewRepositories() *Repositories {
+	repos := &Repositories{
+{{- range .Resources }}
+		{{ .GoName }}: New{{ .GoName }}MemoryRepository(),
+{{- end }}
+	}
+{{- range .Resources }}
+{{- if .References }}
+	repos.{{ .GoName }} = new{{ .GoName }}ReferencesRepository(repos.{{ .GoName }}{{ range .References }}, repos.{{ .GoName }}{{ end }})
+{{- end }}
+{{- end }}
+{{- range .Resources }}
+{{- if $.ReferencingResources . }}
+	repos.{{ .GoName }} = &{{ .VarName }}ReferencedRepository{ {{- .GoName }}Repository: repos.{{ .GoName }}{{ range $.ReferencingResources . }}, {{ .VarPluralName }}: repos.{{ .GoName }}{{ end }}}
+{{- end }}
+{{- end }}
+	return repos
+}
+
+// ReferencedError is returned when a resource referenced by other resources is deleted, e.g. the customer of orders,
+// it is an ErrConflict
+type ReferencedError struct {
+	// Resource is the name of the deleted resource, Field the json name of the field referencing it and Referrers the
+	// plural name of the resources referencing it
+	Resource  string
+	ID        string
+	Field     string
+	Referrers string
+}
+
+func (e *ReferencedError) Error() string {
+	return fmt.Sprintf("%s %s can't be deleted, it is referenced by the %s of %s", e.Resource, e.ID, e.Field, e.Referrers)
+}
+
+// Unwrap returns ErrConflict
+func (e *ReferencedError) Unwrap() error {
+	return ErrConflict
+}
+{{- if .HasReferences }}
+
+// checkReferrers returns a ReferencedError if the list of the referrers, listed with the filters of the referencing
+// field, isn't empty
+func checkReferrers[T any](ctx context.Context, list func(context.Context, ListOptions) ([]T, Page, error), filters []Filter, err *ReferencedError) error {
+	_, page, listErr := list(ctx, ListOptions{Limit: 1, Filters: filters})
+	if listErr != nil {
+		return listErr
+	}
+	if page.Total > 0 {
+		return err
+	}
+	return nil
+}
+{{- end }}
+{{- range $referenced := .Resources }}
+{{- if $.ReferencingResources $referenced }}
+
+// {{ .VarName }}ReferencedRepository decorates the {{ .GoName }}Repository with the check that no resource references the
+// {{ .HumanName }} before it is deleted, the check and the delete are not atomic
+type {{ .VarName }}ReferencedRepository struct {
+	{{ .GoName }}Repository
+{{- range $.ReferencingResources . }}
+	{{ .VarPluralName }} {{ .GoName }}Repository
+{{- end }}
+}
+
+// Delete removes the {{ .HumanName }} or returns a ReferencedError if a resource references it
+func (r *{{ .VarName }}ReferencedRepository) Delete(ctx context.Context, id string) error {
+{{- range $referencing := $.ReferencingResources . }}
+{{- range .Fields }}
+{{- if eq .References $referenced.Name }}
+	if err := checkReferrers(ctx, r.{{ $referencing.VarPluralName }}.List, []Filter{
+		{Field: "{{ .JSONName }}", Op: OpEq, Value: id},
+{{- if eq $referencing.Name $referenced.Name }}
+		// the {{ $referenced.HumanName }} referencing itself can be deleted
+		{Field: "id", Op: OpNe, Value: id},
+{{- end }}
+	}, &ReferencedError{Resource: "{{ $referenced.HumanName }}", ID: id, Field: "{{ .JSONName }}", Referrers: "{{ $referencing.HumanPluralName }}"}); err != nil {
+		return err
+	}
+{{- end }}
+{{- end }}
+{{- end }}
+	return r.{{ .GoName }}Repository.Delete(ctx, id)
+}
+{{- end }}
+{{- end }}
+{{- if .HasNullableFields }}
+
+// valueOf returns the value of the nullable field or the zero value of its type if it is null, the nullable fields
+// are matched and sorted by it
+func valueOf[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+{{- end }}
+{{- if .Cached }}
+
+// NewCachedRepositories decorates the repositories with the read-through cache
+func NewCachedRepositories(repos *Repositories, c cache.Cache, ttl CacheTTL) *Repositories {
+	return &Repositories{
+{{- range .Resources }}
+		{{ .GoName }}: newCachedRepository[models.{{ .GoName }}](repos.{{ .GoName }}, c, ttl, "{{ .PathName }}", func(m *models.{{ .GoName }}) string { return m.ID }),
+{{- end }}
+	}
+}
+{{- end }}
+{{- if .Publishes }}
+
+// NewPublishingRepositories decorates the repositories with the publisher of the events of the writes
+func NewPublishingRepositories(repos *Repositories, publisher events.Publisher) *Repositories {
+	return &Repositories{
+{{- range .Resources }}
+		{{ .GoName }}: newPublishingRepository[models.{{ .GoName }}](repos.{{ .GoName }}, publisher, "{{ $.ProjectDirName }}.{{ .Name }}", func(m *models.{{ .GoName }}) string { return m.ID }),
+{{- end }}
+	}
+}
+{{- end }}
+`)
+}
+
+// HandlersTemplate returns template for pkg/handlers/handlers.go
+func HandlersTemplate() []byte {
+	return []byte(`package handlers
+
+import (
+{{- if or (eq .Router "gin") (eq .Router "echo") }}
+	"context"
+{{- end }}
+	"encoding/json"
+	"log"
+	"net/http"
+{{- if and .RouterImport (ne .Router "gin") (ne .Router "echo") }}
+
+	"{{ .RouterImport }}"
+{{- end }}
+)
+
+// maxBodyBytes is the maximum size of a request body
+const maxBodyBytes = 1 << 20
+{{ if eq .Router "chi" }}
+// pathParam returns the value of the named path parameter of the route
+func pathParam(r *http.Request, name string) string {
+	return chi.URLParam(r, name)
+}
+{{- else if eq .Router "stdlib" }}
+// pathParam returns the value of the named path parameter of the route
+func pathParam(r *http.Request, name string) string {
+	return r.PathValue(name)
+}
+{{- else if or (eq .Router "gin") (eq .Router "echo") }}
+// pathParamsKey is the request context key of the path parameters
+type pathParamsKey struct{}
+
+// WithPathParams returns the request with the path parameters of the route, it is used by the
+// {{ .Router }} adapter in pkg/routes
+func WithPathParams(r *http.Request, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
+
+// pathParam returns the value of the named path parameter of the route
+func pathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params[name]
+}
+{{- else }}
+// pathParam returns the value of the named path parameter of the route
+func pathParam(r *http.Request, name string) string {
+	return mux.Vars(r)[name]
+}
+{{- end }}
+
+// decodeJSON decodes the json request body into v, unknown fields are rejected
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// writeJSON writes v as json response with the status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("error writing response:", err)
+	}
+}
+
+// listResponse is the response of the list endpoints
+type listResponse struct {
+	Items interface{} ` + "`" + `json:"items"` + "`" + `
+	// NextCursor is passed as cursor query parameter to list the next page, it is omitted on the last page
+	NextCursor string ` + "`" + `json:"nextCursor,omitempty"` + "`" + `
+	// Total is the number of items matching the filters
+	Total int ` + "`" + `json:"total"` + "`" + `
+}
+
+`)
+}
+
+// ResourceRoutesTemplate returns template for pkg/routes/resources.go
+func ResourceRoutesTemplate() []byte {
+	return []byte(`// Code generated by crud. DO NOT EDIT.
+
+package routes
+
+import (
+{{- if or .Resources (eq .Router "stdlib") }}
+	"net/http"
+
+{{ end -}}
+{{- if .HasAuthorization }}
+	"{{ .ModuleName }}/pkg/auth"
+{{- end }}
+{{- if .Resources }}
+	"{{ .ModuleName }}/pkg/handlers"
+{{- end }}
+{{- if .Authenticated }}
+	"{{ .ModuleName }}/pkg/middleware"
+{{- end }}
+{{- if .RateLimit }}
+	"{{ .ModuleName }}/pkg/ratelimit"
+{{- end }}
+	"{{ .ModuleName }}/pkg/repository"
+{{- if .RouterImport }}
+
+	"{{ .RouterImport }}"
+{{- end }}
+)
+
+// registerResources registers the CRUD routes of the resources added with crud add resource
+{{- if .Authenticated }}, they are authenticated
+// with the authn middleware{{ if .RateLimit }}, limited by the limiter{{ end }} and require the scopes and roles of the resource
+{{- else if .RateLimit }}, they are limited
+// by the limiter with the rate limits of the resource
+{{- end }}
+func registerResources(r {{ .RouterType }}, repos *repository.Repositories{{ if .Authenticated }}, authn middleware.Middleware{{ end }}{{ if .RateLimit }}, limiter *ratelimit.Limiter{{ end }}) {
+{{- range .Resources }}
+	{{ .VarName }}Handler := handlers.New{{ .GoName }}Handler(repos.{{ .GoName }})
+	{{ $.Route "GET" (printf "/%s" .PathName) ($.ResourceHandler . "list") }}
+	{{ $.Route "POST" (printf "/%s" .PathName) ($.ResourceHandler . "create") }}
+	{{ $.Route "GET" (printf "/%s/{id}" .PathName) ($.ResourceHandler . "get") }}
+	{{ $.Route "PUT" (printf "/%s/{id}" .PathName) ($.ResourceHandler . "update") }}
+	{{ $.Route "DELETE" (printf "/%s/{id}" .PathName) ($.ResourceHandler . "delete") }}
+{{ end -}}
+}
+
+// resourceMethods are the methods of the resource routes keyed by their pattern, the requests of other methods are
+// answered with the 405 problem
+var resourceMethods = map[string][]string{
+{{- range .Resources }}
+	"/{{ .PathName }}":      {http.MethodGet, http.MethodPost},
+	"/{{ .PathName }}/{id}": {http.MethodGet, http.MethodPut, http.MethodDelete},
+{{- end }}
+}
+`)
+}
+
+// ModelTemplate returns template for pkg/models/<resource>.go
+func ModelTemplate() []byte {
+	return []byte(`package models
+
+import "time"
+
+// {{ .Resource.GoName }} is the model of the {{ .Resource.HumanName }} resource
+type {{ .Resource.GoName }} struct {
+	ID string ` + "`" + `json:"id"` + "`" + `
+{{- range .Resource.Fields }}
+	{{ .GoName }} {{ .GoType }} ` + "`" + `{{ .Tag }}` + "`" + `
+{{- end }}
+{{- if .Resource.Owned }}
+	// OwnerID is the subject of the token which created the {{ .Resource.HumanName }}, it is set by the handlers
+	OwnerID string ` + "`" + `json:"ownerId"` + "`" + `
+{{- end }}
+	CreatedAt time.Time ` + "`" + `json:"createdAt"` + "`" + `
+	UpdatedAt time.Time ` + "`" + `json:"updatedAt"` + "`" + `
+}
+`)
+}
+
+// ResourceRepositoryTemplate returns template for pkg/repository/<resource>.go
+func ResourceRepositoryTemplate() []byte {
+	return []byte(`package repository
+
+import (
+	"context"
+{{- if .Resource.References }}
+	"errors"
+{{- end }}
+	"sort"
+	"sync"
+	"time"
+
+	"{{ .Project.ModuleName }}/pkg/models"
+)
+{{ with .Resource }}
+// {{ .GoName }}Fields are the fields of the {{ .HumanName }} which can be filtered and sorted by
+var {{ .GoName }}Fields = map[string]FieldType{
+	"id": StringField,
+{{- range .Fields }}
+	"{{ .JSONName }}": {{ .ListKind }}Field,
+{{- end }}
+{{- if .Owned }}
+	"ownerId": StringField,
+{{- end }}
+	"createdAt": TimeField,
+	"updatedAt": TimeField,
+}
+
+// {{ .GoName }}Repository stores the {{ .HumanPluralName }}
+type {{ .GoName }}Repository interface {
+	// List returns a page of the {{ .HumanPluralName }} matching the filters in the order of the options
+	List(ctx context.Context, opts ListOptions) ([]models.{{ .GoName }}, Page, error)
+	// Get returns the {{ .HumanName }} with the id or ErrNotFound
+	Get(ctx context.Context, id string) (models.{{ .GoName }}, error)
+	// Create stores a new {{ .HumanName }}, the id is generated if it is empty and ErrConflict is returned if it exists
+	Create(ctx context.Context, m *models.{{ .GoName }}) error
+	// Update replaces the {{ .HumanName }} with the id of m or returns ErrNotFound{{ if .Owned }}, the owner is kept{{ end }}
+	Update(ctx context.Context, m *models.{{ .GoName }}) error
+	// Delete removes the {{ .HumanName }} with the id or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+}
+
+// {{ .GoName }}MemoryRepository is an in-memory {{ .GoName }}Repository
+type {{ .GoName }}MemoryRepository struct {
+	mu    sync.RWMutex
+	items map[string]models.{{ .GoName }}
+}
+
+// New{{ .GoName }}MemoryRepository returns an empty in-memory {{ .GoName }}Repository
+func New{{ .GoName }}MemoryRepository() *{{ .GoName }}MemoryRepository {
+	return &{{ .GoName }}MemoryRepository{items: map[string]models.{{ .GoName }}{}}
+}
+
+// List returns a page of the {{ .HumanPluralName }} matching the filters in the order of the options,
+// the page after a cursor starts after the sort key encoded in the cursor so it is stable across inserts and deletes
+func (r *{{ .GoName }}MemoryRepository) List(ctx context.Context, opts ListOptions) ([]models.{{ .GoName }}, Page, error) {
+	opts = opts.normalize()
+	var after *models.{{ .GoName }}
+	if opts.Cursor != "" {
+		after = &models.{{ .GoName }}{}
+		if err := decodeCursor(opts.Cursor, after); err != nil {
+			return nil, Page{}, err
+		}
+	}
+
+	r.mu.RLock()
+	items := make([]models.{{ .GoName }}, 0, len(r.items))
+	for _, m := range r.items {
+		if match{{ .GoName }}(m, opts.Filters) {
+			items = append(items, m)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return compare{{ .GoPluralName }}(items[i], items[j], opts.Sort) < 0
+	})
+
+	start := opts.Offset
+	if after != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			return compare{{ .GoPluralName }}(items[i], *after, opts.Sort) > 0
+		})
+	}
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + opts.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := Page{Total: len(items)}
+	if end < len(items) {
+		page.NextCursor = encodeCursor({{ .VarName }}SortKey(items[end-1], opts.Sort))
+	}
+	return items[start:end], page, nil
+}
+
+// Get returns the {{ .HumanName }} with the id or ErrNotFound
+func (r *{{ .GoName }}MemoryRepository) Get(ctx context.Context, id string) (models.{{ .GoName }}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.items[id]
+	if !ok {
+		return models.{{ .GoName }}{}, ErrNotFound
+	}
+	return m, nil
+}
+
+// Create stores a new {{ .HumanName }}, the id is generated if it is empty and ErrConflict is returned if it exists
+func (r *{{ .GoName }}MemoryRepository) Create(ctx context.Context, m *models.{{ .GoName }}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.ID == "" {
+		m.ID = NewID()
+	}
+	if _, ok := r.items[m.ID]; ok {
+		return ErrConflict
+	}
+	m.CreatedAt = time.Now().UTC()
+	m.UpdatedAt = m.CreatedAt
+	r.items[m.ID] = *m
+	return nil
+}
+
+// Update replaces the {{ .HumanName }} with the id of m or returns ErrNotFound
+func (r *{{ .GoName }}MemoryRepository) Update(ctx context.Context, m *models.{{ .GoName }}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.items[m.ID]
+	if !ok {
+		return ErrNotFound
+	}
+{{- if .Owned }}
+	m.OwnerID = existing.OwnerID
+{{- end }}
+	m.CreatedAt = existing.CreatedAt
+	m.UpdatedAt = time.Now().UTC()
+	r.items[m.ID] = *m
+	return nil
+}
+
+// Delete removes the {{ .HumanName }} with the id or returns ErrNotFound
+func (r *{{ .GoName }}MemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.items[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.items, id)
+	return nil
+}
+
+// match{{ .GoName }} reports whether the {{ .HumanName }} matches all the filters
+func match{{ .GoName }}(m models.{{ .GoName }}, filters []Filter) bool {
+	for _, f := range filters {
+		var ok bool
+		switch f.Field {
+		case "id":
+			ok = matchString(m.ID, f)
+{{- range .Fields }}
+		case "{{ .JSONName }}":
+			ok = match{{ .ListKind }}({{ .ListValue "m" }}, f)
+{{- end }}
+{{- if .Owned }}
+		case "ownerId":
+			ok = matchString(m.OwnerID, f)
+{{- end }}
+		case "createdAt":
+			ok = matchTime(m.CreatedAt, f)
+		case "updatedAt":
+			ok = matchTime(m.UpdatedAt, f)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compare{{ .GoPluralName }} compares the {{ .HumanPluralName }} by the sort fields in order
+func compare{{ .GoPluralName }}(a, b models.{{ .GoName }}, sorts []Sort) int {
+	for _, s := range sorts {
+		var c int
+		switch s.Field {
+		case "id":
+			c = compareString(a.ID, b.ID)
+{{- range .Fields }}
+		case "{{ .JSONName }}":
+			c = compare{{ .ListKind }}({{ .ListValue "a" }}, {{ .ListValue "b" }})
+{{- end }}
+{{- if .Owned }}
+		case "ownerId":
+			c = compareString(a.OwnerID, b.OwnerID)
+{{- end }}
+		case "createdAt":
+			c = compareTime(a.CreatedAt, b.CreatedAt)
+		case "updatedAt":
+			c = compareTime(a.UpdatedAt, b.UpdatedAt)
+		}
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// {{ .VarName }}SortKey returns the {{ .HumanName }} with only the sort fields set, it is encoded into the cursor
+func {{ .VarName }}SortKey(m models.{{ .GoName }}, sorts []Sort) models.{{ .GoName }} {
+	key := models.{{ .GoName }}{ID: m.ID}
+	for _, s := range sorts {
+		switch s.Field {
+{{- range .Fields }}
+		case "{{ .JSONName }}":
+			key.{{ .GoName }} = m.{{ .GoName }}
+{{- end }}
+{{- if .Owned }}
+		case "ownerId":
+			key.OwnerID = m.OwnerID
+{{- end }}
+		case "createdAt":
+			key.CreatedAt = m.CreatedAt
+		case "updatedAt":
+			key.UpdatedAt = m.UpdatedAt
+		}
+	}
+	return key
+}
+{{- if .References }}
+
+// {{ .VarName }}ReferencesRepository decorates the {{ .GoName }}Repository with the check that the resources referenced
+// by the {{ .HumanName }} exist before it is created or updated
+type {{ .VarName }}ReferencesRepository struct {
+	{{ .GoName }}Repository
+{{- range .References }}
+	{{ .VarPluralName }} {{ .GoName }}Repository
+{{- end }}
+}
+
+// new{{ .GoName }}ReferencesRepository returns the repository checking the references of the {{ .HumanPluralName }}
+func new{{ .GoName }}ReferencesRepository(repo {{ .GoName }}Repository{{ range .References }}, {{ .VarPluralName }} {{ .GoName }}Repository{{ end }}) *{{ .VarName }}ReferencesRepository {
+	return &{{ .VarName }}ReferencesRepository{ {{- .GoName }}Repository: repo{{ range .References }}, {{ .VarPluralName }}: {{ .VarPluralName }}{{ end }}}
+}
+
+// Create stores a new {{ .HumanName }} or returns a ReferenceError if a resource it references doesn't exist
+func (r *{{ .VarName }}ReferencesRepository) Create(ctx context.Context, m *models.{{ .GoName }}) error {
+	if err := r.check(ctx, m); err != nil {
+		return err
+	}
+	return r.{{ .GoName }}Repository.Create(ctx, m)
+}
+
+// Update replaces the {{ .HumanName }} or returns a ReferenceError if a resource it references doesn't exist
+func (r *{{ .VarName }}ReferencesRepository) Update(ctx context.Context, m *models.{{ .GoName }}) error {
+	if err := r.check(ctx, m); err != nil {
+		return err
+	}
+	return r.{{ .GoName }}Repository.Update(ctx, m)
+}
+
+// check returns a ReferenceError for the first referenced resource which doesn't exist, empty and null references
+// are not checked, the required rule of the field rejects them
+func (r *{{ .VarName }}ReferencesRepository) check(ctx context.Context, m *models.{{ .GoName }}) error {
+{{- range .Fields }}
+{{- if .References }}
+	if {{ if .Nullable }}m.{{ .GoName }} != nil && {{ end }}{{ .Value "m" }} != "" {
+		if _, err := r.{{ .Reference.VarPluralName }}.Get(ctx, {{ .Value "m" }}); errors.Is(err, ErrNotFound) {
+			return &ReferenceError{Field: "{{ .JSONName }}", Resource: "{{ .Reference.HumanName }}", ID: {{ .Value "m" }}}
+		} else if err != nil {
+			return err
+		}
+	}
+{{- end }}
+{{- end }}
+	return nil
+}
+{{- end }}
+{{ end }}
+`)
+}
+
+// ResourceHandlerTemplate returns template for pkg/handlers/<resource>.go
+func ResourceHandlerTemplate() []byte {
+	return []byte(`package handlers
+
+import (
+	"net/http"
+
+	"{{ .Project.ModuleName }}/pkg/apierror"
+{{- if .Resource.Owned }}
+	"{{ .Project.ModuleName }}/pkg/auth"
+{{- end }}
+	"{{ .Project.ModuleName }}/pkg/models"
+	"{{ .Project.ModuleName }}/pkg/repository"
+)
+{{ with .Resource }}
+// {{ .GoName }}Handler serves the CRUD endpoints of the {{ .HumanName }} resource
+type {{ .GoName }}Handler struct {
+	repo repository.{{ .GoName }}Repository
+}
+
+// New{{ .GoName }}Handler returns the handler serving the {{ .HumanPluralName }} of the repository
+func New{{ .GoName }}Handler(repo repository.{{ .GoName }}Repository) *{{ .GoName }}Handler {
+	return &{{ .GoName }}Handler{repo: repo}
+}
+
+// List serves GET /{{ .PathName }}, see repository.ParseListQuery for the pagination, filter and sort parameters
+func (h *{{ .GoName }}Handler) List(w http.ResponseWriter, r *http.Request) {
+	opts, err := repository.ParseListQuery(r.URL.Query(), repository.{{ .GoName }}Fields)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+{{- if .OwnerOnly "list" }}
+	// only the {{ .HumanPluralName }} owned by the subject of the request are listed
+	opts.Filters = append(opts.Filters, repository.Filter{Field: "ownerId", Op: repository.OpEq, Value: auth.Subject(r.Context())})
+{{- end }}
+	items, page, err := h.repo.List(r.Context(), opts)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Items: items, NextCursor: page.NextCursor, Total: page.Total})
+}
+
+// Get serves GET /{{ .PathName }}/{id}
+func (h *{{ .GoName }}Handler) Get(w http.ResponseWriter, r *http.Request) {
+	m, err := h.repo.Get(r.Context(), pathParam(r, "id"))
+{{- if .OwnerOnly "get" }}
+	if err == nil && m.OwnerID != auth.Subject(r.Context()) {
+		// the {{ .HumanPluralName }} of other owners are not revealed
+		err = repository.ErrNotFound
+	}
+{{- end }}
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+// Create serves POST /{{ .PathName }}
+func (h *{{ .GoName }}Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var m models.{{ .GoName }}
+	if err := decodeJSON(w, r, &m); err != nil {
+		apierror.Write(w, r, apierror.BadRequest(err.Error()))
+		return
+	}
+	if err := models.Validate(m); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+{{- if .Owned }}
+	m.OwnerID = auth.Subject(r.Context())
+{{- end }}
+	if err := h.repo.Create(r.Context(), &m); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/{{ .PathName }}/"+m.ID)
+	writeJSON(w, http.StatusCreated, m)
+}
+
+// Update serves PUT /{{ .PathName }}/{id}
+func (h *{{ .GoName }}Handler) Update(w http.ResponseWriter, r *http.Request) {
+	var m models.{{ .GoName }}
+	if err := decodeJSON(w, r, &m); err != nil {
+		apierror.Write(w, r, apierror.BadRequest(err.Error()))
+		return
+	}
+	m.ID = pathParam(r, "id")
+	if err := models.Validate(m); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+{{- if .OwnerOnly "update" }}
+	if err := h.checkOwner(r, m.ID, "update"); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+{{- end }}
+	if err := h.repo.Update(r.Context(), &m); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+// Delete serves DELETE /{{ .PathName }}/{id}
+func (h *{{ .GoName }}Handler) Delete(w http.ResponseWriter, r *http.Request) {
+{{- if .OwnerOnly "delete" }}
+	if err := h.checkOwner(r, pathParam(r, "id"), "delete"); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+{{- end }}
+	if err := h.repo.Delete(r.Context(), pathParam(r, "id")); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+{{- if or (.OwnerOnly "update") (.OwnerOnly "delete") }}
+
+// checkOwner returns ErrNotFound if the {{ .HumanName }} with the id doesn't exist or 403 Forbidden unless the subject
+// of the request owns it
+func (h *{{ .GoName }}Handler) checkOwner(r *http.Request, id, action string) error {
+	existing, err := h.repo.Get(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return auth.CheckOwner(r.Context(), existing.OwnerID, action, "{{ .HumanName }}")
+}
+{{- end }}
+{{ end }}
+`)
+}
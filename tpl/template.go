@@ -26,74 +26,540 @@ func MainTemplate() []byte {
 	return []byte(`package main
 
 import (
+{{- if or .GRPC (.HasAuth "jwt") .Cached .Publishes }}
 	"context"
+{{- end }}
+	"crypto/tls"
+{{- if .Outbox }}
+	"database/sql"
+{{- end }}
 	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
 	"time"
 
+{{- if .Authenticated }}
+	"{{ .ModuleName }}/pkg/auth"
+{{- end }}
+{{- if .Cached }}
+	"{{ .ModuleName }}/pkg/cache"
+{{- end }}
 	"{{ .ModuleName }}/pkg/conf"
+{{- if .Publishes }}
+	"{{ .ModuleName }}/pkg/events"
+{{- end }}
+{{- if .GRPC }}
+	"{{ .ModuleName }}/pkg/grpcserver"
+{{- end }}
+	"{{ .ModuleName }}/pkg/lifecycle"
+{{- if .Metrics }}
+	"{{ .ModuleName }}/pkg/metrics"
+{{- end }}
+{{- if .RateLimit }}
+	"{{ .ModuleName }}/pkg/ratelimit"
+{{- end }}
+	"{{ .ModuleName }}/pkg/repository"
 	"{{ .ModuleName }}/pkg/routes"
 	"{{ .ModuleName }}/pkg/utils"
-
-	"github.com/gorilla/mux"
+{{- if or .GRPC .Cached .Outbox }}
+{{ end }}
+{{- if .GRPC }}
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+{{- end }}
+{{- if .Cached }}
+	"github.com/redis/go-redis/v9"
+{{- end }}
+{{- if .Outbox }}
+	_ "github.com/jackc/pgx/v5/stdlib"
+{{- end }}
 )
 
+// version of the service, it is set at build time with -ldflags "-X main.version=<version>"
+var version = "dev"
+
 func init() {
-	utils.LoadEnvConfig(&conf.Env)
+	if err := utils.LoadEnvConfig(&conf.Env); err != nil {
+		log.Fatalln("error loading env config:", err)
+	}
 }
 
 func main() {
-	
+
 	var wait time.Duration
+	var healthcheck bool
 	flag.DurationVar(&wait, "graceful-timeout", time.Second*15, "the duration for which the server gracefully wait for existing connections to finish - e.g. 15s or 1m")
+	flag.BoolVar(&healthcheck, "healthcheck", false, "probe the /healthz endpoint of the running server and exit, used by the docker HEALTHCHECK")
 	flag.Parse()
 
+	if healthcheck {
+		if err := healthCheck(); err != nil {
+			log.Fatalln("health check failed:", err)
+		}
+		return
+	}
+
+	log.Println("starting {{ .ProjectDirName }} version", version)
+
+	// create the repositories of the resources
+	repos := repository.NewRepositories()
+{{- if .Publishes }}
+
+	// publish the domain events of the writes
+{{- if .Outbox }} to the outbox, the relay publishes them to the broker
+{{- end }}
+{{- if eq .Events "kafka" }}
+	publisher := events.NewKafkaPublisher(conf.Env.KafkaBrokers, conf.Env.KafkaTopic)
+{{- else if eq .Events "nats" }}
+	publisher, err := events.NewNATSPublisher(conf.Env.NATSURL, conf.Env.NATSSubjectPrefix)
+	if err != nil {
+		log.Fatalln("error connecting to nats:", err)
+	}
+{{- else }}
+	publisher := events.NewLogPublisher()
+{{- end }}
+{{- if .Outbox }}
+	db, err := sql.Open("pgx", conf.Env.DatabaseURL)
+	if err != nil {
+		log.Fatalln("error opening database:", err)
+	}
+	outbox := events.NewOutbox(db)
+	repos = repository.NewPublishingRepositories(repos, outbox)
+{{- else }}
+	repos = repository.NewPublishingRepositories(repos, publisher)
+{{- end }}
+{{- end }}
+{{- if .Cached }}
+
+	// cache the resources in redis, or in the process if REDIS_ADDR is not set
+	var store cache.Cache = cache.NewLRU(conf.Env.CacheLRUSize)
+	var redisClient *redis.Client
+	if conf.Env.RedisAddr != "" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     conf.Env.RedisAddr,
+			Password: conf.Env.RedisPassword,
+			DB:       conf.Env.RedisDB,
+		})
+		store = cache.NewRedis(redisClient, "{{ .ProjectDirName }}:")
+	}
+	repos = repository.NewCachedRepositories(repos, store, repository.CacheTTL{Get: conf.Env.CacheTTL, List: conf.Env.CacheListTTL})
+{{- end }}
+
 	// create a router
-	r := mux.NewRouter()
+	r := routes.NewRouter()
 
-	routes.Routes(r)
-	
-	// start the server
+{{- if .HasAuth "jwt" }}
+
+	// create the authenticator of the bearer tokens, the JWKS is refreshed until the service shuts down
+	authCtx, cancelAuth := context.WithCancel(context.Background())
+	defer cancelAuth()
+	authenticator, err := auth.New(authCtx, auth.Config{
+		Issuer:    conf.Env.JWTIssuer,
+		Audience:  conf.Env.JWTAudience,
+		JWKSURL:   conf.Env.JWTJWKSURL,
+		PublicKey: conf.Env.JWTPublicKey,
+		Secret:    conf.Env.JWTSecret,
+		Leeway:    conf.Env.JWTLeeway,
+	})
+	if err != nil {
+		log.Fatalln("error creating authenticator:", err)
+	}
+{{- end }}
+{{- if .HasAuth "apikey" }}
+
+	// create the authenticator of the api keys, the key file is read again when it changes
+	keyStore, err := auth.NewFileKeyStore(conf.Env.APIKeysFile, conf.Env.APIKeysReloadInterval)
+	if err != nil {
+		log.Fatalln("error loading api keys:", err)
+	}
+	apiKeys := auth.NewAPIKeyAuthenticator(auth.APIKeyConfig{
+		Header:    conf.Env.APIKeyHeader,
+		Store:     keyStore,
+		RateLimit: conf.Env.APIKeyRateLimit,
+		Burst:     conf.Env.APIKeyBurst,
+	})
+{{- end }}
+{{- if .RateLimit }}
+
+	// create the limiter of the resource routes, the buckets of the clients are kept in memory so every instance
+	// of the service limits the clients on its own, a shared ratelimit.Store limits them across the instances
+	routeLimits, err := ratelimit.ParseRoutes(conf.Env.RateLimitRoutes)
+	if err != nil {
+		log.Fatalln("error parsing RATE_LIMIT_ROUTES:", err)
+	}
+	limiter := ratelimit.New(ratelimit.Config{
+		Store:      ratelimit.NewMemoryStore(),
+		Default:    ratelimit.Limit{Rate: conf.Env.RateLimit, Burst: conf.Env.RateLimitBurst},
+		Routes:     routeLimits,
+		TrustProxy: conf.Env.RateLimitTrustProxy,
+{{- if .Authenticated }}
+		IP:         ratelimit.Limit{Rate: conf.Env.RateLimitIP, Burst: conf.Env.RateLimitIPBurst},
+{{- end }}
+	})
+{{- end }}
+{{- if .Authenticated }}
+
+	// the resource routes are authenticated with the first method whose credentials the request has
+	handler := routes.Routes(r, repos, auth.Middleware({{ .AuthMethods }}){{ if .RateLimit }}, limiter{{ end }})
+{{- else }}
+
+	handler := routes.Routes(r, repos{{ if .RateLimit }}, limiter{{ end }})
+{{- end }}
+{{- if .Metrics }}
+
+	// record the metrics of the requests by route, they are served on METRICS_LISTEN_ADDR apart from the api
+	requestMetrics := metrics.New()
+	handler = requestMetrics.Middleware(routes.Pattern)(handler)
+	metricsSrv := &http.Server{
+		Addr:         conf.Env.MetricsListenAddr,
+		WriteTimeout: conf.Env.WriteTimeout,
+		ReadTimeout:  conf.Env.ReadTimeout,
+		IdleTimeout:  conf.Env.IdleTimeout,
+		Handler:      requestMetrics.Handler(),
+	}
+{{- end }}
+
+	// create the server
 	srv := &http.Server{
-		Addr: "0.0.0.0:8080",
+		Addr: conf.Env.ListenAddr,
 		// Good practice to set timeouts to avoid Slowloris attacks.
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      r, // Pass our instance of gorilla/mux in.
+		WriteTimeout: conf.Env.WriteTimeout,
+		ReadTimeout:  conf.Env.ReadTimeout,
+		IdleTimeout:  conf.Env.IdleTimeout,
+		Handler:      handler, // Pass our router wrapped with the middleware in.
 	}
 
-	// Run our server in a goroutine so that it doesn't block.
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			log.Println(err)
+	// serve TLS if the certificate and key files are provided, the certificate is reloaded when the files change
+	if conf.Env.TLSCertFile != "" {
+		certReloader, err := utils.NewCertReloader(conf.Env.TLSCertFile, conf.Env.TLSKeyFile, conf.Env.TLSReloadInterval)
+		if err != nil {
+			log.Fatalln("error loading tls certificate:", err)
 		}
-	}()
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certReloader.GetCertificate,
+		}
+	}
+{{- if .GRPC }}
 
-	log.Println("http server started at port 8080")
+	// create the grpc server serving the resources added with --grpc on the same repositories
+	var grpcOpts []grpc.ServerOption
+	if srv.TLSConfig != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(srv.TLSConfig)))
+	}
+{{- if .Authenticated }}
+	grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(grpcserver.MethodRules, {{ .AuthMethods }})))
+{{- end }}
+	grpcSrv := grpc.NewServer(grpcOpts...)
+	grpcserver.Register(grpcSrv, repos)
+{{- end }}
 
-	c := make(chan os.Signal, 1)
-	// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C)
-	// SIGKILL, SIGQUIT or SIGTERM (Ctrl+/) will not be caught.
-	signal.Notify(c, os.Interrupt)
+	// Register the components in the order they should be started, e.g. database pools before the http server
+	// which uses them. They are stopped in the reverse order on SIGINT or SIGTERM within the graceful-timeout.
+	manager := lifecycle.New(wait)
+{{- if .Outbox }}
+	manager.Register("database", lifecycle.Hooks{
+		OnStart: func(ctx context.Context, _ chan<- error) error { return db.PingContext(ctx) },
+		OnStop:  func(context.Context) error { return db.Close() },
+	})
+{{- end }}
+{{- if .Cached }}
+	if redisClient != nil {
+		manager.Register("redis", lifecycle.Hooks{
+			OnStart: func(ctx context.Context, _ chan<- error) error { return redisClient.Ping(ctx).Err() },
+			OnStop:  func(context.Context) error { return redisClient.Close() },
+		})
+	}
+{{- end }}
+{{- if .Publishes }}
+	manager.Register("event publisher", lifecycle.Hooks{
+		OnStop: func(context.Context) error { return publisher.Close() },
+	})
+{{- end }}
+{{- if .Outbox }}
+	manager.Register("outbox relay", events.NewRelay(outbox, publisher, conf.Env.OutboxPollInterval, conf.Env.OutboxBatchSize))
+{{- end }}
+{{- if .Metrics }}
+	manager.Register("metrics server", lifecycle.HTTPServer(metricsSrv))
+{{- end }}
+{{- if .GRPC }}
+	if conf.Env.HTTPEnabled {
+		manager.Register("http server", lifecycle.HTTPServer(srv))
+	}
+	if conf.Env.GRPCEnabled {
+		manager.Register("grpc server", lifecycle.GRPCServer(grpcSrv, conf.Env.GRPCListenAddr))
+	}
+{{- else }}
+	manager.Register("http server", lifecycle.HTTPServer(srv))
+{{- end }}
 
-	// Block until we receive our signal.
-	<-c
+	if err := manager.Run(); err != nil {
+		log.Fatalln(err)
+	}
+	log.Println("shut down gracefully")
+}
 
-	// Create a deadline to wait for.
-	ctx, cancel := context.WithTimeout(context.Background(), wait)
+// healthCheck probes the /healthz endpoint of the server listening on conf.Env.ListenAddr
+{{- if .GRPC }}, or the grpc health
+// service if only grpc is served
+{{- end }}
+func healthCheck() error {
+{{- if .GRPC }}
+	if !conf.Env.HTTPEnabled {
+		return grpcHealthCheck()
+	}
+
+{{ end -}}
+	host, port, err := net.SplitHostPort(conf.Env.ListenAddr)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: 5 * time.Second}
+	if conf.Env.TLSCertFile != "" {
+		scheme = "https"
+		// the probe only checks that the local server is up, so the certificate is not verified
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+
+	resp, err := client.Get(fmt.Sprintf("%s://%s/healthz", scheme, net.JoinHostPort(host, port)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+{{- if .GRPC }}
+
+// grpcHealthCheck probes the grpc health service of the server listening on conf.Env.GRPCListenAddr
+func grpcHealthCheck() error {
+	host, port, err := net.SplitHostPort(conf.Env.GRPCListenAddr)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+
+	creds := insecure.NewCredentials()
+	if conf.Env.TLSCertFile != "" {
+		// the probe only checks that the local server is up, so the certificate is not verified
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+	}
+	conn, err := grpc.NewClient(net.JoinHostPort(host, port), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	// Doesn't block if no connections, but will otherwise wait
-	// until the timeout deadline.
-	srv.Shutdown(ctx)
-	// Optionally, you could run srv.Shutdown in a goroutine and block on
-	// <-ctx.Done() if your application should wait for other services
-	// to finalize based on context cancellation.
-	log.Println("shutting down")
-	os.Exit(0)
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("unexpected status %s", resp.GetStatus())
+	}
+	return nil
+}
+{{- end }}
+
+`)
+}
+
+// LifecycleTemplate returns template for pkg/lifecycle/lifecycle.go
+func LifecycleTemplate() []byte {
+	return []byte(`package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+{{- if .GRPC }}
+
+	"google.golang.org/grpc"
+{{- end }}
+)
+
+// Component is a part of the service, e.g. http server, database pool or background worker, managed by the Manager
+type Component interface {
+	// Start starts the component without blocking, long running work must run in its own goroutine
+	// and report unexpected failures on errs which shuts the service down
+	Start(ctx context.Context, errs chan<- error) error
+	// Stop gracefully stops the component before the deadline of ctx
+	Stop(ctx context.Context) error
+}
+
+// Hooks adapts start and stop functions to a Component, either of them can be nil
+type Hooks struct {
+	OnStart func(ctx context.Context, errs chan<- error) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Start calls OnStart if set
+func (h Hooks) Start(ctx context.Context, errs chan<- error) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx, errs)
+}
+
+// Stop calls OnStop if set
+func (h Hooks) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+// HTTPServer returns a Component which serves srv on srv.Addr, TLS is served if srv.TLSConfig is set
+func HTTPServer(srv *http.Server) Component {
+	return Hooks{
+		OnStart: func(ctx context.Context, errs chan<- error) error {
+			// listen synchronously so that errors like address already in use fail the start
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				var err error
+				if srv.TLSConfig != nil {
+					err = srv.ServeTLS(ln, "", "")
+				} else {
+					err = srv.Serve(ln)
+				}
+				if err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errs <- err
+				}
+			}()
+			log.Println("http server started at", ln.Addr())
+			return nil
+		},
+		OnStop: srv.Shutdown,
+	}
+}
+{{- if .GRPC }}
+
+// GRPCServer returns a Component which serves srv on addr
+func GRPCServer(srv *grpc.Server, addr string) Component {
+	return Hooks{
+		OnStart: func(ctx context.Context, errs chan<- error) error {
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+					errs <- err
+				}
+			}()
+			log.Println("grpc server started at", ln.Addr())
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				// close the pending rpcs once the graceful timeout is over
+				srv.Stop()
+				return ctx.Err()
+			}
+		},
+	}
+}
+{{- end }}
+
+type namedComponent struct {
+	name string
+	Component
+}
+
+// Manager starts the registered components in order and stops them in reverse order
+type Manager struct {
+	gracefulTimeout time.Duration
+	components      []namedComponent
+}
+
+// New returns a Manager which waits up to gracefulTimeout for the components to stop
+func New(gracefulTimeout time.Duration) *Manager {
+	return &Manager{gracefulTimeout: gracefulTimeout}
+}
+
+// Register adds the component, components are started in the order they are registered
+func (m *Manager) Register(name string, c Component) {
+	m.components = append(m.components, namedComponent{name: name, Component: c})
+}
+
+// Run starts the components and blocks until SIGINT or SIGTERM is received or a component fails,
+// then stops the started components in reverse order within the graceful timeout
+func (m *Manager) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// buffered so that components never block on reporting a failure after shutdown began
+	errs := make(chan error, len(m.components))
+
+	var runErr error
+	started := 0
+	for _, c := range m.components {
+		log.Println("starting", c.name)
+		if err := c.Start(ctx, errs); err != nil {
+			runErr = fmt.Errorf("error starting %s: %w", c.name, err)
+			break
+		}
+		started++
+	}
+
+	if runErr == nil {
+		select {
+		case <-ctx.Done():
+			log.Println("received shutdown signal")
+		case err := <-errs:
+			runErr = err
+		}
+	}
+
+	// restore the default signal handling so that a second signal terminates immediately
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.gracefulTimeout)
+	defer cancel()
+
+	for i := started - 1; i >= 0; i-- {
+		c := m.components[i]
+		log.Println("stopping", c.name)
+		if err := c.Stop(shutdownCtx); err != nil {
+			log.Println("error stopping", c.name, ":", err)
+			if runErr == nil {
+				runErr = fmt.Errorf("error stopping %s: %w", c.name, err)
+			}
+		}
+	}
+	return runErr
 }
 
 `)
@@ -104,13 +570,461 @@ func RoutesTemplate() []byte {
 	return []byte(`package routes
 
 import (
-	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+
+	"{{ .ModuleName }}/pkg/apierror"
+	"{{ .ModuleName }}/pkg/middleware"
+{{- if .RateLimit }}
+	"{{ .ModuleName }}/pkg/ratelimit"
+{{- end }}
+	"{{ .ModuleName }}/pkg/repository"
+{{- if .RouterImport }}
+
+	"{{ .RouterImport }}"
+{{- end }}
+)
+
+// Routes registers the routes of the service on the router and returns the handler serving them
+{{- if .Authenticated }}, the routes of
+// the resources are authenticated with the authn middleware{{ if .RateLimit }} and limited by the limiter, by ip address
+// before they are authenticated{{ end }}
+{{- else if .RateLimit }}, the routes of
+// the resources are limited by the limiter
+{{- end }}
+func Routes(r {{ .RouterType }}, repos *repository.Repositories{{ if .Authenticated }}, authn middleware.Middleware{{ end }}{{ if .RateLimit }}, limiter *ratelimit.Limiter{{ end }}) http.Handler {
+	{{ .Route "GET" "/healthz" "http.HandlerFunc(Healthz)" }}
+{{- if and .Authenticated .RateLimit }}
+
+	// the requests are limited by ip address before they are authenticated, so that the clients guessing
+	// credentials are limited as well
+	authenticate := authn
+	authn = func(next http.Handler) http.Handler {
+		return limiter.IP()(authenticate(next))
+	}
+{{- end }}
+
+	// routes of the resources added with crud add resource
+	registerResources(r, repos{{ if .Authenticated }}, authn{{ end }}{{ if .RateLimit }}, limiter{{ end }})
+
+	// the requests which match no route are answered with problems, the same with every router, the methods of
+	// the routes are checked by allowMethods before the requests are routed
+{{- if eq .Router "chi" }}
+	r.NotFound(NotFound)
+	r.MethodNotAllowed(MethodNotAllowed)
+{{- else if eq .Router "stdlib" }}
+	var h http.Handler = unmatched(r)
+{{- else if eq .Router "gin" }}
+	r.RedirectTrailingSlash = false
+	r.NoRoute(handle(http.HandlerFunc(NotFound)))
+	r.NoMethod(handle(http.HandlerFunc(MethodNotAllowed)))
+{{- else if eq .Router "echo" }}
+	r.HTTPErrorHandler = handleError
+{{- else }}
+	r.NotFoundHandler = http.HandlerFunc(NotFound)
+	r.MethodNotAllowedHandler = http.HandlerFunc(MethodNotAllowed)
+{{- end }}
+
+	// middleware applied to every request, the first one is the outermost
+	return middleware.Chain({{ if eq .Router "stdlib" }}h{{ else }}r{{ end }}, middleware.Logger, middleware.Recoverer, allowMethods)
+}
+
+// NewRouter returns the router the routes are registered on
+func NewRouter() {{ .RouterType }} {
+{{- if eq .Router "chi" }}
+	return chi.NewRouter()
+{{- else if eq .Router "stdlib" }}
+	return http.NewServeMux()
+{{- else if eq .Router "gin" }}
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	return r
+{{- else if eq .Router "echo" }}
+	r := echo.New()
+	r.HideBanner = true
+	r.HidePort = true
+	return r
+{{- else }}
+	return mux.NewRouter()
+{{- end }}
+}
+
+// Healthz reports that the service is up, it is probed by the docker HEALTHCHECK
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// NotFound responds with the 404 problem to the requests whose path matches no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, apierror.New(http.StatusNotFound, "no route matches the path"))
+}
+
+// MethodNotAllowed responds with the 405 problem to the requests whose path matches routes of other methods, the
+// Allow header lists the methods of the routes
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if methods := routeMethods(r.URL.Path); methods != nil {
+		w.Header().Set("Allow", allow(methods))
+	}
+	apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, "method "+r.Method+" is not allowed on the path"))
+}
+
+// allowMethods answers the requests whose path matches a route of the service the same with every router, whose
+// own handling of HEAD, OPTIONS and unknown methods differs. The requests of methods the routes don't have are
+// answered with the 405 problem, OPTIONS with 204 and HEAD is served by the GET route, the server doesn't write the
+// body of HEAD responses. Both list the methods of the routes in the Allow header.
+func allowMethods(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods := routeMethods(r.URL.Path)
+		switch {
+		case methods == nil:
+			// the routes registered in Routes along with the resource routes are left to the router
+			next.ServeHTTP(w, r)
+		case r.Method == http.MethodOptions:
+			w.Header().Set("Allow", allow(methods))
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodHead && hasMethod(methods, http.MethodGet):
+			get := *r
+			get.Method = http.MethodGet
+			next.ServeHTTP(w, &get)
+		case !hasMethod(methods, r.Method):
+			MethodNotAllowed(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// routeMethods returns the methods of the route matching the path, /healthz or a resource route, or nil
+func routeMethods(path string) []string {
+	if path == "/healthz" {
+		return []string{http.MethodGet}
+	}
+	for pattern, methods := range resourceMethods {
+		if matchPattern(pattern, path) {
+			return methods
+		}
+	}
+	return nil
+}
+{{- if .Metrics }}
+
+// Pattern returns the pattern of the route matching the path, e.g. /items/{id}, or empty if no route matches it, the
+// metrics of the requests are recorded by the pattern
+func Pattern(path string) string {
+	if path == "/healthz" {
+		return path
+	}
+	for pattern := range resourceMethods {
+		if matchPattern(pattern, path) {
+			return pattern
+		}
+	}
+	return ""
+}
+{{- end }}
+
+// matchPattern reports whether the path matches the route pattern, whose {name} parameters match any non-empty segment
+func matchPattern(pattern, path string) bool {
+	patternSegments, pathSegments := strings.Split(pattern, "/"), strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") {
+			if pathSegments[i] == "" {
+				return false
+			}
+		} else if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// allow returns the Allow header of the methods, HEAD is allowed along with GET and OPTIONS on every route
+func allow(methods []string) string {
+	allowed := append([]string(nil), methods...)
+	if hasMethod(methods, http.MethodGet) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	return strings.Join(append(allowed, http.MethodOptions), ", ")
+}
+
+// hasMethod reports whether the method is one of the methods
+func hasMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+{{- if eq .Router "stdlib" }}
+
+// unmatched serves the requests with the mux and responds with the problem of the status the mux would respond
+// with to the requests which match no pattern, 404 or 405 along with the Allow header
+func unmatched(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{header: http.Header{}}
+		h.ServeHTTP(rec, r)
+		if rec.status != http.StatusMethodNotAllowed {
+			NotFound(w, r)
+			return
+		}
+		if allow := rec.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+		MethodNotAllowed(w, r)
+	})
+}
+
+// statusRecorder records the status and headers of the response of the mux and discards its body
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+// Header returns the recorded headers
+func (w *statusRecorder) Header() http.Header {
+	return w.header
+}
+
+// Write discards the body
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// WriteHeader records the status code
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+}
+{{- end }}
+`)
+}
+
+// RoutesTestTemplate returns template for pkg/routes/routes_test.go
+func RoutesTestTemplate() []byte {
+	return []byte(`package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+{{- if .RateLimit }}
+
+	"{{ .ModuleName }}/pkg/ratelimit"
+{{- end }}
+	"{{ .ModuleName }}/pkg/repository"
 )
 
-func Routes(r *mux.Router) {
+// newHandler returns the handler of the routes{{ if .Authenticated }}, the requests are not authenticated{{ end }}
+func newHandler() http.Handler {
+	return Routes(NewRouter(), repository.NewRepositories(){{ if .Authenticated }}, func(h http.Handler) http.Handler { return h }{{ end }}{{ if .RateLimit }}, ratelimit.New(ratelimit.Config{}){{ end }})
+}
 
+func TestUnmatchedRoutes(t *testing.T) {
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+	}{
+		{method: http.MethodGet, path: "/healthz", status: http.StatusOK},
+		{method: http.MethodHead, path: "/healthz", status: http.StatusOK},
+		{method: http.MethodOptions, path: "/healthz", status: http.StatusNoContent, allow: "GET, HEAD, OPTIONS"},
+		{method: http.MethodPost, path: "/healthz", status: http.StatusMethodNotAllowed, allow: "GET, HEAD, OPTIONS"},
+		{method: http.MethodGet, path: "/healthz/", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/nope", status: http.StatusNotFound},
+		{method: http.MethodPatch, path: "/nope", status: http.StatusNotFound},
+	}
+	h := newHandler()
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			checkResponse(t, rec, tt.status, tt.allow)
+		})
+	}
 }
 
+func TestResourceRouteMethods(t *testing.T) {
+	h := newHandler()
+	for pattern, methods := range resourceMethods {
+		path := strings.ReplaceAll(pattern, "{id}", "1")
+		allow := strings.Join(methods, ", ") + ", HEAD, OPTIONS"
+		t.Run(pattern, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, path, nil))
+			checkResponse(t, rec, http.StatusMethodNotAllowed, allow)
+
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, path, nil))
+			checkResponse(t, rec, http.StatusNoContent, allow)
+
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"/", nil))
+			checkResponse(t, rec, http.StatusNotFound, "")
+		})
+	}
+}
+
+// checkResponse checks the status and Allow header of the response, the errors must be problems
+func checkResponse(t *testing.T, rec *httptest.ResponseRecorder, status int, allow string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Errorf("status = %d, want %d", rec.Code, status)
+	}
+	if got := rec.Header().Get("Allow"); got != allow {
+		t.Errorf("Allow = %q, want %q", got, allow)
+	}
+	if status >= http.StatusBadRequest {
+		if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+			t.Errorf("Content-Type = %q, want application/problem+json", got)
+		}
+	}
+}
+`)
+}
+
+// RouterAdapterTemplate returns template for pkg/routes/adapter.go
+func RouterAdapterTemplate() []byte {
+	return []byte(`package routes
+
+import (
+{{- if eq .Router "echo" }}
+	"errors"
+{{- end }}
+	"net/http"
+
+{{- if eq .Router "echo" }}
+	"{{ .ModuleName }}/pkg/apierror"
+{{- end }}
+	"{{ .ModuleName }}/pkg/handlers"
+
+	"{{ .RouterImport }}"
+)
+{{ if eq .Router "gin" }}
+// handle adapts the http.Handler to a gin.HandlerFunc, the path parameters are passed on in the request context
+func handle(h http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+		h.ServeHTTP(c.Writer, handlers.WithPathParams(c.Request, params))
+	}
+}
+{{- else }}
+// handle adapts the http.Handler to an echo.HandlerFunc, the path parameters are passed on in the request context
+func handle(h http.Handler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := make(map[string]string, len(c.ParamNames()))
+		for i, name := range c.ParamNames() {
+			params[name] = c.ParamValues()[i]
+		}
+		h.ServeHTTP(c.Response(), handlers.WithPathParams(c.Request(), params))
+		return nil
+	}
+}
+
+// handleError responds with the problems of the errors of echo, e.g. the 404 and 405 of the requests which match
+// no route, the handlers write their errors themselves
+func handleError(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		apierror.Write(c.Response(), c.Request(), err)
+		return
+	}
+	switch he.Code {
+	case http.StatusNotFound:
+		NotFound(c.Response(), c.Request())
+	case http.StatusMethodNotAllowed:
+		MethodNotAllowed(c.Response(), c.Request())
+	default:
+		apierror.Write(c.Response(), c.Request(), apierror.New(he.Code, ""))
+	}
+}
+{{- end }}
+`)
+}
+
+// MiddlewareTemplate returns template for pkg/middleware/middleware.go
+func MiddlewareTemplate() []byte {
+	return []byte(`package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"{{ .ModuleName }}/pkg/apierror"
+)
+
+// Middleware wraps a http.Handler, the middleware of the service are written against net/http
+// so that they work the same with every router
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with the middleware, the first middleware is the outermost
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// Recoverer recovers from panics in the handlers and responds with 500 Internal Server Error
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				log.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				apierror.Write(w, r, apierror.New(http.StatusInternalServerError, ""))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Logger logs the method, path, status and duration of every request
+func Logger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		log.Println(r.Method, r.URL.Path, sw.status, time.Since(start))
+	})
+}
+
+// statusWriter records the status code written by the handler
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, it is used by http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
 `)
 }
 
@@ -127,8 +1041,169 @@ const ()
 func ConfTemplate() []byte {
 	return []byte(`package conf
 
+import "time"
+
 // EnvConfig stores env vars
 type EnvConfig struct {
+	// ListenAddr is the host:port the http server listens on
+	ListenAddr string ` + "`" + `envconfig:"LISTEN_ADDR" default:"0.0.0.0:8080" validate:"required"` + "`" + `
+	// ReadTimeout is the maximum duration for reading the entire request, including the body
+	ReadTimeout time.Duration ` + "`" + `envconfig:"READ_TIMEOUT" default:"15s" validate:"gt=0"` + "`" + `
+	// WriteTimeout is the maximum duration before timing out writes of the response
+	WriteTimeout time.Duration ` + "`" + `envconfig:"WRITE_TIMEOUT" default:"15s" validate:"gt=0"` + "`" + `
+	// IdleTimeout is the maximum amount of time to wait for the next request when keep-alives are enabled
+	IdleTimeout time.Duration ` + "`" + `envconfig:"IDLE_TIMEOUT" default:"60s" validate:"gt=0"` + "`" + `
+
+{{- if .GRPC }}
+
+	// HTTPEnabled serves the http api, it can be disabled to serve only grpc
+	HTTPEnabled bool ` + "`" + `envconfig:"HTTP_ENABLED" default:"true"` + "`" + `
+	// GRPCEnabled serves the grpc api, it can be disabled to serve only http
+	GRPCEnabled bool ` + "`" + `envconfig:"GRPC_ENABLED" default:"true"` + "`" + `
+	// GRPCListenAddr is the host:port the grpc server listens on
+	GRPCListenAddr string ` + "`" + `envconfig:"GRPC_LISTEN_ADDR" default:"0.0.0.0:9090" validate:"required"` + "`" + `
+{{- end }}
+{{- if .Metrics }}
+
+	// MetricsListenAddr is the host:port the prometheus metrics are served on
+	MetricsListenAddr string ` + "`" + `envconfig:"METRICS_LISTEN_ADDR" default:"0.0.0.0:2112" validate:"required"` + "`" + `
+{{- end }}
+
+	// TLSCertFile is the path to the PEM encoded certificate, TLS is enabled when it is set
+	TLSCertFile string ` + "`" + `envconfig:"TLS_CERT_FILE" validate:"required_with=TLSKeyFile"` + "`" + `
+	// TLSKeyFile is the path to the PEM encoded private key of the certificate
+	TLSKeyFile string ` + "`" + `envconfig:"TLS_KEY_FILE" validate:"required_with=TLSCertFile"` + "`" + `
+	// TLSReloadInterval is how often the certificate and key files are checked for changes
+	TLSReloadInterval time.Duration ` + "`" + `envconfig:"TLS_RELOAD_INTERVAL" default:"30s" validate:"gt=0"` + "`" + `
+
+{{- if .HasAuth "jwt" }}
+
+	// JWTIssuer is the required iss claim of the bearer tokens
+	JWTIssuer string ` + "`" + `envconfig:"JWT_ISSUER" validate:"required"` + "`" + `
+	// JWTAudience is the audience the bearer tokens must be issued for
+	JWTAudience string ` + "`" + `envconfig:"JWT_AUDIENCE" validate:"required"` + "`" + `
+	// JWTJWKSURL is the url of the json web key set of the identity provider the tokens are verified with
+	JWTJWKSURL string ` + "`" + `envconfig:"JWT_JWKS_URL" validate:"required_without_all=JWTPublicKey JWTSecret"` + "`" + `
+	// JWTPublicKey is the PEM encoded public key the tokens are verified with if there is no JWKS url
+	JWTPublicKey string ` + "`" + `envconfig:"JWT_PUBLIC_KEY"` + "`" + `
+	// JWTSecret is the HMAC secret the tokens are verified with if there is no JWKS url or public key
+	JWTSecret string ` + "`" + `envconfig:"JWT_SECRET"` + "`" + `
+	// JWTLeeway is the allowed clock skew when validating the exp, nbf and iat claims
+	JWTLeeway time.Duration ` + "`" + `envconfig:"JWT_LEEWAY" default:"30s"` + "`" + `
+{{- end }}
+
+{{- if .HasAuth "apikey" }}
+
+	// APIKeysFile is the path to the json file of the hashed api keys, it is read again when it changes
+	APIKeysFile string ` + "`" + `envconfig:"API_KEYS_FILE" validate:"required"` + "`" + `
+	// APIKeysReloadInterval is how often the api key file is checked for changes
+	APIKeysReloadInterval time.Duration ` + "`" + `envconfig:"API_KEYS_RELOAD_INTERVAL" default:"30s" validate:"gt=0"` + "`" + `
+	// APIKeyHeader is the request header the api key is read from
+	APIKeyHeader string ` + "`" + `envconfig:"API_KEY_HEADER" default:"X-API-Key" validate:"required"` + "`" + `
+	// APIKeyRateLimit is the number of requests per second allowed for the clients whose keys have no rate limit,
+	// 0 disables it
+	APIKeyRateLimit float64 ` + "`" + `envconfig:"API_KEY_RATE_LIMIT" default:"10" validate:"gte=0"` + "`" + `
+	// APIKeyBurst is the number of requests allowed at once above the rate limit
+	APIKeyBurst int ` + "`" + `envconfig:"API_KEY_BURST" default:"20" validate:"gte=0"` + "`" + `
+{{- end }}
+
+{{- if .RateLimit }}
+
+	// RateLimit is the number of requests per second allowed for each client on the resource routes without
+	// a limit, 0 disables it
+	RateLimit float64 ` + "`" + `envconfig:"RATE_LIMIT" default:"100" validate:"gte=0"` + "`" + `
+	// RateLimitBurst is the number of requests allowed at once above the rate limit
+	RateLimitBurst int ` + "`" + `envconfig:"RATE_LIMIT_BURST" default:"200" validate:"gte=0"` + "`" + `
+	// RateLimitRoutes are the limits of the routes overriding the manifest, e.g. items.create:5/10,items.list:50
+	RateLimitRoutes map[string]string ` + "`" + `envconfig:"RATE_LIMIT_ROUTES"` + "`" + `
+	// RateLimitTrustProxy identifies the anonymous clients by the X-Forwarded-For header of the proxy in front
+	// of the service instead of the remote address
+	RateLimitTrustProxy bool ` + "`" + `envconfig:"RATE_LIMIT_TRUST_PROXY" default:"false"` + "`" + `
+{{- if .Authenticated }}
+	// RateLimitIP is the number of requests per second allowed for each ip address before the requests are
+	// authenticated, it limits the clients guessing credentials, 0 disables it
+	RateLimitIP float64 ` + "`" + `envconfig:"RATE_LIMIT_IP" default:"20" validate:"gte=0"` + "`" + `
+	// RateLimitIPBurst is the number of requests of each ip address allowed at once above the rate limit
+	RateLimitIPBurst int ` + "`" + `envconfig:"RATE_LIMIT_IP_BURST" default:"40" validate:"gte=0"` + "`" + `
+{{- end }}
+{{- end }}
+
+{{- if .Cached }}
+
+	// RedisAddr is the host:port of the redis the resources are cached in, they are cached in the process if it is empty
+	RedisAddr string ` + "`" + `envconfig:"REDIS_ADDR"` + "`" + `
+	// RedisPassword is the password of the redis
+	RedisPassword string ` + "`" + `envconfig:"REDIS_PASSWORD"` + "`" + `
+	// RedisDB is the database of the redis
+	RedisDB int ` + "`" + `envconfig:"REDIS_DB" default:"0" validate:"gte=0"` + "`" + `
+	// CacheTTL is how long the resources read by id are cached, 0 disables it
+	CacheTTL time.Duration ` + "`" + `envconfig:"CACHE_TTL" default:"5m" validate:"gte=0"` + "`" + `
+	// CacheListTTL is how long the listed pages are cached, 0 disables it
+	CacheListTTL time.Duration ` + "`" + `envconfig:"CACHE_LIST_TTL" default:"30s" validate:"gte=0"` + "`" + `
+	// CacheLRUSize is the number of values cached in the process when there is no redis
+	CacheLRUSize int ` + "`" + `envconfig:"CACHE_LRU_SIZE" default:"10000" validate:"gt=0"` + "`" + `
+{{- end }}
+
+{{- if .Worker }}
+
+	// ConsumerConcurrency is the maximum number of messages handled at once
+	ConsumerConcurrency int ` + "`" + `envconfig:"CONSUMER_CONCURRENCY" default:"10" validate:"gt=0"` + "`" + `
+	// ConsumerMaxAttempts is the number of times a message is handled before it is dead lettered
+	ConsumerMaxAttempts int ` + "`" + `envconfig:"CONSUMER_MAX_ATTEMPTS" default:"5" validate:"gt=0"` + "`" + `
+	// ConsumerInitialBackoff is the wait before the second attempt, it doubles with every attempt
+	ConsumerInitialBackoff time.Duration ` + "`" + `envconfig:"CONSUMER_INITIAL_BACKOFF" default:"1s" validate:"gt=0"` + "`" + `
+	// ConsumerMaxBackoff is the maximum wait between the attempts
+	ConsumerMaxBackoff time.Duration ` + "`" + `envconfig:"CONSUMER_MAX_BACKOFF" default:"1m" validate:"gtefield=ConsumerInitialBackoff"` + "`" + `
+{{- if eq .Events "kafka" }}
+
+	// KafkaBrokers are the host:port of the kafka brokers the messages are consumed from
+	KafkaBrokers []string ` + "`" + `envconfig:"KAFKA_BROKERS" validate:"required"` + "`" + `
+	// KafkaTopic is the topic of the messages, by default the topic the crud services publish their events to
+	KafkaTopic string ` + "`" + `envconfig:"KAFKA_TOPIC" default:"events" validate:"required"` + "`" + `
+	// KafkaGroupID is the consumer group, the partitions of the topic are shared by the workers of the group
+	KafkaGroupID string ` + "`" + `envconfig:"KAFKA_GROUP_ID" default:"{{ .ProjectDirName }}" validate:"required"` + "`" + `
+	// KafkaDeadLetterTopic is the topic the messages are written to once every attempt failed
+	KafkaDeadLetterTopic string ` + "`" + `envconfig:"KAFKA_DEAD_LETTER_TOPIC" default:"{{ .ProjectDirName }}.dlq" validate:"required"` + "`" + `
+{{- else if eq .Events "nats" }}
+
+	// NATSURL is the url of the nats server the messages are consumed from
+	NATSURL string ` + "`" + `envconfig:"NATS_URL" validate:"required"` + "`" + `
+	// NATSStream is the JetStream stream of the messages, it is created on the subjects if it doesn't exist
+	NATSStream string ` + "`" + `envconfig:"NATS_STREAM" default:"EVENTS" validate:"required"` + "`" + `
+	// NATSSubjects are the subjects of the stream when it is created, by default the subjects the crud services
+	// publish their events to
+	NATSSubjects []string ` + "`" + `envconfig:"NATS_SUBJECTS" default:"events.>"` + "`" + `
+	// NATSConsumer is the durable consumer of the stream, the messages are shared by the workers of the consumer
+	NATSConsumer string ` + "`" + `envconfig:"NATS_CONSUMER" default:"{{ .ProjectDirName }}" validate:"required"` + "`" + `
+	// NATSDeadLetterSubject is the subject the messages are published to once every attempt failed
+	NATSDeadLetterSubject string ` + "`" + `envconfig:"NATS_DEAD_LETTER_SUBJECT" default:"{{ .ProjectDirName }}.dlq" validate:"required"` + "`" + `
+	// NATSAckWait is how long the stream waits for the ack of a message before it delivers it again
+	NATSAckWait time.Duration ` + "`" + `envconfig:"NATS_ACK_WAIT" default:"30s" validate:"gt=0"` + "`" + `
+{{- end }}
+{{- else if eq .Events "kafka" }}
+
+	// KafkaBrokers are the host:port of the kafka brokers the events are published to
+	KafkaBrokers []string ` + "`" + `envconfig:"KAFKA_BROKERS" validate:"required"` + "`" + `
+	// KafkaTopic is the topic of the events, it is shared with the other services, the types of the events tell them apart
+	KafkaTopic string ` + "`" + `envconfig:"KAFKA_TOPIC" default:"events" validate:"required"` + "`" + `
+{{- else if eq .Events "nats" }}
+
+	// NATSURL is the url of the nats server the events are published to
+	NATSURL string ` + "`" + `envconfig:"NATS_URL" validate:"required"` + "`" + `
+	// NATSSubjectPrefix prefixes the subjects of the events, which are their types, e.g. events.{{ .ProjectDirName }}.item.created,
+	// the subjects are the types if it is empty
+	NATSSubjectPrefix string ` + "`" + `envconfig:"NATS_SUBJECT_PREFIX" default:"events"` + "`" + `
+{{- end }}
+
+{{- if .Outbox }}
+
+	// DatabaseURL is the url of the postgres database of the outbox
+	DatabaseURL string ` + "`" + `envconfig:"DATABASE_URL" validate:"required"` + "`" + `
+	// OutboxPollInterval is how often the relay polls the outbox for events
+	OutboxPollInterval time.Duration ` + "`" + `envconfig:"OUTBOX_POLL_INTERVAL" default:"1s" validate:"gt=0"` + "`" + `
+	// OutboxBatchSize is the maximum number of events the relay publishes at once
+	OutboxBatchSize int ` + "`" + `envconfig:"OUTBOX_BATCH_SIZE" default:"100" validate:"gt=0"` + "`" + `
+{{- end }}
 }
 
 // Env stores env vars
@@ -162,25 +1237,271 @@ func LoadEnvConfig(spec interface{}) error {
 `)
 }
 
+// TLSTemplate returns template for pkg/utils/tls.go
+func TLSTemplate() []byte {
+	return []byte(`package utils
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a tls certificate loaded from the cert and key files and reloads it when either file changes
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+}
+
+// NewCertReloader loads the certificate from the provided files, the files are checked for changes at most once every interval
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	c := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate returns the current certificate, it can be used as tls.Config.GetCertificate
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	cert, lastCheck := c.cert, c.lastCheck
+	c.mu.RUnlock()
+
+	if time.Since(lastCheck) < c.interval {
+		return cert, nil
+	}
+
+	// keep serving the previous certificate if the files are being rotated and can't be loaded yet
+	if err := c.reload(); err != nil {
+		return cert, nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// reload loads the certificate again if the modification time of the cert or key file changed
+func (c *CertReloader) reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastCheck = time.Now()
+
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return err
+	}
+	if c.cert != nil && certInfo.ModTime().Equal(c.certModTime) && keyInfo.ModTime().Equal(c.keyModTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.cert = &cert
+	c.certModTime = certInfo.ModTime()
+	c.keyModTime = keyInfo.ModTime()
+	return nil
+}
+
+`)
+}
+
 // ModelsTemplate returns template for pkg/models/models.go
 func ModelsTemplate() []byte {
 	return []byte(`package models
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator"
+)
+
+// validate validates the models with the validate tags of their fields
+var validate = newValidator()
+
+// regexes caches the compiled patterns of the regex validation
+var regexes sync.Map
+
+// Validate validates the model with the validate tags of its fields, validator.ValidationErrors is returned
+// with the json names of the invalid fields
+func Validate(m interface{}) error {
+	return validate.Struct(m)
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	if err := v.RegisterValidation("regex", matchRegex); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// matchRegex is the regex=<pattern> validation of string fields, commas and pipes of the pattern are written
+// as 0x2C and 0x7C in the tag
+func matchRegex(fl validator.FieldLevel) bool {
+	pattern := fl.Param()
+	re, ok := regexes.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		re, _ = regexes.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(fl.Field().String())
+}
+
 `)
 }
 
 // DockerfileTemplate returns template for Dockerfile
 func DockerfileTemplate() []byte {
-	return []byte(`FROM debian
-COPY ./{{ .ProjectDirName }} /{{ .ProjectDirName }}
+	return []byte(`# syntax=docker/dockerfile:1
+
+ARG GO_VERSION={{ .GoVersion }}
+
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION} AS builder
+WORKDIR /src
+
+# download the modules first so that the layer is cached until go.mod or go.sum change
+COPY go.mod go.sum ./
+RUN --mount=type=cache,target=/go/pkg/mod go mod download
+
+COPY . .
+
+ARG TARGETOS
+ARG TARGETARCH
+ARG VERSION=dev
+RUN --mount=type=cache,target=/go/pkg/mod \
+    --mount=type=cache,target=/root/.cache/go-build \
+    CGO_ENABLED=0 GOOS=$TARGETOS GOARCH=$TARGETARCH \
+    go build -trimpath -tags timetzdata -ldflags "-s -w -X main.version=${VERSION}" -o /out/{{ .ProjectDirName }} .
+{{ if eq .BaseImage "scratch" }}
+FROM scratch
+COPY --from=builder /etc/ssl/certs/ca-certificates.crt /etc/ssl/certs/
+{{- else }}
+FROM gcr.io/distroless/static-debian12:nonroot
+{{- end }}
+COPY --from=builder /out/{{ .ProjectDirName }} /{{ .ProjectDirName }}
+USER 65532:65532
+EXPOSE 8080{{ if .GRPC }} 9090{{ end }}{{ if .Metrics }} 2112{{ end }}
+HEALTHCHECK --interval=30s --timeout=5s --start-period=5s --retries=3 CMD [ "/{{ .ProjectDirName }}", "-healthcheck" ]
 ENTRYPOINT [ "/{{ .ProjectDirName }}" ]
 `)
 }
 
+// DockerignoreTemplate returns template for .dockerignore
+func DockerignoreTemplate() []byte {
+	return []byte(`.git
+.crud
+.dockerignore
+Dockerfile
+Makefile
+docker-compose.yaml
+build.sh
+bin
+charts
+{{ .ProjectDirName }}
+`)
+}
+
+// MakefileTemplate returns template for Makefile
+func MakefileTemplate() []byte {
+	return []byte(`# image name is derived from the project directory and tagged with the short git sha,
+# set REGISTRY to push the image to a registry, e.g. make docker-push REGISTRY=ghcr.io/acme
+BINARY   ?= {{ .ProjectDirName }}
+REGISTRY ?=
+IMAGE    ?= $(if $(REGISTRY),$(REGISTRY)/)$(BINARY)
+TAG      ?= $(shell git rev-parse --short HEAD 2>/dev/null || echo dev)
+VERSION  ?= $(shell git describe --tags --always --dirty 2>/dev/null || echo dev)
+CHART    ?= charts/{{ .ProjectDirName }}
+
+GOLANGCI_LINT ?= golangci-lint
+
+.DEFAULT_GOAL := help
+
+.PHONY: help build test lint run docker-build docker-push helm-lint generate clean{{ if .CreateCompose }} up down{{ end }}
+
+help: ## show the available targets
+	@grep -E '^[a-z-]+:.*## ' $(MAKEFILE_LIST) | awk 'BEGIN {FS = ":.*## "}; {printf "%-14s %s\n", $$1, $$2}'
+
+build: ## build the binary into bin/
+	CGO_ENABLED=0 go build -trimpath -ldflags "-s -w -X main.version=$(VERSION)" -o bin/$(BINARY) .
+
+test: ## run the tests with race detection
+	go test -race -cover ./...
+
+lint: ## run go vet and golangci-lint
+	go vet ./...
+	$(GOLANGCI_LINT) run ./...
+
+run: ## run the service locally
+	go run -ldflags "-X main.version=$(VERSION)" .
+
+docker-build: ## build the docker image tagged with the short git sha
+	DOCKER_BUILDKIT=1 docker build --build-arg VERSION=$(VERSION) -t $(IMAGE):$(TAG) .
+
+docker-push: docker-build ## push the docker image to REGISTRY
+	docker push $(IMAGE):$(TAG)
+
+helm-lint: ## lint the helm chart
+{{- if .CreateHelmChart }}
+	helm lint $(CHART)
+{{- else }}
+	@echo "no helm chart, the project was created without --chart"
+{{- end }}
+
+generate: ## run go generate
+	go generate ./...
+
+clean: ## remove the build output
+	rm -rf bin
+{{- if .CreateCompose }}
+
+up: ## run the service along with its backing services with docker compose
+	docker compose up --build
+
+down: ## stop the docker compose stack
+	docker compose down
+{{- end }}
+`)
+}
+
 // BuildFileTemplate returns template for build.sh file
 func BuildFileTemplate() []byte {
 	return []byte(`#!/bin/sh
 
-GOOS=linux go build .
-docker build -t {{ .ProjectDirName }} .
+VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo dev)
+DOCKER_BUILDKIT=1 docker build --build-arg VERSION="$VERSION" -t {{ .ProjectDirName }}:"$VERSION" -t {{ .ProjectDirName }} .
 `)
 }
\ No newline at end of file
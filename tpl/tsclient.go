@@ -0,0 +1,273 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// TSModelsTemplate returns template for the models.ts of the typescript client
+func TSModelsTemplate() []byte {
+	return []byte(`// Code generated by crud. DO NOT EDIT.
+// The interfaces of the models of {{ .ProjectDirName }}, they are generated again by crud add resource.
+{{- range .Resources }}
+
+/** {{ .GoName }} is the {{ .HumanName }} resource */
+export interface {{ .GoName }} {
+  id: string;
+{{- range .Fields }}
+  {{ .JSONName }}: {{ .TSType }};
+{{- end }}
+{{- if .Owned }}
+  /** ownerId is the subject of the token which created the {{ .HumanName }} */
+  ownerId: string;
+{{- end }}
+  /** createdAt is the RFC 3339 time the {{ .HumanName }} was created at */
+  createdAt: string;
+  /** updatedAt is the RFC 3339 time the {{ .HumanName }} was last updated at */
+  updatedAt: string;
+}
+
+/** {{ .GoName }}Input is the body which creates or replaces the {{ .HumanName }}, the fields which are not provided are their zero value */
+export interface {{ .GoName }}Input {
+{{- range .Fields }}
+  {{ .JSONName }}{{ if not .Required }}?{{ end }}: {{ .TSType }};
+{{- end }}
+}
+{{- end }}
+`)
+}
+
+// TSClientTemplate returns template for the client.ts of the typescript client
+func TSClientTemplate() []byte {
+	return []byte(`// Code generated by crud. DO NOT EDIT.
+// The fetch client of the {{ .ProjectDirName }} api, it is generated again by crud add resource.
+{{- if .Resources }}
+
+import type {
+{{- range .Resources }}
+  {{ .GoName }},
+  {{ .GoName }}Input,
+{{- end }}
+} from "./models";
+{{- end }}
+
+/** ListOptions are the pagination, filter and sort query parameters of the list requests */
+export interface ListOptions {
+  /** limit is the maximum number of items listed */
+  limit?: number;
+  /** offset skips the first items, it is ignored when cursor is set */
+  offset?: number;
+  /** cursor is the nextCursor of the previous page listed with the same filters and sort */
+  cursor?: string;
+  /** sort orders the items by the fields in order, a - prefix sorts in descending order, e.g. ["-price"] */
+  sort?: string[];
+  /** filters are the filters of the fields, e.g. { status: "active", price_gt: 10 } */
+  filters?: Record<string, string | number | boolean | Array<string | number | boolean>>;
+}
+
+/** Page is a listed page of items */
+export interface Page<T> {
+  items: T[];
+  /** nextCursor is the cursor of the next page, it is omitted on the last page */
+  nextCursor?: string;
+  /** total is the number of items matching the filters */
+  total: number;
+}
+
+/** InvalidParam is a request field which failed the validation along with the reasons */
+export interface InvalidParam {
+  name: string;
+  reasons: string[];
+}
+
+/** Problem is the RFC 7807 problem details error response of the api */
+export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  "invalid-params"?: InvalidParam[];
+}
+
+/** ProblemError is thrown for the error responses of the api */
+export class ProblemError extends Error {
+  readonly problem: Problem;
+
+  constructor(problem: Problem) {
+    super(problem.status + " " + problem.title + (problem.detail ? ": " + problem.detail : ""));
+    this.name = "ProblemError";
+    this.problem = problem;
+  }
+
+  /** status is the status code of the response */
+  get status(): number {
+    return this.problem.status;
+  }
+
+  /** invalidParams are the request fields which failed the validation */
+  get invalidParams(): InvalidParam[] {
+    return this.problem["invalid-params"] ?? [];
+  }
+}
+
+/** ClientOptions configure the Client */
+export interface ClientOptions {
+  /** baseUrl is the url the api is served at, e.g. http://localhost:8080 */
+  baseUrl: string;
+  /** headers are set on every request, a function is called for every request, e.g. to set a refreshed token */
+  headers?: Record<string, string> | (() => Record<string, string> | Promise<Record<string, string>>);
+  /** timeoutMs aborts the requests which take longer, 30000 by default, 0 disables it */
+  timeoutMs?: number;
+  /** fetch sends the requests, globalThis.fetch by default */
+  fetch?: typeof fetch;
+}
+
+/** RequestOptions are the options of a single request */
+export interface RequestOptions {
+  /** signal aborts the request */
+  signal?: AbortSignal;
+}
+
+/** Client sends the requests to the api, the resources are served by its resource clients */
+export class Client {
+{{- range .Resources }}
+  /** {{ .VarPluralName }} is the client of the {{ .HumanPluralName }} */
+  readonly {{ .VarPluralName }}: ResourceClient<{{ .GoName }}, {{ .GoName }}Input>;
+{{- end }}
+
+  private readonly baseUrl: string;
+  private readonly options: ClientOptions;
+
+  constructor(options: ClientOptions) {
+    this.baseUrl = options.baseUrl.replace(/\/+$/, "");
+    this.options = options;
+{{- range .Resources }}
+    this.{{ .VarPluralName }} = new ResourceClient(this, "/{{ .PathName }}");
+{{- end }}
+  }
+
+  /** request sends the request with the json of body and returns the json response, the error responses are thrown as ProblemError */
+  async request<T>(method: string, path: string, body?: unknown, query?: URLSearchParams, init?: RequestOptions): Promise<T> {
+    const headers: Record<string, string> = { Accept: "application/json" };
+    const extra = typeof this.options.headers === "function" ? await this.options.headers() : this.options.headers;
+    Object.assign(headers, extra);
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+
+    const controller = new AbortController();
+    const abort = () => controller.abort(init?.signal?.reason);
+    if (init?.signal?.aborted) {
+      abort();
+    }
+    init?.signal?.addEventListener("abort", abort);
+    const timeoutMs = this.options.timeoutMs ?? 30000;
+    const timer = timeoutMs > 0 ? setTimeout(() => controller.abort(new Error("request timed out after " + timeoutMs + "ms")), timeoutMs) : undefined;
+
+    try {
+      const url = this.baseUrl + path + (query && query.toString() ? "?" + query.toString() : "");
+      const send = this.options.fetch ?? globalThis.fetch;
+      const response = await send(url, {
+        method,
+        headers,
+        body: body === undefined ? undefined : JSON.stringify(body),
+        signal: controller.signal,
+      });
+      if (!response.ok) {
+        throw new ProblemError(await readProblem(response));
+      }
+      if (response.status === 204) {
+        return undefined as T;
+      }
+      return (await response.json()) as T;
+    } finally {
+      clearTimeout(timer);
+      init?.signal?.removeEventListener("abort", abort);
+    }
+  }
+}
+
+/** ResourceClient sends the CRUD requests of a resource */
+export class ResourceClient<T, TInput> {
+  constructor(private readonly client: Client, private readonly path: string) {}
+
+  /** list lists a page of the resources */
+  list(options: ListOptions = {}, init?: RequestOptions): Promise<Page<T>> {
+    return this.client.request<Page<T>>("GET", this.path, undefined, listQuery(options), init);
+  }
+
+  /** get gets the resource with the id */
+  get(id: string, init?: RequestOptions): Promise<T> {
+    return this.client.request<T>("GET", this.path + "/" + encodeURIComponent(id), undefined, undefined, init);
+  }
+
+  /** create creates the resource and returns it along with its id */
+  create(input: TInput, init?: RequestOptions): Promise<T> {
+    return this.client.request<T>("POST", this.path, input, undefined, init);
+  }
+
+  /** update replaces the resource with the id */
+  update(id: string, input: TInput, init?: RequestOptions): Promise<T> {
+    return this.client.request<T>("PUT", this.path + "/" + encodeURIComponent(id), input, undefined, init);
+  }
+
+  /** delete deletes the resource with the id */
+  delete(id: string, init?: RequestOptions): Promise<void> {
+    return this.client.request<void>("DELETE", this.path + "/" + encodeURIComponent(id), undefined, undefined, init);
+  }
+}
+
+/** listQuery returns the query parameters of the list options */
+function listQuery(options: ListOptions): URLSearchParams {
+  const query = new URLSearchParams();
+  for (const [name, value] of Object.entries(options.filters ?? {})) {
+    for (const v of Array.isArray(value) ? value : [value]) {
+      query.append(name, String(v));
+    }
+  }
+  if (options.limit !== undefined) {
+    query.set("limit", String(options.limit));
+  }
+  if (options.offset !== undefined) {
+    query.set("offset", String(options.offset));
+  }
+  if (options.cursor) {
+    query.set("cursor", options.cursor);
+  }
+  if (options.sort && options.sort.length > 0) {
+    query.set("sort", options.sort.join(","));
+  }
+  return query;
+}
+
+/** readProblem reads the problem details of the error response, the responses which are not problem details, e.g. of a proxy, are described by their status */
+async function readProblem(response: Response): Promise<Problem> {
+  try {
+    const problem = (await response.json()) as Problem;
+    if (problem && typeof problem.status === "number") {
+      return { ...problem, status: response.status };
+    }
+  } catch {
+    // the body is not json
+  }
+  return { type: "about:blank", title: response.statusText, status: response.status };
+}
+`)
+}
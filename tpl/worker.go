@@ -0,0 +1,868 @@
+/*
+Copyright © 2021 Piyush Jajoo piyush.jajoo1991@gmail.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package tpl
+
+// WorkerMainTemplate returns the template for main.go of the workers
+func WorkerMainTemplate() []byte {
+	return []byte(`package main
+
+import (
+{{- if eq .Events "nats" }}
+	"context"
+{{- end }}
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"{{ .ModuleName }}/pkg/conf"
+	"{{ .ModuleName }}/pkg/consumer"
+	"{{ .ModuleName }}/pkg/lifecycle"
+	"{{ .ModuleName }}/pkg/routes"
+	"{{ .ModuleName }}/pkg/utils"
+)
+
+// version of the worker, it is set at build time with -ldflags "-X main.version=<version>"
+var version = "dev"
+
+func init() {
+	if err := utils.LoadEnvConfig(&conf.Env); err != nil {
+		log.Fatalln("error loading env config:", err)
+	}
+}
+
+func main() {
+
+	var wait time.Duration
+	var healthcheck bool
+	flag.DurationVar(&wait, "graceful-timeout", time.Second*15, "the duration for which the worker gracefully wait for the messages being handled to finish - e.g. 15s or 1m")
+	flag.BoolVar(&healthcheck, "healthcheck", false, "probe the /healthz endpoint of the running worker and exit, used by the docker HEALTHCHECK")
+	flag.Parse()
+
+	if healthcheck {
+		if err := healthCheck(); err != nil {
+			log.Fatalln("health check failed:", err)
+		}
+		return
+	}
+
+	log.Println("starting {{ .ProjectDirName }} version", version)
+
+	// register the handlers of the message types
+	router := consumer.NewRouter()
+	routes.Routes(router)
+
+	// subscribe to the messages
+{{- if eq .Events "kafka" }}
+	sub := consumer.NewKafkaSubscription(consumer.KafkaConfig{
+		Brokers:         conf.Env.KafkaBrokers,
+		Topic:           conf.Env.KafkaTopic,
+		GroupID:         conf.Env.KafkaGroupID,
+		DeadLetterTopic: conf.Env.KafkaDeadLetterTopic,
+	})
+{{- else if eq .Events "nats" }}
+	sub, err := consumer.NewNATSSubscription(context.Background(), consumer.NATSConfig{
+		URL:               conf.Env.NATSURL,
+		Stream:            conf.Env.NATSStream,
+		Subjects:          conf.Env.NATSSubjects,
+		Consumer:          conf.Env.NATSConsumer,
+		DeadLetterSubject: conf.Env.NATSDeadLetterSubject,
+		AckWait:           conf.Env.NATSAckWait,
+		MaxAckPending:     conf.Env.ConsumerConcurrency,
+	})
+	if err != nil {
+		log.Fatalln("error subscribing to nats:", err)
+	}
+{{- else }}
+	// the messages are published to the in-memory subscription with sub.Publish, e.g. in tests, subscribe to a
+	// message broker in production
+	sub := consumer.NewMemorySubscription(conf.Env.ConsumerConcurrency)
+{{- end }}
+	worker := consumer.New(sub, router, consumer.Config{
+		Concurrency:    conf.Env.ConsumerConcurrency,
+		MaxAttempts:    conf.Env.ConsumerMaxAttempts,
+		InitialBackoff: conf.Env.ConsumerInitialBackoff,
+		MaxBackoff:     conf.Env.ConsumerMaxBackoff,
+	})
+
+	// create the server of the health check
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := &http.Server{
+		Addr:         conf.Env.ListenAddr,
+		WriteTimeout: conf.Env.WriteTimeout,
+		ReadTimeout:  conf.Env.ReadTimeout,
+		IdleTimeout:  conf.Env.IdleTimeout,
+		Handler:      mux,
+	}
+
+	// serve TLS if the certificate and key files are provided, the certificate is reloaded when the files change
+	if conf.Env.TLSCertFile != "" {
+		certReloader, err := utils.NewCertReloader(conf.Env.TLSCertFile, conf.Env.TLSKeyFile, conf.Env.TLSReloadInterval)
+		if err != nil {
+			log.Fatalln("error loading tls certificate:", err)
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certReloader.GetCertificate,
+		}
+	}
+
+	// The consumer is started after the health check server and stopped before it on SIGINT or SIGTERM, the
+	// messages being handled are finished within the graceful-timeout and the others are delivered again.
+	manager := lifecycle.New(wait)
+	manager.Register("http server", lifecycle.HTTPServer(srv))
+	manager.Register("consumer", worker)
+
+	if err := manager.Run(); err != nil {
+		log.Fatalln(err)
+	}
+	log.Println("shut down gracefully")
+}
+
+// healthCheck probes the /healthz endpoint of the worker listening on conf.Env.ListenAddr
+func healthCheck() error {
+	host, port, err := net.SplitHostPort(conf.Env.ListenAddr)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: 5 * time.Second}
+	if conf.Env.TLSCertFile != "" {
+		scheme = "https"
+		// the probe only checks that the local server is up, so the certificate is not verified
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+
+	resp, err := client.Get(fmt.Sprintf("%s://%s/healthz", scheme, net.JoinHostPort(host, port)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+`)
+}
+
+// WorkerRoutesTemplate returns template for pkg/routes/routes.go of the workers
+func WorkerRoutesTemplate() []byte {
+	return []byte(`package routes
+
+import (
+	"{{ .ModuleName }}/pkg/consumer"
+	"{{ .ModuleName }}/pkg/handlers"
+)
+
+// Routes registers the handlers of the message types on the router, e.g.
+//
+//	r.HandleFunc("inventory.item.created", handlers.ItemCreated)
+func Routes(r *consumer.Router) {
+	// the messages without a handler of their type are logged
+	r.Default(consumer.HandlerFunc(handlers.Log))
+}
+`)
+}
+
+// WorkerHandlersTemplate returns template for pkg/handlers/handlers.go of the workers
+func WorkerHandlersTemplate() []byte {
+	return []byte(`package handlers
+
+import (
+	"context"
+	"log"
+
+	"{{ .ModuleName }}/pkg/consumer"
+)
+
+// Log logs the message, it handles the messages without a handler of their type
+func Log(ctx context.Context, msg *consumer.Message) error {
+	log.Println("message", msg.ID, "of type", msg.Type, ":", string(msg.Data))
+	return nil
+}
+`)
+}
+
+// ConsumerTemplate returns template for pkg/consumer/consumer.go
+func ConsumerTemplate() []byte {
+	return []byte(`// Package consumer handles the messages of a subscription with the handlers of their type, the failed messages
+// are retried with backoff and dead lettered once every attempt failed
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Message is a message of the broker
+type Message struct {
+	// ID identifies the message, the handlers use it to ignore the messages delivered more than once
+	ID string
+	// Type routes the message to its handler, e.g. inventory.item.created
+	Type string
+	// Key is the key the messages are ordered by, e.g. the id of the resource
+	Key     string
+	Data    []byte
+	Headers map[string]string
+	// Attempt is the number of the attempt to handle the message, starting at 1
+	Attempt int
+
+	// raw is the message of the broker, it is acknowledged by the subscription
+	raw interface{}
+}
+
+// envelope is the json of the events published by the crud services
+type envelope struct {
+	ID      string ` + "`" + `json:"id"` + "`" + `
+	Type    string ` + "`" + `json:"type"` + "`" + `
+	Subject string ` + "`" + `json:"subject"` + "`" + `
+}
+
+// fromEnvelope sets the id, type and key of the message which are not set from its data if it is an event
+func (m *Message) fromEnvelope() {
+	var e envelope
+	if json.Unmarshal(m.Data, &e) != nil {
+		return
+	}
+	if m.ID == "" {
+		m.ID = e.ID
+	}
+	if m.Type == "" {
+		m.Type = e.Type
+	}
+	if m.Key == "" {
+		m.Key = e.Subject
+	}
+}
+
+// Handler handles the messages, the message is retried if an error is returned unless it is Permanent. The
+// handlers must return when ctx is done, the message is then delivered again.
+type Handler interface {
+	HandleMessage(ctx context.Context, msg *Message) error
+}
+
+// HandlerFunc adapts a function to a Handler
+type HandlerFunc func(ctx context.Context, msg *Message) error
+
+// HandleMessage calls f
+func (f HandlerFunc) HandleMessage(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// permanentError is an error retrying the message doesn't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error of a message which can't be handled, e.g. it is invalid, the message is dead lettered
+// without being retried
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether the error is marked as Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Router routes the messages to the handlers of their type, it is a Handler
+type Router struct {
+	handlers map[string]Handler
+	fallback Handler
+}
+
+// NewRouter returns a Router without handlers
+func NewRouter() *Router {
+	return &Router{handlers: map[string]Handler{}}
+}
+
+// Handle registers the handler of the message type
+func (r *Router) Handle(msgType string, h Handler) {
+	r.handlers[msgType] = h
+}
+
+// HandleFunc registers the handler function of the message type
+func (r *Router) HandleFunc(msgType string, f func(ctx context.Context, msg *Message) error) {
+	r.Handle(msgType, HandlerFunc(f))
+}
+
+// Default registers the handler of the messages without a handler of their type
+func (r *Router) Default(h Handler) {
+	r.fallback = h
+}
+
+// HandleMessage handles the message with the handler of its type, the messages without a handler are dead
+// lettered if there is no default handler
+func (r *Router) HandleMessage(ctx context.Context, msg *Message) error {
+	h, ok := r.handlers[msg.Type]
+	if !ok {
+		h = r.fallback
+	}
+	if h == nil {
+		return Permanent(fmt.Errorf("no handler of message type %q", msg.Type))
+	}
+	return h.HandleMessage(ctx, msg)
+}
+
+// Subscription delivers the messages of the broker, the messages which are not acknowledged are delivered again
+type Subscription interface {
+	// Receive blocks until the next message is delivered or ctx is done
+	Receive(ctx context.Context) (*Message, error)
+	// Ack acknowledges the handled message
+	Ack(ctx context.Context, msg *Message) error
+	// Extend tells the broker the message is still being handled, it is called before every retry
+	Extend(ctx context.Context, msg *Message) error
+	// DeadLetter moves the message which failed with cause to the dead letter destination and acknowledges it
+	DeadLetter(ctx context.Context, msg *Message, cause error) error
+	// Close closes the connection to the broker
+	Close() error
+}
+
+// Config configures the Consumer
+type Config struct {
+	// Concurrency is the maximum number of messages handled at once
+	Concurrency int
+	// MaxAttempts is the number of times a message is handled before it is dead lettered
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt, it doubles with every attempt up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Consumer receives the messages of the subscription and handles them with the handler
+type Consumer struct {
+	sub     Subscription
+	handler Handler
+	cfg     Config
+
+	stop     context.CancelFunc
+	abort    context.CancelFunc
+	received chan struct{}
+	handling sync.WaitGroup
+}
+
+// New returns a Consumer of the subscription
+func New(sub Subscription, handler Handler, cfg Config) *Consumer {
+	return &Consumer{sub: sub, handler: handler, cfg: cfg}
+}
+
+// Start starts receiving the messages, it is a lifecycle.Component
+func (c *Consumer) Start(_ context.Context, errs chan<- error) error {
+	receiveCtx, stop := context.WithCancel(context.Background())
+	handleCtx, abort := context.WithCancel(context.Background())
+	c.stop, c.abort, c.received = stop, abort, make(chan struct{})
+	go c.receive(receiveCtx, handleCtx, errs)
+	return nil
+}
+
+// Stop stops receiving the messages and waits for the messages being handled, the handlers are canceled at the
+// deadline of ctx and their messages are delivered again
+func (c *Consumer) Stop(ctx context.Context) error {
+	c.stop()
+	<-c.received
+
+	handled := make(chan struct{})
+	go func() {
+		c.handling.Wait()
+		close(handled)
+	}()
+	var err error
+	select {
+	case <-handled:
+	case <-ctx.Done():
+		// the handlers which don't return when they are canceled are not waited for
+		err = ctx.Err()
+	}
+	c.abort()
+	return errors.Join(err, c.sub.Close())
+}
+
+// receive receives the messages and handles each of them in a goroutine, at most Concurrency at once
+func (c *Consumer) receive(receiveCtx, handleCtx context.Context, errs chan<- error) {
+	defer close(c.received)
+	slots := make(chan struct{}, c.cfg.Concurrency)
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-receiveCtx.Done():
+			return
+		}
+		msg, err := c.sub.Receive(receiveCtx)
+		if err != nil {
+			<-slots
+			if receiveCtx.Err() != nil {
+				return
+			}
+			// the service shuts down as the subscription is broken
+			select {
+			case errs <- fmt.Errorf("error receiving messages: %w", err):
+			case <-receiveCtx.Done():
+			}
+			return
+		}
+
+		c.handling.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				c.handling.Done()
+			}()
+			c.process(handleCtx, msg)
+		}()
+	}
+}
+
+// process handles the message until it succeeds, it fails permanently or every attempt failed, it is then
+// acknowledged or dead lettered
+func (c *Consumer) process(ctx context.Context, msg *Message) {
+	var err error
+	for msg.Attempt = 1; ; msg.Attempt++ {
+		if err = c.handle(ctx, msg); err == nil {
+			if err = c.sub.Ack(ctx, msg); err != nil {
+				log.Println("error acknowledging message", msg.ID, ":", err)
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			// the consumer is stopping, the message is delivered again
+			return
+		}
+		if IsPermanent(err) || msg.Attempt >= c.cfg.MaxAttempts {
+			break
+		}
+
+		log.Println("error handling message", msg.ID, "of type", msg.Type, "attempt", msg.Attempt, ":", err)
+		if err := c.sub.Extend(ctx, msg); err != nil {
+			log.Println("error extending message", msg.ID, ":", err)
+		}
+		select {
+		case <-time.After(c.backoff(msg.Attempt)):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	log.Println("dead lettering message", msg.ID, "of type", msg.Type, "after", msg.Attempt, "attempts:", err)
+	if err = c.sub.DeadLetter(ctx, msg, err); err != nil {
+		log.Println("error dead lettering message", msg.ID, ":", err)
+	}
+}
+
+// handle handles the message with the handler, a panic of the handler is returned as an error
+func (c *Consumer) handle(ctx context.Context, msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.handler.HandleMessage(ctx, msg)
+}
+
+// backoff returns the wait after the attempt, the exponential backoff is jittered so that the messages which
+// failed together are not retried together
+func (c *Consumer) backoff(attempt int) time.Duration {
+	d := c.cfg.InitialBackoff << (attempt - 1)
+	if d <= 0 || d > c.cfg.MaxBackoff {
+		d = c.cfg.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+`)
+}
+
+// MemorySubscriptionTemplate returns template for pkg/consumer/memory.go
+func MemorySubscriptionTemplate() []byte {
+	return []byte(`package consumer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySubscription delivers the messages published to it, the tests publish the messages with it and assert
+// which of them are acknowledged and dead lettered
+type MemorySubscription struct {
+	messages chan *Message
+
+	mu           sync.Mutex
+	acked        []*Message
+	deadLettered []*Message
+}
+
+// NewMemorySubscription returns a MemorySubscription which buffers up to size messages
+func NewMemorySubscription(size int) *MemorySubscription {
+	return &MemorySubscription{messages: make(chan *Message, size)}
+}
+
+// Publish delivers the message, its id, type and key are set from its data if it is an event, it blocks while
+// the buffer is full
+func (s *MemorySubscription) Publish(msg *Message) {
+	msg.fromEnvelope()
+	s.messages <- msg
+}
+
+// Receive returns the next published message
+func (s *MemorySubscription) Receive(ctx context.Context) (*Message, error) {
+	select {
+	case msg := <-s.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Ack records the handled message
+func (s *MemorySubscription) Ack(_ context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acked = append(s.acked, msg)
+	return nil
+}
+
+// Extend does nothing
+func (s *MemorySubscription) Extend(context.Context, *Message) error {
+	return nil
+}
+
+// DeadLetter records the dead lettered message
+func (s *MemorySubscription) DeadLetter(_ context.Context, msg *Message, _ error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLettered = append(s.deadLettered, msg)
+	return nil
+}
+
+// Acked returns the acknowledged messages in order
+func (s *MemorySubscription) Acked() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.acked...)
+}
+
+// DeadLettered returns the dead lettered messages in order
+func (s *MemorySubscription) DeadLettered() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.deadLettered...)
+}
+
+// Close does nothing
+func (s *MemorySubscription) Close() error {
+	return nil
+}
+
+`)
+}
+
+// KafkaSubscriptionTemplate returns template for pkg/consumer/kafka.go
+func KafkaSubscriptionTemplate() []byte {
+	return []byte(`package consumer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig configures the KafkaSubscription
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+	// GroupID is the consumer group, the partitions of the topic are shared by the workers of the group
+	GroupID string
+	// DeadLetterTopic is the topic the dead lettered messages are written to
+	DeadLetterTopic string
+}
+
+// KafkaSubscription delivers the messages of a kafka topic to a consumer group. The messages are handled
+// concurrently, so the offset of a partition is committed once the messages before it are handled as well.
+type KafkaSubscription struct {
+	reader     *kafka.Reader
+	deadLetter *kafka.Writer
+
+	mu sync.Mutex
+	// pending are the offsets being handled by partition in the order they were received
+	pending map[int][]*kafkaOffset
+}
+
+type kafkaOffset struct {
+	offset int64
+	done   bool
+}
+
+// NewKafkaSubscription returns a KafkaSubscription of the topic
+func NewKafkaSubscription(cfg KafkaConfig) *KafkaSubscription {
+	return &KafkaSubscription{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: cfg.Brokers,
+			Topic:   cfg.Topic,
+			GroupID: cfg.GroupID,
+		}),
+		deadLetter: &kafka.Writer{
+			Addr:                   kafka.TCP(cfg.Brokers...),
+			Topic:                  cfg.DeadLetterTopic,
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		pending: map[int][]*kafkaOffset{},
+	}
+}
+
+// Receive fetches the next message, its type is the type header or the type of the event
+func (s *KafkaSubscription) Receive(ctx context.Context) (*Message, error) {
+	m, err := s.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.pending[m.Partition] = append(s.pending[m.Partition], &kafkaOffset{offset: m.Offset})
+	s.mu.Unlock()
+
+	msg := &Message{Key: string(m.Key), Data: m.Value, Headers: map[string]string{}, raw: m}
+	for _, h := range m.Headers {
+		msg.Headers[h.Key] = string(h.Value)
+	}
+	msg.Type = msg.Headers["type"]
+	msg.fromEnvelope()
+	if msg.ID == "" {
+		msg.ID = m.Topic + "/" + strconv.Itoa(m.Partition) + "/" + strconv.FormatInt(m.Offset, 10)
+	}
+	return msg, nil
+}
+
+// Ack commits the offset of the partition up to the last message handled after every message before it
+func (s *KafkaSubscription) Ack(ctx context.Context, msg *Message) error {
+	m := msg.raw.(kafka.Message)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offsets := s.pending[m.Partition]
+	for _, o := range offsets {
+		if o.offset == m.Offset {
+			o.done = true
+		}
+	}
+	n := 0
+	for n < len(offsets) && offsets[n].done {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	s.pending[m.Partition] = offsets[n:]
+	m.Offset = offsets[n-1].offset
+	return s.reader.CommitMessages(ctx, m)
+}
+
+// Extend does nothing, the messages of kafka are not redelivered while the worker is in the group
+func (s *KafkaSubscription) Extend(context.Context, *Message) error {
+	return nil
+}
+
+// DeadLetter writes the message to the dead letter topic along with the error and the attempts and commits it
+func (s *KafkaSubscription) DeadLetter(ctx context.Context, msg *Message, cause error) error {
+	m := msg.raw.(kafka.Message)
+	headers := append(m.Headers[:len(m.Headers):len(m.Headers)],
+		kafka.Header{Key: "error", Value: []byte(cause.Error())},
+		kafka.Header{Key: "attempts", Value: []byte(strconv.Itoa(msg.Attempt))},
+	)
+	if err := s.deadLetter.WriteMessages(ctx, kafka.Message{Key: m.Key, Value: m.Value, Headers: headers}); err != nil {
+		return err
+	}
+	return s.Ack(ctx, msg)
+}
+
+// Close leaves the consumer group and flushes the dead letter writer
+func (s *KafkaSubscription) Close() error {
+	return errors.Join(s.reader.Close(), s.deadLetter.Close())
+}
+
+`)
+}
+
+// NATSSubscriptionTemplate returns template for pkg/consumer/nats.go
+func NATSSubscriptionTemplate() []byte {
+	return []byte(`package consumer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSConfig configures the NATSSubscription
+type NATSConfig struct {
+	URL string
+	// Stream is the JetStream stream of the messages, it is created on the subjects if it doesn't exist
+	Stream   string
+	Subjects []string
+	// Consumer is the durable consumer of the stream, the messages are shared by the workers of the consumer
+	Consumer string
+	// DeadLetterSubject is the subject the dead lettered messages are published to
+	DeadLetterSubject string
+	// AckWait is how long the stream waits for the ack before it delivers the message again
+	AckWait time.Duration
+	// MaxAckPending is the maximum number of messages delivered without an ack
+	MaxAckPending int
+}
+
+// NATSSubscription delivers the messages of a durable JetStream consumer
+type NATSSubscription struct {
+	conn              *nats.Conn
+	messages          jetstream.MessagesContext
+	deadLetterSubject string
+}
+
+// NewNATSSubscription connects to nats and creates the stream, if it doesn't exist, and the durable consumer
+func NewNATSSubscription(ctx context.Context, cfg NATSConfig) (*NATSSubscription, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name(cfg.Consumer), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	messages, err := natsMessages(ctx, conn, cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &NATSSubscription{conn: conn, messages: messages, deadLetterSubject: cfg.DeadLetterSubject}, nil
+}
+
+// natsMessages returns the messages of the durable consumer of the stream
+func natsMessages(ctx context.Context, conn *nats.Conn, cfg NATSConfig) (jetstream.MessagesContext, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := js.Stream(ctx, cfg.Stream)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		stream, err = js.CreateStream(ctx, jetstream.StreamConfig{Name: cfg.Stream, Subjects: cfg.Subjects})
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the consumer retries and dead letters the messages, so the stream delivers them again only if the worker stops
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       cfg.Consumer,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       cfg.AckWait,
+		MaxAckPending: cfg.MaxAckPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return consumer.Messages()
+}
+
+// Receive returns the next message, its type is the type of the event or the subject
+func (s *NATSSubscription) Receive(ctx context.Context) (*Message, error) {
+	m, err := s.messages.Next(jetstream.NextContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{ID: m.Headers().Get(nats.MsgIdHdr), Data: m.Data(), Headers: map[string]string{}, raw: m}
+	for name := range m.Headers() {
+		msg.Headers[name] = m.Headers().Get(name)
+	}
+	msg.fromEnvelope()
+	if msg.Type == "" {
+		msg.Type = m.Subject()
+	}
+	if msg.ID == "" {
+		if meta, err := m.Metadata(); err == nil {
+			msg.ID = meta.Stream + "/" + strconv.FormatUint(meta.Sequence.Stream, 10)
+		}
+	}
+	return msg, nil
+}
+
+// Ack acknowledges the message
+func (s *NATSSubscription) Ack(_ context.Context, msg *Message) error {
+	return msg.raw.(jetstream.Msg).Ack()
+}
+
+// Extend resets the ack wait of the message
+func (s *NATSSubscription) Extend(_ context.Context, msg *Message) error {
+	return msg.raw.(jetstream.Msg).InProgress()
+}
+
+// DeadLetter publishes the message to the dead letter subject along with the error and the attempts and
+// terminates it, so the stream doesn't deliver it again
+func (s *NATSSubscription) DeadLetter(ctx context.Context, msg *Message, cause error) error {
+	m := msg.raw.(jetstream.Msg)
+	dead := nats.NewMsg(s.deadLetterSubject)
+	dead.Data = m.Data()
+	for name, values := range m.Headers() {
+		dead.Header[name] = values
+	}
+	dead.Header.Set("Subject", m.Subject())
+	dead.Header.Set("Error", cause.Error())
+	dead.Header.Set("Attempts", strconv.Itoa(msg.Attempt))
+	if err := s.conn.PublishMsg(dead); err != nil {
+		return err
+	}
+	return m.Term()
+}
+
+// Close stops the delivery of the messages and closes the connection, the messages which are not acknowledged
+// are delivered again
+func (s *NATSSubscription) Close() error {
+	s.messages.Stop()
+	return s.conn.Drain()
+}
+
+`)
+}